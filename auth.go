@@ -0,0 +1,176 @@
+// 认证策略定义
+package aliexhbase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Authenticator 认证策略,在http传输层的每个请求发出前为请求添加认证信息
+// socket传输层不支持认证策略,会被忽略
+type Authenticator interface {
+	// Authenticate 为即将发出的请求添加认证信息,req已经是原请求的副本,可以直接修改
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc 使用函数实现认证策略
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate 为即将发出的请求添加认证信息
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+type noAuth struct{}
+
+func (noAuth) Authenticate(*http.Request) error {
+	return nil
+}
+
+// NoAuth 不做认证,用于本地或自建的thrift服务
+var NoAuth Authenticator = noAuth{}
+
+type staticHeaderAuth struct {
+	headers map[string]string
+}
+
+func (a *staticHeaderAuth) Authenticate(req *http.Request) error {
+	for k, v := range a.headers {
+		req.Header.Set(k, v)
+	}
+	return nil
+}
+
+// NewStaticHeaderAuth 每个请求都带上固定的请求头
+func NewStaticHeaderAuth(headers map[string]string) Authenticator {
+	h := make(map[string]string, len(headers))
+	for k, v := range headers {
+		h[k] = v
+	}
+	return &staticHeaderAuth{headers: h}
+}
+
+// NewAccessKeyAuth 阿里云增强版hbase使用的认证方式,通过请求头ACCESSKEYID和ACCESSSIGNATURE传递用户名和密码
+func NewAccessKeyAuth(user, passwd string) Authenticator {
+	return NewStaticHeaderAuth(map[string]string{
+		"ACCESSKEYID":     user,
+		"ACCESSSIGNATURE": passwd,
+	})
+}
+
+// NewBasicAuth 使用http basic认证
+func NewBasicAuth(user, passwd string) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		req.SetBasicAuth(user, passwd)
+		return nil
+	})
+}
+
+// TokenSource 获取bearer token的回调,返回token和它的过期时间,过期时间为零值表示永不过期
+type TokenSource func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// 在token过期前提前刷新的时间
+const tokenRefreshAhead = 30 * time.Second
+
+type bearerTokenAuth struct {
+	source TokenSource
+	lock   sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// token 获取缓存的token,快过期时通过回调刷新
+func (a *bearerTokenAuth) getToken(ctx context.Context) (string, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.token != "" && (a.expiry.IsZero() || nowFunc().Add(tokenRefreshAhead).Before(a.expiry)) {
+		return a.token, nil
+	}
+	token, expiry, err := a.source(ctx)
+	if err != nil {
+		return "", fmt.Errorf("刷新bearer token失败: %w", err)
+	}
+	a.token = token
+	a.expiry = expiry
+	return token, nil
+}
+
+func (a *bearerTokenAuth) Authenticate(req *http.Request) error {
+	token, err := a.getToken(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// NewBearerTokenAuth 使用bearer token认证,token会被缓存,在过期前通过source刷新
+func NewBearerTokenAuth(source TokenSource) Authenticator {
+	return &bearerTokenAuth{source: source}
+}
+
+// Credentials 用户名密码形式的认证凭证
+type Credentials struct {
+	User   string
+	Passwd string
+}
+
+// CredentialsProvider 认证凭证的提供者,每个请求都会重新获取凭证,因此可以在不重建连接池的情况下轮换密码
+// 实现需要自行处理缓存并保证并发安全
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// CredentialsProviderFunc 使用函数实现认证凭证的提供者
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+// Credentials 获取认证凭证
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+// NewCredentialsAuth 使用CredentialsProvider提供的凭证,以ACCESSKEYID和ACCESSSIGNATURE请求头认证
+func NewCredentialsAuth(provider CredentialsProvider) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) error {
+		cred, err := provider.Credentials(req.Context())
+		if err != nil {
+			return fmt.Errorf("获取认证凭证失败: %w", err)
+		}
+		req.Header.Set("ACCESSKEYID", cred.User)
+		req.Header.Set("ACCESSSIGNATURE", cred.Passwd)
+		return nil
+	})
+}
+
+// authTransport 在请求发出前调用认证策略的RoundTripper
+type authTransport struct {
+	base http.RoundTripper
+	auth Authenticator
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper不能修改原请求,thrift的http客户端在多个请求间共用请求头
+	r := req.Clone(req.Context())
+	if err := t.auth.Authenticate(r); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(r)
+}
+
+// authenticator 确定http传输层使用的认证策略
+// 未设置Auth时如果设置了用户名密码则使用阿里云增强版hbase的认证方式,否则不认证
+func (c *ThriftPoolConfig) authenticator() Authenticator {
+	if c.Auth != nil {
+		return c.Auth
+	}
+	if c.User != "" || c.Passwd != "" {
+		return NewAccessKeyAuth(c.User, c.Passwd)
+	}
+	return NoAuth
+}
@@ -0,0 +1,233 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+	logrus "github.com/sirupsen/logrus"
+)
+
+func TestAuthenticators(t *testing.T) {
+	static := map[string]string{"X-Token": "t1"}
+	staticAuth := NewStaticHeaderAuth(static)
+	// 修改传入的map不影响已经创建的认证策略
+	static["X-Token"] = "t2"
+	for _, tc := range []struct {
+		name string
+		auth Authenticator
+		want map[string]string
+	}{
+		{"none", NoAuth, map[string]string{}},
+		{"static", staticAuth, map[string]string{"X-Token": "t1"}},
+		{"access key", NewAccessKeyAuth("user", "pwd"), map[string]string{"Accesskeyid": "user", "Accesssignature": "pwd"}},
+		{"basic", NewBasicAuth("user", "pwd"), map[string]string{"Authorization": "Basic dXNlcjpwd2Q="}},
+		{"bearer", NewBearerTokenAuth(func(ctx context.Context) (string, time.Time, error) {
+			return "tok", time.Time{}, nil
+		}), map[string]string{"Authorization": "Bearer tok"}},
+		{"credentials", NewCredentialsAuth(CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+			return Credentials{User: "u2", Passwd: "p2"}, nil
+		})), map[string]string{"Accesskeyid": "u2", "Accesssignature": "p2"}},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, "http://hbase:9190", nil)
+		if err := tc.auth.Authenticate(req); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(req.Header) != len(tc.want) {
+			t.Errorf("%s: headers = %v, want %v", tc.name, req.Header, tc.want)
+		}
+		for k, v := range tc.want {
+			if got := req.Header.Get(k); got != v {
+				t.Errorf("%s: %s = %q, want %q", tc.name, k, got, v)
+			}
+		}
+	}
+}
+
+func TestBearerTokenRefresh(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		expiry time.Duration
+		calls  int
+	}{
+		// 离过期时间还远时使用缓存的token
+		{"cached", time.Hour, 1},
+		// 快过期时每次都刷新
+		{"refresh ahead", tokenRefreshAhead / 2, 3},
+	} {
+		calls := 0
+		auth := NewBearerTokenAuth(func(ctx context.Context) (string, time.Time, error) {
+			calls++
+			return "tok", time.Now().Add(tc.expiry), nil
+		})
+		for i := 0; i < 3; i++ {
+			req, _ := http.NewRequest(http.MethodPost, "http://hbase:9190", nil)
+			if err := auth.Authenticate(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if calls != tc.calls {
+			t.Errorf("%s: token刷新了%d次,期望%d次", tc.name, calls, tc.calls)
+		}
+	}
+
+	failure := errors.New("token service down")
+	auth := NewBearerTokenAuth(func(ctx context.Context) (string, time.Time, error) {
+		return "", time.Time{}, failure
+	})
+	req, _ := http.NewRequest(http.MethodPost, "http://hbase:9190", nil)
+	if err := auth.Authenticate(req); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want %v", err, failure)
+	}
+}
+
+// headerServer 记录每个请求的请求头的假服务端
+type headerServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	headers []http.Header
+}
+
+func newHeaderServer(t *testing.T) *headerServer {
+	t.Helper()
+	processor := hbase.NewTHBaseServiceProcessor(tableHandler{})
+	factory := thrift.NewTBinaryProtocolFactoryDefault()
+	handler := thrift.NewThriftHandlerFunc(processor, factory, factory)
+	s := &headerServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.headers = append(s.headers, r.Header.Clone())
+		s.mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *headerServer) requests() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header(nil), s.headers...)
+}
+
+// layer 在请求头X-Layers中追加name的RoundTripper
+func layer(name string, base http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		r := req.Clone(req.Context())
+		r.Header.Add("X-Layers", name)
+		return base.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newAuthTestClient(t *testing.T, URL string, opts ...Option) *Client {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c, err := New(append([]Option{WithURL(URL), WithLogger(logger), WithMaxAttempts(1)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.HardClose() })
+	return c
+}
+
+func TestHTTPLayerOrder(t *testing.T) {
+	srv := newHeaderServer(t)
+	middleware := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return layer(name, next)
+		}
+	}
+	c := newAuthTestClient(t, srv.URL,
+		WithRoundTripper(layer("base", http.DefaultTransport)),
+		WithAuth(AuthenticatorFunc(func(req *http.Request) error {
+			req.Header.Add("X-Layers", "auth")
+			return nil
+		})),
+		WithHTTPMiddlewares(middleware("outer")),
+		WithHTTPMiddlewares(middleware("inner")),
+	)
+	for i := 0; i < 2; i++ {
+		if _, err := c.TableExists(context.Background(), &hbase.TTableName{Qualifier: []byte("t")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 中间件在最外层,先注册的在外,认证在中间件和传输层之间;thrift的http客户端在请求间共用请求头,每层都不能修改原请求
+	requests := srv.requests()
+	if len(requests) != 2 {
+		t.Fatalf("服务端收到%d个请求", len(requests))
+	}
+	for i, h := range requests {
+		if got := strings.Join(h.Values("X-Layers"), ","); got != "outer,inner,auth,base" {
+			t.Errorf("第%d个请求: X-Layers = %s", i, got)
+		}
+	}
+}
+
+func TestHTTPAuthSelection(t *testing.T) {
+	srv := newHeaderServer(t)
+	URL := strings.Replace(srv.URL, "http://", "http://user:pwd@", 1)
+	table := &hbase.TTableName{Qualifier: []byte("t")}
+
+	// url中的用户名密码默认使用阿里云增强版hbase的认证方式
+	c := newAuthTestClient(t, URL)
+	if _, err := c.TableExists(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+	// WithAuth覆盖url中的用户名密码
+	c = newAuthTestClient(t, URL, WithAuth(NewBasicAuth("u2", "p2")))
+	if _, err := c.TableExists(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+	// 没有用户名密码时不认证
+	c = newAuthTestClient(t, srv.URL)
+	if _, err := c.TableExists(context.Background(), table); err != nil {
+		t.Fatal(err)
+	}
+	requests := srv.requests()
+	if len(requests) != 3 {
+		t.Fatalf("服务端收到%d个请求", len(requests))
+	}
+	if h := requests[0]; h.Get("ACCESSKEYID") != "user" || h.Get("ACCESSSIGNATURE") != "pwd" || h.Get("Authorization") != "" {
+		t.Errorf("url认证的请求头 %v", h)
+	}
+	if h := requests[1]; h.Get("ACCESSKEYID") != "" || h.Get("Authorization") != "Basic dTI6cDI=" {
+		t.Errorf("WithAuth认证的请求头 %v", h)
+	}
+	if h := requests[2]; h.Get("ACCESSKEYID") != "" || h.Get("Authorization") != "" {
+		t.Errorf("不认证的请求头 %v", h)
+	}
+
+	// 认证失败时请求不会发出
+	failure := errors.New("no credentials")
+	c = newAuthTestClient(t, srv.URL, WithAuth(failingAuth(failure)))
+	// thrift的http传输层错误没有实现Unwrap,只能通过错误信息判断
+	if _, err := c.TableExists(context.Background(), table); err == nil || !strings.Contains(err.Error(), failure.Error()) {
+		t.Fatalf("err = %v, want %v", err, failure)
+	}
+	if n := len(srv.requests()); n != 3 {
+		t.Fatalf("认证失败时请求仍然被发出,服务端收到%d个请求", n)
+	}
+}
+
+// failingAuth 获取凭证总是失败的认证策略
+func failingAuth(err error) Authenticator {
+	return NewCredentialsAuth(CredentialsProviderFunc(func(ctx context.Context) (Credentials, error) {
+		return Credentials{}, err
+	}))
+}
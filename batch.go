@@ -0,0 +1,299 @@
+// 可以识别部分失败的批量操作
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// defaultBatchChunkSize 批量操作默认的分块大小
+const defaultBatchChunkSize = 1000
+
+// ItemError 批量操作中单个元素的错误
+type ItemError struct {
+	// 元素在输入中的下标
+	Index int
+	// 失败原因
+	Err error
+}
+
+// BatchResult 批量操作的结果
+type BatchResult struct {
+	// 成功的元素下标,升序
+	Succeeded []int
+	// 失败的元素及原因,按下标升序
+	Failed []ItemError
+}
+
+// Err 有元素失败时返回*BatchError,否则返回nil
+func (r *BatchResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	return &BatchError{Failed: r.Failed, Total: len(r.Succeeded) + len(r.Failed)}
+}
+
+// BatchError 批量操作中部分元素失败
+type BatchError struct {
+	// 失败的元素及原因,按下标升序
+	Failed []ItemError
+	// 元素总数
+	Total int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("批量操作中%d/%d个元素失败,第一个失败的元素下标为%d: %s", len(e.Failed), e.Total, e.Failed[0].Index, e.Failed[0].Err)
+}
+
+// Unwrap 返回第一个失败元素的错误,可以用errors.Is判断错误类型
+func (e *BatchError) Unwrap() error {
+	return e.Failed[0].Err
+}
+
+// batchOptions 批量操作配置
+type batchOptions struct {
+	chunkSize   int
+	concurrency int
+}
+
+// BatchOption 批量操作配置项
+type BatchOption func(*batchOptions)
+
+// WithBatchChunkSize 设置每个请求最多包含的元素数,默认为1000
+func WithBatchChunkSize(ChunkSize int) BatchOption {
+	return func(o *batchOptions) {
+		o.chunkSize = ChunkSize
+	}
+}
+
+// WithBatchConcurrency 设置同时执行的分块数,BatchPut/BatchDelete默认为1,GetMultipleChunked/ExistsAllChunked默认为4
+func WithBatchConcurrency(Concurrency int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = Concurrency
+	}
+}
+
+func newBatchOptions(opts []BatchOption, concurrency int) batchOptions {
+	o := batchOptions{chunkSize: defaultBatchChunkSize, concurrency: concurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.chunkSize <= 0 {
+		o.chunkSize = defaultBatchChunkSize
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = 1
+	}
+	return o
+}
+
+// batchCollector 并发收集批量操作的结果
+type batchCollector struct {
+	lock   sync.Mutex
+	result BatchResult
+}
+
+func (c *batchCollector) succeed(indexes []int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.result.Succeeded = append(c.result.Succeeded, indexes...)
+}
+
+func (c *batchCollector) fail(indexes []int, err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, i := range indexes {
+		c.result.Failed = append(c.result.Failed, ItemError{Index: i, Err: err})
+	}
+}
+
+// sorted 获取按下标排序后的结果
+func (c *batchCollector) sorted() *BatchResult {
+	sort.Ints(c.result.Succeeded)
+	sort.Slice(c.result.Failed, func(i, j int) bool {
+		return c.result.Failed[i].Index < c.result.Failed[j].Index
+	})
+	return &c.result
+}
+
+// runChunks 将[0,n)按分块大小拆分,以给定并发数执行每个分块
+func runChunks(ctx context.Context, n int, o batchOptions, fn func(ctx context.Context, indexes []int)) {
+	chunks := make(chan []int)
+	var wg sync.WaitGroup
+	for i := 0; i < o.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for indexes := range chunks {
+				fn(ctx, indexes)
+			}
+		}()
+	}
+	for start := 0; start < n; start += o.chunkSize {
+		end := start + o.chunkSize
+		if end > n {
+			end = n
+		}
+		indexes := make([]int, 0, end-start)
+		for i := start; i < end; i++ {
+			indexes = append(indexes, i)
+		}
+		chunks <- indexes
+	}
+	close(chunks)
+	wg.Wait()
+}
+
+// chunkLevelError 判断错误是否与具体元素无关,这类错误拆分请求也无法成功,不再二分.
+// 除了表级别的错误,限流,超时,传输层错误,region不可用和连接池耗尽也整块标记为失败,避免拆分后向已经过载的服务端发出更多请求
+func chunkLevelError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return true
+	}
+	for _, kind := range []error{
+		exceptions.ErrTableNotFound,
+		exceptions.ErrNamespaceNotFound,
+		exceptions.ErrTableDisabled,
+		exceptions.ErrAuthFailed,
+		exceptions.ErrThrottled,
+		exceptions.ErrTimeout,
+		exceptions.ErrTransport,
+		exceptions.ErrRegionUnavailable,
+		ErrPoolClosed,
+		ErrOverMax,
+	} {
+		if errors.Is(err, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// bisect 执行一组元素,失败时二分拆分重试以找出具体失败的元素
+func bisect(ctx context.Context, indexes []int, c *batchCollector, exec func(ctx context.Context, indexes []int) error) {
+	err := exec(ctx, indexes)
+	if err == nil {
+		c.succeed(indexes)
+		return
+	}
+	if len(indexes) == 1 || chunkLevelError(ctx, err) {
+		c.fail(indexes, err)
+		return
+	}
+	mid := len(indexes) / 2
+	bisect(ctx, indexes[:mid], c, exec)
+	bisect(ctx, indexes[mid:], c, exec)
+}
+
+// BatchPut 分块执行PutMultiple,请求失败时二分拆分重试以找出具体失败的TPut
+// 表不存在,鉴权失败,限流,超时或ctx结束等与具体元素无关的错误不会拆分,整块标记为失败.
+// 默认串行执行分块,返回的error为结果的Err()
+func (p *Client) BatchPut(ctx context.Context, table []byte, tputs []*hbase.TPut, opts ...BatchOption) (*BatchResult, error) {
+	o := newBatchOptions(opts, 1)
+	c := &batchCollector{}
+	exec := func(ctx context.Context, indexes []int) error {
+		chunk := make([]*hbase.TPut, len(indexes))
+		for i, index := range indexes {
+			chunk[i] = tputs[index]
+		}
+		return p.PutMultiple(ctx, table, chunk)
+	}
+	runChunks(ctx, len(tputs), o, func(ctx context.Context, indexes []int) {
+		bisect(ctx, indexes, c, exec)
+	})
+	result := c.sorted()
+	return result, result.Err()
+}
+
+// BatchDelete 分块执行DeleteMultiple,请求失败或返回未执行的删除时二分拆分重试以找出具体失败的TDelete
+// 表不存在,鉴权失败,限流,超时或ctx结束等与具体元素无关的错误不会拆分,整块标记为失败.
+// 默认串行执行分块,返回的error为结果的Err()
+func (p *Client) BatchDelete(ctx context.Context, table []byte, tdeletes []*hbase.TDelete, opts ...BatchOption) (*BatchResult, error) {
+	o := newBatchOptions(opts, 1)
+	c := &batchCollector{}
+	exec := func(ctx context.Context, indexes []int) error {
+		chunk := make([]*hbase.TDelete, len(indexes))
+		for i, index := range indexes {
+			chunk[i] = tdeletes[index]
+		}
+		notApplied, err := p.DeleteMultiple(ctx, table, chunk)
+		if err != nil {
+			return err
+		}
+		if len(notApplied) > 0 {
+			return ErrDeleteNotApplied
+		}
+		return nil
+	}
+	runChunks(ctx, len(tdeletes), o, func(ctx context.Context, indexes []int) {
+		bisect(ctx, indexes, c, exec)
+	})
+	result := c.sorted()
+	return result, result.Err()
+}
+
+// defaultBatchReadConcurrency 分块批量读取默认的并发数
+const defaultBatchReadConcurrency = 4
+
+// GetMultipleChunked 将TGet分块后并发执行GetMultiple,结果按输入顺序返回
+// 单个分块失败不影响其他分块,失败分块对应的结果为nil,返回的错误为*BatchError,其中列出了失败的下标.
+// 默认每块1000个,并发数为4
+func (p *Client) GetMultipleChunked(ctx context.Context, table []byte, tgets []*hbase.TGet, opts ...BatchOption) ([]*hbase.TResult_, error) {
+	o := newBatchOptions(opts, defaultBatchReadConcurrency)
+	c := &batchCollector{}
+	results := make([]*hbase.TResult_, len(tgets))
+	runChunks(ctx, len(tgets), o, func(ctx context.Context, indexes []int) {
+		chunk := make([]*hbase.TGet, len(indexes))
+		for i, index := range indexes {
+			chunk[i] = tgets[index]
+		}
+		rs, err := p.GetMultiple(ctx, table, chunk)
+		if err == nil && len(rs) != len(chunk) {
+			err = ErrBatchResultMismatch
+		}
+		if err != nil {
+			c.fail(indexes, err)
+			return
+		}
+		// 每个分块写入results中互不重叠的位置,不需要加锁
+		for i, index := range indexes {
+			results[index] = rs[i]
+		}
+		c.succeed(indexes)
+	})
+	return results, c.sorted().Err()
+}
+
+// ExistsAllChunked 将TGet分块后并发执行ExistsAll,结果按输入顺序返回
+// 单个分块失败不影响其他分块,失败分块对应的结果为false,返回的错误为*BatchError,其中列出了失败的下标.
+// 默认每块1000个,并发数为4
+func (p *Client) ExistsAllChunked(ctx context.Context, table []byte, tgets []*hbase.TGet, opts ...BatchOption) ([]bool, error) {
+	o := newBatchOptions(opts, defaultBatchReadConcurrency)
+	c := &batchCollector{}
+	results := make([]bool, len(tgets))
+	runChunks(ctx, len(tgets), o, func(ctx context.Context, indexes []int) {
+		chunk := make([]*hbase.TGet, len(indexes))
+		for i, index := range indexes {
+			chunk[i] = tgets[index]
+		}
+		rs, err := p.ExistsAll(ctx, table, chunk)
+		if err == nil && len(rs) != len(chunk) {
+			err = ErrBatchResultMismatch
+		}
+		if err != nil {
+			c.fail(indexes, err)
+			return
+		}
+		for i, index := range indexes {
+			results[index] = rs[i]
+		}
+		c.succeed(indexes)
+	})
+	return results, c.sorted().Err()
+}
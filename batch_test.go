@@ -0,0 +1,507 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// batchHandler 拒绝包含指定行的批量请求的假服务端
+type batchHandler struct {
+	hbase.THBaseService
+	mu sync.Mutex
+	// 请求中包含这些行时整个请求失败
+	reject map[string]bool
+	// DeleteMultiple不执行这些行的删除,将其作为未执行的删除返回
+	notApplied map[string]bool
+	// 不为nil时所有请求都返回该错误
+	err error
+	// 读取请求的第一行为key时先等待对应的时间再返回,用于打乱分块完成的顺序
+	delay map[string]time.Duration
+	// 每次请求包含的行
+	calls [][]string
+	// 成功写入或删除的行
+	applied []string
+}
+
+func newBatchHandler(reject ...string) *batchHandler {
+	h := &batchHandler{reject: map[string]bool{}, notApplied: map[string]bool{}}
+	for _, row := range reject {
+		h.reject[row] = true
+	}
+	return h
+}
+
+// handle 记录请求,请求中有被拒绝的行时返回错误
+func (h *batchHandler) handle(rows []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls = append(h.calls, rows)
+	if h.err != nil {
+		return h.err
+	}
+	for _, row := range rows {
+		if h.reject[row] {
+			return &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.DoNotRetryIOException: bad row " + row)}
+		}
+	}
+	return nil
+}
+
+func (h *batchHandler) PutMultiple(ctx context.Context, table []byte, tputs []*hbase.TPut) error {
+	rows := make([]string, len(tputs))
+	for i, tput := range tputs {
+		rows[i] = string(tput.Row)
+	}
+	if err := h.handle(rows); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.applied = append(h.applied, rows...)
+	return nil
+}
+
+func (h *batchHandler) DeleteMultiple(ctx context.Context, table []byte, tdeletes []*hbase.TDelete) ([]*hbase.TDelete, error) {
+	rows := make([]string, len(tdeletes))
+	for i, tdelete := range tdeletes {
+		rows[i] = string(tdelete.Row)
+	}
+	if err := h.handle(rows); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var notApplied []*hbase.TDelete
+	for _, tdelete := range tdeletes {
+		if h.notApplied[string(tdelete.Row)] {
+			notApplied = append(notApplied, tdelete)
+			continue
+		}
+		h.applied = append(h.applied, string(tdelete.Row))
+	}
+	return notApplied, nil
+}
+
+// readRows 获取读取请求中的行,按delay等待后记录请求
+func (h *batchHandler) readRows(tgets []*hbase.TGet) ([]string, error) {
+	rows := make([]string, len(tgets))
+	for i, tget := range tgets {
+		rows[i] = string(tget.Row)
+	}
+	if len(rows) > 0 {
+		time.Sleep(h.delay[rows[0]])
+	}
+	return rows, h.handle(rows)
+}
+
+func (h *batchHandler) GetMultiple(ctx context.Context, table []byte, tgets []*hbase.TGet) ([]*hbase.TResult_, error) {
+	rows, err := h.readRows(tgets)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*hbase.TResult_, len(rows))
+	for i, row := range rows {
+		results[i] = &hbase.TResult_{Row: []byte(row)}
+	}
+	return results, nil
+}
+
+// ExistsAll 下标为偶数的行存在
+func (h *batchHandler) ExistsAll(ctx context.Context, table []byte, tgets []*hbase.TGet) ([]bool, error) {
+	rows, err := h.readRows(tgets)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]bool, len(rows))
+	for i, row := range rows {
+		var index int
+		fmt.Sscanf(row, "row%d", &index)
+		results[i] = index%2 == 0
+	}
+	return results, nil
+}
+
+func (h *batchHandler) callCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.calls)
+}
+
+func (h *batchHandler) appliedRows() map[string]int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	applied := map[string]int{}
+	for _, row := range h.applied {
+		applied[row]++
+	}
+	return applied
+}
+
+func testPuts(n int) []*hbase.TPut {
+	tputs := make([]*hbase.TPut, n)
+	for i := range tputs {
+		tputs[i] = &hbase.TPut{Row: testRow(i)}
+	}
+	return tputs
+}
+
+func testGets(n int) []*hbase.TGet {
+	tgets := make([]*hbase.TGet, n)
+	for i := range tgets {
+		tgets[i] = &hbase.TGet{Row: testRow(i)}
+	}
+	return tgets
+}
+
+func testDeletes(n int) []*hbase.TDelete {
+	tdeletes := make([]*hbase.TDelete, n)
+	for i := range tdeletes {
+		tdeletes[i] = &hbase.TDelete{Row: testRow(i)}
+	}
+	return tdeletes
+}
+
+// failedIndexes 获取结果中失败元素的下标
+func failedIndexes(r *BatchResult) []int {
+	indexes := []int{}
+	for _, f := range r.Failed {
+		indexes = append(indexes, f.Index)
+	}
+	return indexes
+}
+
+// checkBatchResult 检查只有wantFailed中的元素失败,其余元素都成功且只执行了一次
+func checkBatchResult(t *testing.T, h *batchHandler, result *BatchResult, err error, n int, wantFailed []int) {
+	t.Helper()
+	if got := failedIndexes(result); !reflect.DeepEqual(got, wantFailed) {
+		t.Fatalf("失败的下标为%v,期望%v", got, wantFailed)
+	}
+	failed := map[int]bool{}
+	for _, i := range wantFailed {
+		failed[i] = true
+	}
+	wantSucceeded := []int{}
+	for i := 0; i < n; i++ {
+		if !failed[i] {
+			wantSucceeded = append(wantSucceeded, i)
+		}
+	}
+	succeeded := append([]int{}, result.Succeeded...)
+	if !reflect.DeepEqual(succeeded, wantSucceeded) {
+		t.Fatalf("成功的下标为%v,期望%v", succeeded, wantSucceeded)
+	}
+	applied := h.appliedRows()
+	for i := 0; i < n; i++ {
+		want := 1
+		if failed[i] {
+			want = 0
+		}
+		if got := applied[string(testRow(i))]; got != want {
+			t.Fatalf("%s被执行了%d次,期望%d次", testRow(i), got, want)
+		}
+	}
+	if len(wantFailed) == 0 {
+		if err != nil {
+			t.Fatalf("没有元素失败时返回了错误: %v", err)
+		}
+		return
+	}
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("错误%v不是*BatchError", err)
+	}
+	if batchErr.Total != n || !reflect.DeepEqual(failedIndexes(&BatchResult{Failed: batchErr.Failed}), wantFailed) {
+		t.Fatalf("BatchError为%+v,期望%d个元素中%v失败", batchErr, n, wantFailed)
+	}
+}
+
+func TestBatchPut(t *testing.T) {
+	cases := []struct {
+		name       string
+		n          int
+		chunkSize  int
+		reject     []int
+		wantFailed []int
+	}{
+		{"all succeed", 10, 4, nil, []int{}},
+		{"one bad row", 10, 4, []int{6}, []int{6}},
+		{"bad rows in different chunks", 10, 4, []int{2, 7}, []int{2, 7}},
+		{"adjacent bad rows", 16, 16, []int{8, 9}, []int{8, 9}},
+		{"whole chunk bad", 6, 3, []int{3, 4, 5}, []int{3, 4, 5}},
+		{"single row chunks", 5, 1, []int{0, 4}, []int{0, 4}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newBatchHandler()
+			for _, i := range tc.reject {
+				h.reject[string(testRow(i))] = true
+			}
+			c := newTestClient(t, h)
+			result, err := c.BatchPut(context.Background(), []byte("t"), testPuts(tc.n), WithBatchChunkSize(tc.chunkSize))
+			checkBatchResult(t, h, result, err, tc.n, tc.wantFailed)
+			for _, f := range result.Failed {
+				if !errors.Is(f.Err, exceptions.ErrIO) {
+					t.Fatalf("下标%d的错误%v不是ErrIO", f.Index, f.Err)
+				}
+			}
+			if len(tc.wantFailed) > 0 && !errors.Is(err, exceptions.ErrIO) {
+				t.Fatalf("BatchError没有包装第一个失败元素的错误: %v", err)
+			}
+		})
+	}
+}
+
+func TestBatchPutBisectRequests(t *testing.T) {
+	h := newBatchHandler(string(testRow(5)))
+	c := newTestClient(t, h)
+	if _, err := c.BatchPut(context.Background(), []byte("t"), testPuts(8), WithBatchChunkSize(8)); err == nil {
+		t.Fatal("有元素失败时没有返回错误")
+	}
+	// 8个元素中1个失败,每层二分只会继续拆分包含该元素的一半
+	want := [][]string{
+		{"row0000", "row0001", "row0002", "row0003", "row0004", "row0005", "row0006", "row0007"},
+		{"row0000", "row0001", "row0002", "row0003"},
+		{"row0004", "row0005", "row0006", "row0007"},
+		{"row0004", "row0005"},
+		{"row0004"},
+		{"row0005"},
+		{"row0006", "row0007"},
+	}
+	if !reflect.DeepEqual(h.calls, want) {
+		t.Fatalf("请求为%v,期望%v", h.calls, want)
+	}
+}
+
+func TestBatchChunkLevelErrorNoBisect(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"table not found", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.TableNotFoundException: t")}, exceptions.ErrTableNotFound},
+		{"table disabled", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.TableNotEnabledException: t")}, exceptions.ErrTableDisabled},
+		{"access denied", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.security.AccessDeniedException: denied")}, exceptions.ErrAuthFailed},
+		{"throttled", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.quotas.RpcThrottlingException: request throttled")}, exceptions.ErrThrottled},
+		{"region unavailable", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.NotServingRegionException: r")}, exceptions.ErrRegionUnavailable},
+		{"timeout", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.ipc.CallTimeoutException: timed out")}, exceptions.ErrTimeout},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newBatchHandler()
+			h.err = tc.err
+			// 不重试,只统计拆分产生的请求
+			c := newTestClient(t, h, WithMaxAttempts(1))
+			result, err := c.BatchPut(context.Background(), []byte("t"), testPuts(10), WithBatchChunkSize(4))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("错误为%v,期望%v", err, tc.want)
+			}
+			// 3个分块各请求一次,不拆分
+			if n := h.callCount(); n != 3 {
+				t.Fatalf("请求了%d次,期望3次", n)
+			}
+			checkBatchResult(t, h, result, err, 10, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+		})
+	}
+}
+
+func TestBatchThrottledRequests(t *testing.T) {
+	h := newBatchHandler()
+	h.err = &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.CallQueueTooBigException: call queue is full")}
+	c := newTestClient(t, h, WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+	result, err := c.BatchPut(context.Background(), []byte("t"), testPuts(100), WithBatchChunkSize(50))
+	if !errors.Is(err, exceptions.ErrThrottled) {
+		t.Fatalf("错误为%v,期望ErrThrottled", err)
+	}
+	// 2个分块各按重试策略请求2次,不拆分
+	if n := h.callCount(); n != 4 {
+		t.Fatalf("请求了%d次,期望4次", n)
+	}
+	if len(result.Failed) != 100 || len(result.Succeeded) != 0 {
+		t.Fatalf("成功%d个,失败%d个,期望全部失败", len(result.Succeeded), len(result.Failed))
+	}
+}
+
+func TestBatchCanceledNoBisect(t *testing.T) {
+	h := newBatchHandler(string(testRow(1)))
+	c := newTestClient(t, h)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := c.BatchDelete(ctx, []byte("t"), testDeletes(4))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("错误为%v,期望context.Canceled", err)
+	}
+	if n := h.callCount(); n > 1 {
+		t.Fatalf("ctx结束后仍拆分请求了%d次", n)
+	}
+	if got := failedIndexes(result); !reflect.DeepEqual(got, []int{0, 1, 2, 3}) {
+		t.Fatalf("失败的下标为%v,期望全部失败", got)
+	}
+}
+
+func TestBatchDelete(t *testing.T) {
+	cases := []struct {
+		name       string
+		reject     []int
+		notApplied []int
+		wantFailed []int
+	}{
+		{"all applied", nil, nil, []int{}},
+		{"not applied", nil, []int{3}, []int{3}},
+		{"not applied and rejected", []int{8}, []int{1, 6}, []int{1, 6, 8}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newBatchHandler()
+			for _, i := range tc.reject {
+				h.reject[string(testRow(i))] = true
+			}
+			for _, i := range tc.notApplied {
+				h.notApplied[string(testRow(i))] = true
+			}
+			c := newTestClient(t, h)
+			result, err := c.BatchDelete(context.Background(), []byte("t"), testDeletes(10), WithBatchChunkSize(4))
+			notApplied := map[int]bool{}
+			for _, i := range tc.notApplied {
+				notApplied[i] = true
+			}
+			// 未执行的删除重试时仍未执行,包含它们的请求中其他行已被删除,这里只检查结果
+			if got := failedIndexes(result); !reflect.DeepEqual(got, tc.wantFailed) {
+				t.Fatalf("失败的下标为%v,期望%v", got, tc.wantFailed)
+			}
+			if len(tc.wantFailed) == 0 && err != nil {
+				t.Fatalf("没有元素失败时返回了错误: %v", err)
+			}
+			for _, f := range result.Failed {
+				if notApplied[f.Index] != errors.Is(f.Err, ErrDeleteNotApplied) {
+					t.Fatalf("下标%d的错误为%v", f.Index, f.Err)
+				}
+			}
+			if n := len(result.Succeeded) + len(result.Failed); n != 10 {
+				t.Fatalf("结果中有%d个元素,期望10个", n)
+			}
+		})
+	}
+}
+
+// newChunkedReadHandler 创建按每块3行读取时,越靠前的分块返回越慢且拒绝reject所在分块的假服务端
+func newChunkedReadHandler(n int, reject ...int) *batchHandler {
+	h := newBatchHandler()
+	h.delay = map[string]time.Duration{}
+	for i := 0; i < n; i += 3 {
+		h.delay[string(testRow(i))] = time.Duration(n-i) * time.Millisecond
+	}
+	for _, i := range reject {
+		h.reject[string(testRow(i))] = true
+	}
+	return h
+}
+
+// checkChunkedReadError 检查只有wantFailed中的下标失败
+func checkChunkedReadError(t *testing.T, err error, n int, wantFailed []int) {
+	t.Helper()
+	if len(wantFailed) == 0 {
+		if err != nil {
+			t.Fatalf("没有分块失败时返回了错误: %v", err)
+		}
+		return
+	}
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("错误%v不是*BatchError", err)
+	}
+	if got := failedIndexes(&BatchResult{Failed: batchErr.Failed}); batchErr.Total != n || !reflect.DeepEqual(got, wantFailed) {
+		t.Fatalf("%d个元素中失败的下标为%v,期望%d个元素中%v失败", batchErr.Total, got, n, wantFailed)
+	}
+	if !errors.Is(err, exceptions.ErrIO) {
+		t.Fatalf("BatchError没有包装失败分块的错误: %v", err)
+	}
+}
+
+var chunkedReadCases = []struct {
+	name       string
+	reject     []int
+	wantFailed []int
+}{
+	{"all succeed", nil, []int{}},
+	{"one chunk fails", []int{7}, []int{6, 7, 8}},
+	{"first and last chunks fail", []int{0, 19}, []int{0, 1, 2, 18, 19}},
+}
+
+func TestGetMultipleChunked(t *testing.T) {
+	const n = 20
+	for _, tc := range chunkedReadCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newChunkedReadHandler(n, tc.reject...)
+			c := newTestClient(t, h)
+			results, err := c.GetMultipleChunked(context.Background(), []byte("t"), testGets(n), WithBatchChunkSize(3), WithBatchConcurrency(4))
+			checkChunkedReadError(t, err, n, tc.wantFailed)
+			if len(results) != n {
+				t.Fatalf("返回了%d个结果,期望%d个", len(results), n)
+			}
+			failed := map[int]bool{}
+			for _, i := range tc.wantFailed {
+				failed[i] = true
+			}
+			for i, r := range results {
+				if failed[i] {
+					if r != nil {
+						t.Fatalf("失败分块中下标%d的结果为%s,期望nil", i, r.Row)
+					}
+					continue
+				}
+				if r == nil || string(r.Row) != string(testRow(i)) {
+					t.Fatalf("下标%d的结果为%v,期望%s", i, r, testRow(i))
+				}
+			}
+			if got, want := h.callCount(), (n+2)/3; got != want {
+				t.Fatalf("请求了%d次,期望每个分块请求一次共%d次", got, want)
+			}
+		})
+	}
+}
+
+func TestExistsAllChunked(t *testing.T) {
+	const n = 20
+	for _, tc := range chunkedReadCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newChunkedReadHandler(n, tc.reject...)
+			c := newTestClient(t, h)
+			results, err := c.ExistsAllChunked(context.Background(), []byte("t"), testGets(n), WithBatchChunkSize(3), WithBatchConcurrency(4))
+			checkChunkedReadError(t, err, n, tc.wantFailed)
+			failed := map[int]bool{}
+			for _, i := range tc.wantFailed {
+				failed[i] = true
+			}
+			want := make([]bool, n)
+			for i := range want {
+				want[i] = !failed[i] && i%2 == 0
+			}
+			if !reflect.DeepEqual(results, want) {
+				t.Fatalf("结果为%v,期望%v", results, want)
+			}
+		})
+	}
+}
+
+func TestBatchErrorMessage(t *testing.T) {
+	r := &BatchResult{Succeeded: []int{0, 2}, Failed: []ItemError{{Index: 1, Err: ErrDeleteNotApplied}, {Index: 3, Err: ErrDeleteNotApplied}}}
+	err := r.Err()
+	if got, want := err.Error(), fmt.Sprintf("批量操作中2/4个元素失败,第一个失败的元素下标为1: %s", ErrDeleteNotApplied); got != want {
+		t.Fatalf("错误信息为%q,期望%q", got, want)
+	}
+	if !errors.Is(err, ErrDeleteNotApplied) {
+		t.Fatal("BatchError没有包装第一个失败元素的错误")
+	}
+	if (&BatchResult{Succeeded: []int{0}}).Err() != nil {
+		t.Fatal("没有元素失败时Err()不为nil")
+	}
+}
@@ -0,0 +1,478 @@
+// 带缓冲的批量写入
+package aliexhbase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// 批量写入的默认配置
+const (
+	defaultBatchWriterMaxBatchCount    = 1000
+	defaultBatchWriterMaxBatchBytes    = 2 << 20
+	defaultBatchWriterMaxBufferedBytes = 64 << 20
+	defaultBatchWriterFlushInterval    = time.Second
+	defaultBatchWriterFlushers         = 4
+)
+
+// mutationOverhead 估算变更大小时每个变更和每列额外计算的字节数
+const mutationOverhead = 32
+
+// MutationError 批量写入中单个变更的失败信息
+type MutationError struct {
+	// 表名
+	Table []byte
+	// 失败的变更,为*hbase.TPut,*hbase.TDelete或*hbase.TIncrement
+	Mutation interface{}
+	// 失败原因
+	Err error
+}
+
+// batchWriterOptions 批量写入配置
+type batchWriterOptions struct {
+	maxBatchCount    int
+	maxBatchBytes    int64
+	maxBufferedBytes int64
+	flushInterval    time.Duration
+	flushers         int
+	onError          func(MutationError)
+}
+
+// BatchWriterOption 批量写入配置项
+type BatchWriterOption func(*batchWriterOptions)
+
+// WithBatchWriterMaxBatchCount 设置单个表缓冲的变更数达到多少时刷新,默认为1000
+func WithBatchWriterMaxBatchCount(MaxBatchCount int) BatchWriterOption {
+	return func(o *batchWriterOptions) {
+		o.maxBatchCount = MaxBatchCount
+	}
+}
+
+// WithBatchWriterMaxBatchBytes 设置单个表缓冲的变更大小达到多少字节时刷新,默认为2MB
+func WithBatchWriterMaxBatchBytes(MaxBatchBytes int64) BatchWriterOption {
+	return func(o *batchWriterOptions) {
+		o.maxBatchBytes = MaxBatchBytes
+	}
+}
+
+// WithBatchWriterMaxBufferedBytes 设置所有表缓冲和正在刷新的变更总大小上限,超过时写入会阻塞,默认为64MB
+func WithBatchWriterMaxBufferedBytes(MaxBufferedBytes int64) BatchWriterOption {
+	return func(o *batchWriterOptions) {
+		o.maxBufferedBytes = MaxBufferedBytes
+	}
+}
+
+// WithBatchWriterFlushInterval 设置定时刷新的间隔,默认为1s,小于0时不定时刷新
+func WithBatchWriterFlushInterval(FlushInterval time.Duration) BatchWriterOption {
+	return func(o *batchWriterOptions) {
+		o.flushInterval = FlushInterval
+	}
+}
+
+// WithBatchWriterFlushers 设置并行刷新的协程数,默认为4
+func WithBatchWriterFlushers(Flushers int) BatchWriterOption {
+	return func(o *batchWriterOptions) {
+		o.flushers = Flushers
+	}
+}
+
+// WithBatchWriterErrorHandler 设置变更失败时的回调,会被刷新协程并发调用,未设置时失败会记录到Logger
+func WithBatchWriterErrorHandler(OnError func(MutationError)) BatchWriterOption {
+	return func(o *batchWriterOptions) {
+		o.onError = OnError
+	}
+}
+
+// mutation 缓冲中的变更
+type mutation struct {
+	put       *hbase.TPut
+	delete    *hbase.TDelete
+	increment *hbase.TIncrement
+}
+
+// value 获取变更本身
+func (m mutation) value() interface{} {
+	switch {
+	case m.put != nil:
+		return m.put
+	case m.delete != nil:
+		return m.delete
+	default:
+		return m.increment
+	}
+}
+
+// tableBuffer 单个表的缓冲
+type tableBuffer struct {
+	mutations []mutation
+	bytes     int64
+}
+
+// flushJob 一个表的一批变更
+type flushJob struct {
+	table     []byte
+	mutations []mutation
+	bytes     int64
+	// err 批次中第一个失败的原因,done关闭后可读
+	err  error
+	done chan struct{}
+}
+
+// BatchWriter 带缓冲的批量写入器,按表缓冲变更,缓冲达到大小或数量上限,或者定时器触发时在后台通过BatchPut/BatchDelete写入.
+// 同一批次内的变更按写入顺序执行,连续的同类变更合并为一次请求,Increment非幂等且没有批量接口,会逐个发送;
+// 并行刷新时同一个表的不同批次之间不保证顺序,需要严格保证同一行变更顺序时可以将刷新协程数设为1.
+// 请求失败时会二分拆分找出具体失败的变更并通过错误回调报告,不会重试非幂等的Increment.
+// 每次BatchPut/BatchDelete/Increment调用使用单独的ctx,客户端的QueryTimeout限制的是一次调用及其拆分出的所有请求
+type BatchWriter struct {
+	client *Client
+	opts   batchWriterOptions
+
+	lock     sync.Mutex
+	tables   map[string]*tableBuffer
+	inFlight map[*flushJob]struct{}
+	// 缓冲和正在刷新的变更总大小
+	bytes int64
+	// freed 有空间释放时关闭并替换,用于唤醒被阻塞的写入
+	freed  chan struct{}
+	closed bool
+
+	jobs chan *flushJob
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBatchWriter 创建批量写入器,使用完后需要调用Close写入剩余的变更
+func (p *Client) NewBatchWriter(opts ...BatchWriterOption) *BatchWriter {
+	o := batchWriterOptions{
+		maxBatchCount:    defaultBatchWriterMaxBatchCount,
+		maxBatchBytes:    defaultBatchWriterMaxBatchBytes,
+		maxBufferedBytes: defaultBatchWriterMaxBufferedBytes,
+		flushInterval:    defaultBatchWriterFlushInterval,
+		flushers:         defaultBatchWriterFlushers,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.flushers <= 0 {
+		o.flushers = 1
+	}
+	w := &BatchWriter{
+		client:   p,
+		opts:     o,
+		tables:   map[string]*tableBuffer{},
+		inFlight: map[*flushJob]struct{}{},
+		freed:    make(chan struct{}),
+		jobs:     make(chan *flushJob),
+		quit:     make(chan struct{}),
+	}
+	for i := 0; i < o.flushers; i++ {
+		w.wg.Add(1)
+		go w.flusher()
+	}
+	if o.flushInterval > 0 {
+		w.wg.Add(1)
+		go w.ticker()
+	}
+	return w
+}
+
+// Put 写入TPut
+// 返回nil表示变更已经进入缓冲,之后即使ctx结束也会在后台写入,写入失败通过错误回调报告;
+// 只有在缓冲已满阻塞期间ctx结束,或者写入器已经关闭时才返回错误,此时变更没有被接受,可以安全地重试.
+// tput或其中的列为nil时返回ErrNilMutation
+func (w *BatchWriter) Put(ctx context.Context, table []byte, tput *hbase.TPut) error {
+	if tput == nil {
+		return ErrNilMutation
+	}
+	size := int64(len(tput.Row) + mutationOverhead)
+	for _, c := range tput.ColumnValues {
+		if c == nil {
+			return ErrNilMutation
+		}
+		size += int64(len(c.Family) + len(c.Qualifier) + len(c.Value) + mutationOverhead)
+	}
+	return w.add(ctx, table, mutation{put: tput}, size)
+}
+
+// Delete 写入TDelete,返回值与Put相同,返回错误时变更没有被接受,返回nil后不能重试
+func (w *BatchWriter) Delete(ctx context.Context, table []byte, tdelete *hbase.TDelete) error {
+	if tdelete == nil {
+		return ErrNilMutation
+	}
+	size := int64(len(tdelete.Row) + mutationOverhead)
+	for _, c := range tdelete.Columns {
+		if c == nil {
+			return ErrNilMutation
+		}
+		size += int64(len(c.Family) + len(c.Qualifier) + mutationOverhead)
+	}
+	return w.add(ctx, table, mutation{delete: tdelete}, size)
+}
+
+// Increment 写入TIncrement,返回值与Put相同,返回错误时变更没有被接受.
+// Increment非幂等,返回nil后重试会重复计数
+func (w *BatchWriter) Increment(ctx context.Context, table []byte, tincrement *hbase.TIncrement) error {
+	if tincrement == nil {
+		return ErrNilMutation
+	}
+	size := int64(len(tincrement.Row) + mutationOverhead)
+	for _, c := range tincrement.Columns {
+		if c == nil {
+			return ErrNilMutation
+		}
+		size += int64(len(c.Family) + len(c.Qualifier) + mutationOverhead)
+	}
+	return w.add(ctx, table, mutation{increment: tincrement}, size)
+}
+
+// add 将变更加入缓冲,总大小超过上限时阻塞到有空间释放或ctx结束,只有变更没有进入缓冲时返回错误
+func (w *BatchWriter) add(ctx context.Context, table []byte, m mutation, size int64) error {
+	w.lock.Lock()
+	for {
+		if w.closed {
+			w.lock.Unlock()
+			return ErrBatchWriterClosed
+		}
+		// 缓冲为空时总是允许写入,避免单个变更超过上限时永远阻塞
+		if w.bytes == 0 || w.bytes+size <= w.opts.maxBufferedBytes {
+			break
+		}
+		freed := w.freed
+		w.lock.Unlock()
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		w.lock.Lock()
+	}
+	buf, ok := w.tables[string(table)]
+	if !ok {
+		buf = &tableBuffer{}
+		w.tables[string(table)] = buf
+	}
+	buf.mutations = append(buf.mutations, m)
+	buf.bytes += size
+	w.bytes += size
+	var job *flushJob
+	if len(buf.mutations) >= w.opts.maxBatchCount || buf.bytes >= w.opts.maxBatchBytes {
+		job = w.cut(table, buf)
+	}
+	w.lock.Unlock()
+	if job != nil {
+		// 变更已经进入缓冲,ctx结束时批次仍会在后台提交,不能返回错误,否则调用方重试会重复写入
+		w.submit(ctx, job)
+	}
+	return nil
+}
+
+// cut 将表的缓冲转为刷新批次,需要持有锁
+func (w *BatchWriter) cut(table []byte, buf *tableBuffer) *flushJob {
+	job := &flushJob{
+		table:     table,
+		mutations: buf.mutations,
+		bytes:     buf.bytes,
+		done:      make(chan struct{}),
+	}
+	delete(w.tables, string(table))
+	w.inFlight[job] = struct{}{}
+	return job
+}
+
+// cutAll 将所有表的缓冲转为刷新批次,需要持有锁
+func (w *BatchWriter) cutAll() []*flushJob {
+	jobs := make([]*flushJob, 0, len(w.tables))
+	for table, buf := range w.tables {
+		jobs = append(jobs, w.cut([]byte(table), buf))
+	}
+	return jobs
+}
+
+// submit 将批次交给刷新协程,ctx结束时批次仍然会在后台提交,返回ctx的错误
+func (w *BatchWriter) submit(ctx context.Context, job *flushJob) error {
+	select {
+	case w.jobs <- job:
+		return nil
+	case <-ctx.Done():
+		go func() {
+			w.jobs <- job
+		}()
+		return ctx.Err()
+	}
+}
+
+// flusher 刷新协程
+func (w *BatchWriter) flusher() {
+	defer w.wg.Done()
+	for {
+		select {
+		case job := <-w.jobs:
+			w.execute(job)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// ticker 定时刷新协程
+func (w *BatchWriter) ticker() {
+	defer w.wg.Done()
+	t := time.NewTicker(w.opts.flushInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.lock.Lock()
+			jobs := w.cutAll()
+			w.lock.Unlock()
+			for _, job := range jobs {
+				select {
+				case w.jobs <- job:
+				case <-w.quit:
+					return
+				}
+			}
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// execute 执行一个批次,连续的同类变更合并为一次请求
+func (w *BatchWriter) execute(job *flushJob) {
+	muts := job.mutations
+	for len(muts) > 0 {
+		muts = muts[w.executeRun(job, muts):]
+	}
+	w.lock.Lock()
+	w.bytes -= job.bytes
+	delete(w.inFlight, job)
+	close(w.freed)
+	w.freed = make(chan struct{})
+	w.lock.Unlock()
+	close(job.done)
+}
+
+// executeRun 执行muts开头连续的同类变更,返回执行的变更数
+// 每次请求使用单独的ctx,QueryTimeout限制的是一次BatchPut/BatchDelete/Increment而不是整个批次
+func (w *BatchWriter) executeRun(job *flushJob, muts []mutation) int {
+	ctx, cancel := w.client.NewCtx()
+	defer cancel()
+	n := 1
+	switch {
+	case muts[0].put != nil:
+		for n < len(muts) && muts[n].put != nil {
+			n++
+		}
+		tputs := make([]*hbase.TPut, n)
+		for i := range tputs {
+			tputs[i] = muts[i].put
+		}
+		result, _ := w.client.BatchPut(ctx, job.table, tputs, WithBatchChunkSize(n))
+		for _, f := range result.Failed {
+			w.fail(job, muts[f.Index], f.Err)
+		}
+	case muts[0].delete != nil:
+		for n < len(muts) && muts[n].delete != nil {
+			n++
+		}
+		tdeletes := make([]*hbase.TDelete, n)
+		for i := range tdeletes {
+			tdeletes[i] = muts[i].delete
+		}
+		result, _ := w.client.BatchDelete(ctx, job.table, tdeletes, WithBatchChunkSize(n))
+		for _, f := range result.Failed {
+			w.fail(job, muts[f.Index], f.Err)
+		}
+	default:
+		if _, err := w.client.Increment(ctx, job.table, muts[0].increment); err != nil {
+			w.fail(job, muts[0], err)
+		}
+	}
+	return n
+}
+
+// fail 报告失败的变更
+func (w *BatchWriter) fail(job *flushJob, m mutation, err error) {
+	if job.err == nil {
+		job.err = err
+	}
+	if w.opts.onError != nil {
+		w.opts.onError(MutationError{Table: job.table, Mutation: m.value(), Err: err})
+		return
+	}
+	w.client.Opts.Logger.WithError(err).WithField("table", string(job.table)).Error("Batch write mutation error")
+}
+
+// Flush 立即刷新所有缓冲并等待调用前写入的变更全部执行完,返回这些批次中的第一个失败原因
+func (w *BatchWriter) Flush(ctx context.Context) error {
+	w.lock.Lock()
+	jobs := w.cutAll()
+	waiting := make([]*flushJob, 0, len(w.inFlight))
+	for job := range w.inFlight {
+		waiting = append(waiting, job)
+	}
+	w.lock.Unlock()
+	for i, job := range jobs {
+		if err := w.submit(ctx, job); err != nil {
+			for _, rest := range jobs[i+1:] {
+				go func(job *flushJob) {
+					w.jobs <- job
+				}(rest)
+			}
+			return err
+		}
+	}
+	var firstErr error
+	for _, job := range waiting {
+		select {
+		case <-job.done:
+			if firstErr == nil {
+				firstErr = job.err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return firstErr
+}
+
+// Close 停止接收新的变更,刷新剩余的缓冲并停止后台协程
+// ctx结束时仍未写完的变更会继续在后台执行,执行完后后台协程再退出
+func (w *BatchWriter) Close(ctx context.Context) error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return ErrBatchWriterClosed
+	}
+	w.closed = true
+	// 唤醒被阻塞的写入
+	close(w.freed)
+	w.freed = make(chan struct{})
+	w.lock.Unlock()
+	err := w.Flush(ctx)
+	if ctx.Err() != nil {
+		go w.shutdown()
+		return err
+	}
+	w.shutdown()
+	return err
+}
+
+// shutdown 等待所有批次执行完后停止后台协程,关闭后不会再产生新的批次
+func (w *BatchWriter) shutdown() {
+	w.lock.Lock()
+	waiting := make([]*flushJob, 0, len(w.inFlight))
+	for job := range w.inFlight {
+		waiting = append(waiting, job)
+	}
+	w.lock.Unlock()
+	for _, job := range waiting {
+		<-job.done
+	}
+	close(w.quit)
+	w.wg.Wait()
+}
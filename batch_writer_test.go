@@ -0,0 +1,157 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// writeHandler 记录每行被写入次数的假服务端,gate不为nil时写入请求会阻塞到gate关闭
+type writeHandler struct {
+	hbase.THBaseService
+	mu      sync.Mutex
+	writes  map[string]int
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func newWriteHandler() *writeHandler {
+	return &writeHandler{writes: map[string]int{}, started: make(chan struct{}, 16)}
+}
+
+func (h *writeHandler) wait() {
+	h.started <- struct{}{}
+	if h.gate != nil {
+		<-h.gate
+	}
+}
+
+func (h *writeHandler) PutMultiple(ctx context.Context, table []byte, tputs []*hbase.TPut) error {
+	h.wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, tput := range tputs {
+		h.writes[string(tput.Row)]++
+	}
+	return nil
+}
+
+func (h *writeHandler) Increment(ctx context.Context, table []byte, tincrement *hbase.TIncrement) (*hbase.TResult_, error) {
+	h.wait()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writes[string(tincrement.Row)]++
+	return &hbase.TResult_{}, nil
+}
+
+func (h *writeHandler) count(row string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.writes[row]
+}
+
+func testIncrement(row string) *hbase.TIncrement {
+	return &hbase.TIncrement{Row: []byte(row), Columns: []*hbase.TColumnIncrement{{Family: []byte("cf"), Qualifier: []byte("n")}}}
+}
+
+func TestBatchWriterQueuedAfterCancel(t *testing.T) {
+	h := newWriteHandler()
+	h.gate = make(chan struct{})
+	c := newTestClient(t, h)
+	w := c.NewBatchWriter(WithBatchWriterFlushers(1), WithBatchWriterMaxBatchCount(1), WithBatchWriterFlushInterval(-1))
+
+	if err := w.Put(context.Background(), []byte("t"), &hbase.TPut{Row: []byte("r1")}); err != nil {
+		t.Fatal(err)
+	}
+	// 唯一的刷新协程阻塞在第一个批次上,第二个批次提交时会等待
+	<-h.started
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- w.Increment(ctx, []byte("t"), testIncrement("r2"))
+	}()
+	select {
+	case err := <-errc:
+		t.Fatalf("刷新协程忙时Increment没有等待提交: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	cancel()
+	// 变更已经进入缓冲,ctx结束后也会在后台写入,必须返回nil以免调用方重试导致重复计数
+	if err := <-errc; err != nil {
+		t.Fatalf("Increment() = %v, want nil", err)
+	}
+	close(h.gate)
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := h.count("r1"); n != 1 {
+		t.Fatalf("r1写入了%d次", n)
+	}
+	if n := h.count("r2"); n != 1 {
+		t.Fatalf("r2写入了%d次", n)
+	}
+}
+
+func TestBatchWriterCanceledWhileBlocked(t *testing.T) {
+	h := newWriteHandler()
+	c := newTestClient(t, h)
+	w := c.NewBatchWriter(WithBatchWriterMaxBufferedBytes(1), WithBatchWriterFlushInterval(-1))
+
+	if err := w.Put(context.Background(), []byte("t"), &hbase.TPut{Row: []byte("r1")}); err != nil {
+		t.Fatal(err)
+	}
+	// 缓冲已满,ctx结束时变更没有被接受,返回ctx的错误
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Put(ctx, []byte("t"), &hbase.TPut{Row: []byte("r2")}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Put() = %v, want context.DeadlineExceeded", err)
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := h.count("r1"); n != 1 {
+		t.Fatalf("r1写入了%d次", n)
+	}
+	if n := h.count("r2"); n != 0 {
+		t.Fatalf("没有被接受的r2写入了%d次", n)
+	}
+	if err := w.Put(context.Background(), []byte("t"), &hbase.TPut{Row: []byte("r3")}); !errors.Is(err, ErrBatchWriterClosed) {
+		t.Fatalf("Put() after Close = %v, want ErrBatchWriterClosed", err)
+	}
+}
+
+func TestBatchWriterNilMutation(t *testing.T) {
+	h := newWriteHandler()
+	c := newTestClient(t, h)
+	w := c.NewBatchWriter()
+	ctx := context.Background()
+	for name, write := range map[string]func() error{
+		"nil put": func() error { return w.Put(ctx, []byte("t"), nil) },
+		"nil put column": func() error {
+			return w.Put(ctx, []byte("t"), &hbase.TPut{Row: []byte("r"), ColumnValues: []*hbase.TColumnValue{nil}})
+		},
+		"nil delete": func() error { return w.Delete(ctx, []byte("t"), nil) },
+		"nil delete column": func() error {
+			return w.Delete(ctx, []byte("t"), &hbase.TDelete{Row: []byte("r"), Columns: []*hbase.TColumn{nil}})
+		},
+		"nil increment": func() error { return w.Increment(ctx, []byte("t"), nil) },
+		"nil increment column": func() error {
+			return w.Increment(ctx, []byte("t"), &hbase.TIncrement{Row: []byte("r"), Columns: []*hbase.TColumnIncrement{nil}})
+		},
+	} {
+		if err := write(); !errors.Is(err, ErrNilMutation) {
+			t.Errorf("%s: err = %v, want ErrNilMutation", name, err)
+		}
+	}
+	// 被拒绝的变更没有进入缓冲
+	if err := w.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(h.started); n != 0 {
+		t.Fatalf("发出了%d次请求", n)
+	}
+}
@@ -0,0 +1,115 @@
+// TGet,TPut,TDelete,TScan,TIncrement和TAppend的链式构造器
+// 构造器负责填充生成代码中的指针字段并校验参数,参数错误会被记录下来在Build时返回:
+//
+//	tget, err := builder.NewGet(row).Columns("cf:a", "cf:b").Versions(3).Build()
+//	tscan, err := builder.NewScan().Prefix([]byte("user_")).Limit(100).Reverse().Caching(500).Build()
+//
+// 列使用`family:qualifier`的形式指定,只有列族时表示整个列族
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// 错误类型
+var (
+	//ErrEmptyRow 行键为空
+	ErrEmptyRow = errors.New("行键为空")
+	//ErrInvalidColumn 列格式错误
+	ErrInvalidColumn = errors.New("列格式错误,应为family或family:qualifier")
+	//ErrInvalidVersions 版本数必须大于0
+	ErrInvalidVersions = errors.New("版本数必须大于0")
+	//ErrInvalidTimeRange 时间范围错误
+	ErrInvalidTimeRange = errors.New("时间范围错误,最小时间戳不能大于最大时间戳")
+	//ErrInvalidLimit 数量参数必须大于0
+	ErrInvalidLimit = errors.New("数量参数必须大于0")
+	//ErrNoColumns 没有设置列
+	ErrNoColumns = errors.New("没有设置列")
+	//ErrPrefixWithRange 前缀和起止行不能同时设置
+	ErrPrefixWithRange = errors.New("前缀和起止行不能同时设置")
+)
+
+// errHolder 记录构造过程中的第一个参数错误,嵌入到各个构造器中
+type errHolder struct {
+	err error
+}
+
+// setErr 记录第一个错误
+func (h *errHolder) setErr(err error) {
+	if h.err == nil {
+		h.err = err
+	}
+}
+
+// Err 返回构造过程中记录的第一个参数错误,与Build返回的错误相同
+func (h *errHolder) Err() error {
+	return h.err
+}
+
+// parseColumn 解析`family:qualifier`形式的列,只有列族时qualifier为nil
+func parseColumn(column string) (family string, qualifier []byte, err error) {
+	family, q, found := strings.Cut(column, ":")
+	if family == "" {
+		return "", nil, fmt.Errorf("%w: %q", ErrInvalidColumn, column)
+	}
+	if found {
+		qualifier = []byte(q)
+	}
+	return family, qualifier, nil
+}
+
+// parseColumns 解析多个列
+func parseColumns(columns []string) ([]*hbase.TColumn, error) {
+	result := make([]*hbase.TColumn, 0, len(columns))
+	for _, column := range columns {
+		family, qualifier, err := parseColumn(column)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, &hbase.TColumn{Family: []byte(family), Qualifier: qualifier})
+	}
+	return result, nil
+}
+
+// checkFamily 校验列族不为空
+func checkFamily(family string) error {
+	if family == "" {
+		return fmt.Errorf("%w: 列族为空", ErrInvalidColumn)
+	}
+	return nil
+}
+
+// newTimeRange 构造时间范围[min,max)
+func newTimeRange(min, max int64) (*hbase.TTimeRange, error) {
+	if min > max {
+		return nil, fmt.Errorf("%w: [%d,%d)", ErrInvalidTimeRange, min, max)
+	}
+	return &hbase.TTimeRange{MinStamp: min, MaxStamp: max}, nil
+}
+
+// PrefixStopRow 计算前缀扫描的结束行(不包含),即大于所有以prefix开头的行的最小行键
+// 去掉末尾的0xFF后将最后一个字节加1;prefix为空或全部为0xFF时没有这样的行键,返回nil表示扫描到表尾
+func PrefixStopRow(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xFF {
+			stop := make([]byte, i+1)
+			copy(stop, prefix[:i+1])
+			stop[i]++
+			return stop
+		}
+	}
+	return nil
+}
+
+// setAttribute 设置属性,m为nil时创建
+func setAttribute(m map[string][]byte, key string, value []byte) map[string][]byte {
+	if m == nil {
+		m = map[string][]byte{}
+	}
+	m[key] = value
+	return m
+}
@@ -0,0 +1,83 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestPrefixStopRow(t *testing.T) {
+	for _, tc := range []struct {
+		prefix string
+		want   []byte
+	}{
+		{"a", []byte("b")},
+		{"abc", []byte("abd")},
+		{"a\xfe", []byte("a\xff")},
+		{"a\xff", []byte("b")},
+		{"ab\xff\xff", []byte("ac")},
+		{"\x00", []byte("\x01")},
+		{"\xff\xff", nil},
+		{"\xff", nil},
+		{"", nil},
+	} {
+		prefix := []byte(tc.prefix)
+		got := PrefixStopRow(prefix)
+		if !bytes.Equal(got, tc.want) || (tc.want == nil) != (got == nil) {
+			t.Errorf("PrefixStopRow(%q) = %q, want %q", tc.prefix, got, tc.want)
+		}
+		if string(prefix) != tc.prefix {
+			t.Errorf("PrefixStopRow(%q) modified prefix to %q", tc.prefix, prefix)
+		}
+	}
+	if got := PrefixStopRow(nil); got != nil {
+		t.Errorf("PrefixStopRow(nil) = %q, want nil", got)
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := parseColumns([]string{"cf", "cf:a", "cf:", "cf:a:b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []struct {
+		family    string
+		qualifier []byte
+	}{
+		{"cf", nil},
+		{"cf", []byte("a")},
+		{"cf", []byte{}},
+		{"cf", []byte("a:b")},
+	} {
+		c := columns[i]
+		if string(c.Family) != want.family || !bytes.Equal(c.Qualifier, want.qualifier) || (c.Qualifier == nil) != (want.qualifier == nil) {
+			t.Errorf("columns[%d] = %s:%q, want %s:%q", i, c.Family, c.Qualifier, want.family, want.qualifier)
+		}
+	}
+	for _, column := range []string{"", ":a"} {
+		if _, err := parseColumns([]string{column}); !errors.Is(err, ErrInvalidColumn) {
+			t.Errorf("parseColumns(%q) err = %v, want ErrInvalidColumn", column, err)
+		}
+	}
+}
+
+func TestErrKeepsFirstError(t *testing.T) {
+	for name, b := range map[string]interface{ Err() error }{
+		"get":       NewGet(nil).Versions(0),
+		"put":       NewPut(nil).Add("bad:", "q", nil),
+		"delete":    NewDelete(nil).Family("bad:"),
+		"increment": NewIncrement(nil).Add("bad:", "q", 1),
+		"append":    NewAppend(nil).Add("bad:", "q", nil),
+	} {
+		if err := b.Err(); !errors.Is(err, ErrEmptyRow) {
+			t.Errorf("%s: Err() = %v, want ErrEmptyRow", name, err)
+		}
+	}
+	b := NewScan().Versions(0).Limit(0)
+	if err := b.Err(); !errors.Is(err, ErrInvalidVersions) {
+		t.Fatalf("Err() = %v, want ErrInvalidVersions", err)
+	}
+	if _, err := b.Build(); !errors.Is(err, ErrInvalidVersions) {
+		t.Fatalf("Build() = %v, want ErrInvalidVersions", err)
+	}
+}
@@ -0,0 +1,34 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/filter"
+)
+
+func TestFilterBy(t *testing.T) {
+	f := filter.And(filter.PrefixFilter{Prefix: []byte("a")}, filter.KeyOnlyFilter{})
+	tscan, err := NewScan().FilterBy(f).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(tscan.FilterString), "PrefixFilter('a') AND KeyOnlyFilter()"; got != want {
+		t.Fatalf("FilterString = %q, want %q", got, want)
+	}
+	tget, err := NewGet([]byte("row")).FilterBy(f).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(tget.FilterString), "PrefixFilter('a') AND KeyOnlyFilter()"; got != want {
+		t.Fatalf("FilterString = %q, want %q", got, want)
+	}
+
+	// 空的过滤器列表会生成空字符串,必须在构造时报错
+	if _, err := NewScan().FilterBy(filter.Or()).Build(); !errors.Is(err, filter.ErrEmptyFilterList) {
+		t.Fatalf("Build() err = %v, want ErrEmptyFilterList", err)
+	}
+	if _, err := NewGet([]byte("row")).FilterBy(nil).Build(); !errors.Is(err, filter.ErrNilFilter) {
+		t.Fatalf("Build() err = %v, want ErrNilFilter", err)
+	}
+}
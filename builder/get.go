@@ -0,0 +1,158 @@
+// TGet构造器
+package builder
+
+import (
+	"github.com/Golang-Tools/aliexhbase/filter"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// GetBuilder TGet构造器
+type GetBuilder struct {
+	tget *hbase.TGet
+	errHolder
+}
+
+// NewGet 创建读取指定行的TGet构造器
+func NewGet(row []byte) *GetBuilder {
+	b := &GetBuilder{tget: hbase.NewTGet()}
+	b.tget.Row = row
+	if len(row) == 0 {
+		b.setErr(ErrEmptyRow)
+	}
+	return b
+}
+
+// Family 读取整个列族
+func (b *GetBuilder) Family(family string) *GetBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tget.Columns = append(b.tget.Columns, &hbase.TColumn{Family: []byte(family)})
+	return b
+}
+
+// Column 读取指定列
+func (b *GetBuilder) Column(family, qualifier string) *GetBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tget.Columns = append(b.tget.Columns, &hbase.TColumn{Family: []byte(family), Qualifier: []byte(qualifier)})
+	return b
+}
+
+// Columns 读取`family:qualifier`形式指定的多个列,只有列族时读取整个列族
+func (b *GetBuilder) Columns(columns ...string) *GetBuilder {
+	cols, err := parseColumns(columns)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tget.Columns = append(b.tget.Columns, cols...)
+	return b
+}
+
+// Versions 设置每列最多返回的版本数
+func (b *GetBuilder) Versions(n int32) *GetBuilder {
+	if n <= 0 {
+		b.setErr(ErrInvalidVersions)
+		return b
+	}
+	b.tget.MaxVersions = thrift.Int32Ptr(n)
+	return b
+}
+
+// Timestamp 只读取指定时间戳的版本
+func (b *GetBuilder) Timestamp(ts int64) *GetBuilder {
+	b.tget.Timestamp = thrift.Int64Ptr(ts)
+	return b
+}
+
+// TimeRange 只读取时间戳在[min,max)之间的版本
+func (b *GetBuilder) TimeRange(min, max int64) *GetBuilder {
+	tr, err := newTimeRange(min, max)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tget.TimeRange = tr
+	return b
+}
+
+// Filter 设置过滤器字符串
+func (b *GetBuilder) Filter(filterString string) *GetBuilder {
+	b.tget.FilterString = []byte(filterString)
+	return b
+}
+
+// FilterBy 使用filter包构造的过滤器,过滤器不合法时Build返回filter.Validate的错误
+func (b *GetBuilder) FilterBy(f filter.Filter) *GetBuilder {
+	filterString, err := filter.Build(f)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	return b.Filter(filterString)
+}
+
+// CacheBlocks 设置是否缓存读取的数据块
+func (b *GetBuilder) CacheBlocks(cache bool) *GetBuilder {
+	b.tget.CacheBlocks = thrift.BoolPtr(cache)
+	return b
+}
+
+// StoreLimit 设置每个列族最多返回的列数
+func (b *GetBuilder) StoreLimit(n int32) *GetBuilder {
+	if n <= 0 {
+		b.setErr(ErrInvalidLimit)
+		return b
+	}
+	b.tget.StoreLimit = thrift.Int32Ptr(n)
+	return b
+}
+
+// StoreOffset 设置每个列族跳过的列数
+func (b *GetBuilder) StoreOffset(n int32) *GetBuilder {
+	b.tget.StoreOffset = thrift.Int32Ptr(n)
+	return b
+}
+
+// ExistenceOnly 只判断行是否存在,不返回数据
+func (b *GetBuilder) ExistenceOnly() *GetBuilder {
+	b.tget.ExistenceOnly = thrift.BoolPtr(true)
+	return b
+}
+
+// Consistency 设置一致性级别
+func (b *GetBuilder) Consistency(consistency hbase.TConsistency) *GetBuilder {
+	b.tget.Consistency = &consistency
+	return b
+}
+
+// TargetReplica 设置读取的副本,需要配合TConsistency_TIMELINE使用
+func (b *GetBuilder) TargetReplica(id int32) *GetBuilder {
+	b.tget.TargetReplicaId = thrift.Int32Ptr(id)
+	return b
+}
+
+// Authorizations 设置可见性标签
+func (b *GetBuilder) Authorizations(labels ...string) *GetBuilder {
+	b.tget.Authorizations = &hbase.TAuthorization{Labels: labels}
+	return b
+}
+
+// Attribute 设置请求属性
+func (b *GetBuilder) Attribute(key string, value []byte) *GetBuilder {
+	b.tget.Attributes = setAttribute(b.tget.Attributes, key, value)
+	return b
+}
+
+// Build 构造TGet,返回构造过程中的第一个参数错误
+func (b *GetBuilder) Build() (*hbase.TGet, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	return b.tget, nil
+}
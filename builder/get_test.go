@@ -0,0 +1,87 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+func TestGet(t *testing.T) {
+	tget, err := NewGet([]byte("row")).
+		Columns("cf:a", "cf2").
+		Family("cf3").
+		Column("cf4", "q").
+		Versions(3).
+		TimeRange(1, 10).
+		Filter("KeyOnlyFilter()").
+		CacheBlocks(false).
+		StoreLimit(5).
+		StoreOffset(2).
+		Consistency(hbase.TConsistency_TIMELINE).
+		TargetReplica(1).
+		Authorizations("secret").
+		Attribute("k", []byte("v")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(tget.Row) != "row" {
+		t.Errorf("Row = %q, want row", tget.Row)
+	}
+	wantColumns := []string{"cf:a", "cf2:", "cf3:", "cf4:q"}
+	if len(tget.Columns) != len(wantColumns) {
+		t.Fatalf("Columns = %v, want %v", tget.Columns, wantColumns)
+	}
+	for i, c := range tget.Columns {
+		if got := string(c.Family) + ":" + string(c.Qualifier); got != wantColumns[i] {
+			t.Errorf("Columns[%d] = %s, want %s", i, got, wantColumns[i])
+		}
+	}
+	if tget.GetMaxVersions() != 3 || tget.TimeRange.MinStamp != 1 || tget.TimeRange.MaxStamp != 10 {
+		t.Errorf("MaxVersions = %d, TimeRange = %v", tget.GetMaxVersions(), tget.TimeRange)
+	}
+	if string(tget.FilterString) != "KeyOnlyFilter()" || tget.GetCacheBlocks() {
+		t.Errorf("FilterString = %q, CacheBlocks = %v", tget.FilterString, tget.GetCacheBlocks())
+	}
+	if tget.GetStoreLimit() != 5 || tget.GetStoreOffset() != 2 {
+		t.Errorf("StoreLimit = %d, StoreOffset = %d", tget.GetStoreLimit(), tget.GetStoreOffset())
+	}
+	if tget.GetConsistency() != hbase.TConsistency_TIMELINE || tget.GetTargetReplicaId() != 1 {
+		t.Errorf("Consistency = %v, TargetReplicaId = %d", tget.GetConsistency(), tget.GetTargetReplicaId())
+	}
+	if len(tget.Authorizations.Labels) != 1 || tget.Authorizations.Labels[0] != "secret" || string(tget.Attributes["k"]) != "v" {
+		t.Errorf("Authorizations = %v, Attributes = %v", tget.Authorizations, tget.Attributes)
+	}
+
+	tget, err = NewGet([]byte("row")).Timestamp(7).ExistenceOnly().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tget.GetTimestamp() != 7 || !tget.GetExistenceOnly() || tget.Columns != nil {
+		t.Errorf("Timestamp = %d, ExistenceOnly = %v, Columns = %v", tget.GetTimestamp(), tget.GetExistenceOnly(), tget.Columns)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    *GetBuilder
+		want error
+	}{
+		{"nil row", NewGet(nil), ErrEmptyRow},
+		{"empty row", NewGet([]byte{}).Columns("cf"), ErrEmptyRow},
+		{"family", NewGet([]byte("r")).Family(""), ErrInvalidColumn},
+		{"column", NewGet([]byte("r")).Column("", "q"), ErrInvalidColumn},
+		{"columns", NewGet([]byte("r")).Columns("cf", ""), ErrInvalidColumn},
+		{"versions", NewGet([]byte("r")).Versions(0), ErrInvalidVersions},
+		{"time range", NewGet([]byte("r")).TimeRange(2, 1), ErrInvalidTimeRange},
+		{"store limit", NewGet([]byte("r")).StoreLimit(0), ErrInvalidLimit},
+		// 返回第一个错误
+		{"first error", NewGet([]byte("r")).Versions(0).StoreLimit(0), ErrInvalidVersions},
+	} {
+		if tget, err := tc.b.Build(); !errors.Is(err, tc.want) || tget != nil {
+			t.Errorf("%s: Build() = %v, %v, want nil, %v", tc.name, tget, err, tc.want)
+		}
+	}
+}
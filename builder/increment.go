@@ -0,0 +1,137 @@
+// TIncrement和TAppend构造器
+package builder
+
+import (
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// IncrementBuilder TIncrement构造器
+type IncrementBuilder struct {
+	tincrement *hbase.TIncrement
+	errHolder
+}
+
+// NewIncrement 创建对指定行计数器自增的TIncrement构造器
+func NewIncrement(row []byte) *IncrementBuilder {
+	b := &IncrementBuilder{tincrement: hbase.NewTIncrement()}
+	b.tincrement.Row = row
+	if len(row) == 0 {
+		b.setErr(ErrEmptyRow)
+	}
+	return b
+}
+
+// Add 对计数器列增加amount,amount可以为负数
+func (b *IncrementBuilder) Add(family, qualifier string, amount int64) *IncrementBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tincrement.Columns = append(b.tincrement.Columns, &hbase.TColumnIncrement{
+		Family:    []byte(family),
+		Qualifier: []byte(qualifier),
+		Amount:    amount,
+	})
+	return b
+}
+
+// ReturnResults 设置是否返回自增后的值,返回值可以用codec.Counter读取
+func (b *IncrementBuilder) ReturnResults(returnResults bool) *IncrementBuilder {
+	b.tincrement.ReturnResults = thrift.BoolPtr(returnResults)
+	return b
+}
+
+// Durability 设置写WAL的方式
+func (b *IncrementBuilder) Durability(durability hbase.TDurability) *IncrementBuilder {
+	b.tincrement.Durability = &durability
+	return b
+}
+
+// CellVisibility 设置单元格的可见性表达式
+func (b *IncrementBuilder) CellVisibility(expression string) *IncrementBuilder {
+	b.tincrement.CellVisibility = &hbase.TCellVisibility{Expression: thrift.StringPtr(expression)}
+	return b
+}
+
+// Attribute 设置请求属性
+func (b *IncrementBuilder) Attribute(key string, value []byte) *IncrementBuilder {
+	b.tincrement.Attributes = setAttribute(b.tincrement.Attributes, key, value)
+	return b
+}
+
+// Build 构造TIncrement,返回构造过程中的第一个参数错误,没有添加计数器列时返回ErrNoColumns
+func (b *IncrementBuilder) Build() (*hbase.TIncrement, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	if len(b.tincrement.Columns) == 0 {
+		return nil, ErrNoColumns
+	}
+	return b.tincrement, nil
+}
+
+// AppendBuilder TAppend构造器
+type AppendBuilder struct {
+	tappend *hbase.TAppend
+	errHolder
+}
+
+// NewAppend 创建向指定行的单元格追加内容的TAppend构造器
+func NewAppend(row []byte) *AppendBuilder {
+	b := &AppendBuilder{tappend: hbase.NewTAppend()}
+	b.tappend.Row = row
+	if len(row) == 0 {
+		b.setErr(ErrEmptyRow)
+	}
+	return b
+}
+
+// Add 向单元格末尾追加value
+func (b *AppendBuilder) Add(family, qualifier string, value []byte) *AppendBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tappend.Columns = append(b.tappend.Columns, &hbase.TColumnValue{
+		Family:    []byte(family),
+		Qualifier: []byte(qualifier),
+		Value:     value,
+	})
+	return b
+}
+
+// ReturnResults 设置是否返回追加后的值
+func (b *AppendBuilder) ReturnResults(returnResults bool) *AppendBuilder {
+	b.tappend.ReturnResults = thrift.BoolPtr(returnResults)
+	return b
+}
+
+// Durability 设置写WAL的方式
+func (b *AppendBuilder) Durability(durability hbase.TDurability) *AppendBuilder {
+	b.tappend.Durability = &durability
+	return b
+}
+
+// CellVisibility 设置单元格的可见性表达式
+func (b *AppendBuilder) CellVisibility(expression string) *AppendBuilder {
+	b.tappend.CellVisibility = &hbase.TCellVisibility{Expression: thrift.StringPtr(expression)}
+	return b
+}
+
+// Attribute 设置请求属性
+func (b *AppendBuilder) Attribute(key string, value []byte) *AppendBuilder {
+	b.tappend.Attributes = setAttribute(b.tappend.Attributes, key, value)
+	return b
+}
+
+// Build 构造TAppend,返回构造过程中的第一个参数错误,没有添加单元格时返回ErrNoColumns
+func (b *AppendBuilder) Build() (*hbase.TAppend, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	if len(b.tappend.Columns) == 0 {
+		return nil, ErrNoColumns
+	}
+	return b.tappend, nil
+}
@@ -0,0 +1,86 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+func TestIncrement(t *testing.T) {
+	tincrement, err := NewIncrement([]byte("row")).
+		Add("cf", "a", 1).
+		Add("cf", "b", -2).
+		ReturnResults(true).
+		Durability(hbase.TDurability_SYNC_WAL).
+		CellVisibility("secret").
+		Attribute("k", []byte("v")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(tincrement.Row) != "row" || len(tincrement.Columns) != 2 {
+		t.Fatalf("Build() = %+v", tincrement)
+	}
+	for i, want := range []struct {
+		qualifier string
+		amount    int64
+	}{{"a", 1}, {"b", -2}} {
+		c := tincrement.Columns[i]
+		if string(c.Family) != "cf" || string(c.Qualifier) != want.qualifier || c.Amount != want.amount {
+			t.Errorf("Columns[%d] = %s:%s+%d, want cf:%s+%d", i, c.Family, c.Qualifier, c.Amount, want.qualifier, want.amount)
+		}
+	}
+	if !tincrement.GetReturnResults() || tincrement.GetDurability() != hbase.TDurability_SYNC_WAL {
+		t.Errorf("ReturnResults = %v, Durability = %v", tincrement.GetReturnResults(), tincrement.GetDurability())
+	}
+	if tincrement.CellVisibility.GetExpression() != "secret" || string(tincrement.Attributes["k"]) != "v" {
+		t.Errorf("CellVisibility = %v, Attributes = %v", tincrement.CellVisibility, tincrement.Attributes)
+	}
+
+	for _, tc := range []struct {
+		name string
+		b    *IncrementBuilder
+		want error
+	}{
+		{"empty row", NewIncrement(nil).Add("cf", "a", 1), ErrEmptyRow},
+		{"no columns", NewIncrement([]byte("r")), ErrNoColumns},
+		{"empty family", NewIncrement([]byte("r")).Add("", "a", 1), ErrInvalidColumn},
+	} {
+		if tincrement, err := tc.b.Build(); !errors.Is(err, tc.want) || tincrement != nil {
+			t.Errorf("%s: Build() = %v, %v, want nil, %v", tc.name, tincrement, err, tc.want)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	tappend, err := NewAppend([]byte("row")).
+		Add("cf", "a", []byte("x")).
+		ReturnResults(false).
+		Durability(hbase.TDurability_FSYNC_WAL).
+		Attribute("k", []byte("v")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tappend.Columns) != 1 || string(tappend.Columns[0].Qualifier) != "a" || string(tappend.Columns[0].Value) != "x" {
+		t.Errorf("Columns = %v", tappend.Columns)
+	}
+	if tappend.ReturnResults == nil || tappend.GetReturnResults() || tappend.GetDurability() != hbase.TDurability_FSYNC_WAL {
+		t.Errorf("ReturnResults = %v, Durability = %v", tappend.ReturnResults, tappend.GetDurability())
+	}
+
+	for _, tc := range []struct {
+		name string
+		b    *AppendBuilder
+		want error
+	}{
+		{"empty row", NewAppend(nil).Add("cf", "a", nil), ErrEmptyRow},
+		{"no columns", NewAppend([]byte("r")), ErrNoColumns},
+		{"empty family", NewAppend([]byte("r")).Add("", "a", nil), ErrInvalidColumn},
+	} {
+		if tappend, err := tc.b.Build(); !errors.Is(err, tc.want) || tappend != nil {
+			t.Errorf("%s: Build() = %v, %v, want nil, %v", tc.name, tappend, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// TPut和TDelete构造器
+package builder
+
+import (
+	"github.com/Golang-Tools/aliexhbase/codec"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// PutBuilder TPut构造器
+type PutBuilder struct {
+	tput *hbase.TPut
+	errHolder
+}
+
+// NewPut 创建写入指定行的TPut构造器
+func NewPut(row []byte) *PutBuilder {
+	b := &PutBuilder{tput: hbase.NewTPut()}
+	b.tput.Row = row
+	if len(row) == 0 {
+		b.setErr(ErrEmptyRow)
+	}
+	return b
+}
+
+// add 添加一个单元格
+func (b *PutBuilder) add(family, qualifier string, ts *int64, value []byte) *PutBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tput.ColumnValues = append(b.tput.ColumnValues, &hbase.TColumnValue{
+		Family:    []byte(family),
+		Qualifier: []byte(qualifier),
+		Value:     value,
+		Timestamp: ts,
+	})
+	return b
+}
+
+// Add 写入一个单元格
+func (b *PutBuilder) Add(family, qualifier string, value []byte) *PutBuilder {
+	return b.add(family, qualifier, nil, value)
+}
+
+// AddAt 写入一个指定时间戳的单元格
+func (b *PutBuilder) AddAt(family, qualifier string, ts int64, value []byte) *PutBuilder {
+	return b.add(family, qualifier, thrift.Int64Ptr(ts), value)
+}
+
+// AddString 写入字符串
+func (b *PutBuilder) AddString(family, qualifier string, value string) *PutBuilder {
+	return b.add(family, qualifier, nil, codec.EncodeString(value))
+}
+
+// AddInt64 写入Bytes.toBytes(long)编码的整数,可以作为计数器的初始值
+func (b *PutBuilder) AddInt64(family, qualifier string, value int64) *PutBuilder {
+	return b.add(family, qualifier, nil, codec.EncodeInt64(value))
+}
+
+// Timestamp 设置没有单独指定时间戳的单元格的时间戳
+func (b *PutBuilder) Timestamp(ts int64) *PutBuilder {
+	b.tput.Timestamp = thrift.Int64Ptr(ts)
+	return b
+}
+
+// Durability 设置写WAL的方式
+func (b *PutBuilder) Durability(durability hbase.TDurability) *PutBuilder {
+	b.tput.Durability = &durability
+	return b
+}
+
+// CellVisibility 设置单元格的可见性表达式
+func (b *PutBuilder) CellVisibility(expression string) *PutBuilder {
+	b.tput.CellVisibility = &hbase.TCellVisibility{Expression: thrift.StringPtr(expression)}
+	return b
+}
+
+// Attribute 设置请求属性
+func (b *PutBuilder) Attribute(key string, value []byte) *PutBuilder {
+	b.tput.Attributes = setAttribute(b.tput.Attributes, key, value)
+	return b
+}
+
+// Build 构造TPut,返回构造过程中的第一个参数错误,没有写入任何单元格时返回ErrNoColumns
+func (b *PutBuilder) Build() (*hbase.TPut, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	if len(b.tput.ColumnValues) == 0 {
+		return nil, ErrNoColumns
+	}
+	return b.tput, nil
+}
+
+// DeleteBuilder TDelete构造器
+type DeleteBuilder struct {
+	tdelete *hbase.TDelete
+	errHolder
+}
+
+// NewDelete 创建删除指定行的TDelete构造器,不指定列时删除整行
+func NewDelete(row []byte) *DeleteBuilder {
+	b := &DeleteBuilder{tdelete: hbase.NewTDelete()}
+	b.tdelete.Row = row
+	if len(row) == 0 {
+		b.setErr(ErrEmptyRow)
+	}
+	return b
+}
+
+// Family 删除整个列族
+func (b *DeleteBuilder) Family(family string) *DeleteBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tdelete.Columns = append(b.tdelete.Columns, &hbase.TColumn{Family: []byte(family)})
+	return b
+}
+
+// Column 删除指定列
+func (b *DeleteBuilder) Column(family, qualifier string) *DeleteBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tdelete.Columns = append(b.tdelete.Columns, &hbase.TColumn{Family: []byte(family), Qualifier: []byte(qualifier)})
+	return b
+}
+
+// ColumnAt 删除指定列在指定时间戳的版本
+func (b *DeleteBuilder) ColumnAt(family, qualifier string, ts int64) *DeleteBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tdelete.Columns = append(b.tdelete.Columns, &hbase.TColumn{Family: []byte(family), Qualifier: []byte(qualifier), Timestamp: thrift.Int64Ptr(ts)})
+	return b
+}
+
+// Columns 删除`family:qualifier`形式指定的多个列,只有列族时删除整个列族
+func (b *DeleteBuilder) Columns(columns ...string) *DeleteBuilder {
+	cols, err := parseColumns(columns)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tdelete.Columns = append(b.tdelete.Columns, cols...)
+	return b
+}
+
+// LatestVersionOnly 只删除列的最新版本,默认删除所有版本
+func (b *DeleteBuilder) LatestVersionOnly() *DeleteBuilder {
+	b.tdelete.DeleteType = hbase.TDeleteType_DELETE_COLUMN
+	return b
+}
+
+// Timestamp 只删除时间戳不大于ts的版本
+func (b *DeleteBuilder) Timestamp(ts int64) *DeleteBuilder {
+	b.tdelete.Timestamp = thrift.Int64Ptr(ts)
+	return b
+}
+
+// Durability 设置写WAL的方式
+func (b *DeleteBuilder) Durability(durability hbase.TDurability) *DeleteBuilder {
+	b.tdelete.Durability = &durability
+	return b
+}
+
+// Attribute 设置请求属性
+func (b *DeleteBuilder) Attribute(key string, value []byte) *DeleteBuilder {
+	b.tdelete.Attributes = setAttribute(b.tdelete.Attributes, key, value)
+	return b
+}
+
+// Build 构造TDelete,返回构造过程中的第一个参数错误
+func (b *DeleteBuilder) Build() (*hbase.TDelete, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	return b.tdelete, nil
+}
@@ -0,0 +1,129 @@
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/codec"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+func TestPut(t *testing.T) {
+	tput, err := NewPut([]byte("row")).
+		Add("cf", "a", []byte("1")).
+		AddAt("cf", "b", 5, []byte("2")).
+		AddString("cf", "c", "中文").
+		AddInt64("cf", "n", -1).
+		Timestamp(9).
+		Durability(hbase.TDurability_SKIP_WAL).
+		CellVisibility("secret").
+		Attribute("k", []byte("v")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(tput.Row) != "row" || tput.GetTimestamp() != 9 || tput.GetDurability() != hbase.TDurability_SKIP_WAL {
+		t.Errorf("Row = %q, Timestamp = %d, Durability = %v", tput.Row, tput.GetTimestamp(), tput.GetDurability())
+	}
+	if tput.CellVisibility.GetExpression() != "secret" || string(tput.Attributes["k"]) != "v" {
+		t.Errorf("CellVisibility = %v, Attributes = %v", tput.CellVisibility, tput.Attributes)
+	}
+	want := []struct {
+		qualifier string
+		value     []byte
+		ts        *int64
+	}{
+		{"a", []byte("1"), nil},
+		{"b", []byte("2"), &[]int64{5}[0]},
+		{"c", codec.EncodeString("中文"), nil},
+		{"n", codec.EncodeInt64(-1), nil},
+	}
+	if len(tput.ColumnValues) != len(want) {
+		t.Fatalf("ColumnValues = %v", tput.ColumnValues)
+	}
+	for i, cv := range tput.ColumnValues {
+		w := want[i]
+		if string(cv.Family) != "cf" || string(cv.Qualifier) != w.qualifier || !bytes.Equal(cv.Value, w.value) {
+			t.Errorf("ColumnValues[%d] = %s:%s=%x, want cf:%s=%x", i, cv.Family, cv.Qualifier, cv.Value, w.qualifier, w.value)
+		}
+		if (cv.Timestamp == nil) != (w.ts == nil) || (w.ts != nil && *cv.Timestamp != *w.ts) {
+			t.Errorf("ColumnValues[%d].Timestamp = %v, want %v", i, cv.Timestamp, w.ts)
+		}
+	}
+}
+
+func TestPutErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    *PutBuilder
+		want error
+	}{
+		{"empty row", NewPut(nil).Add("cf", "a", nil), ErrEmptyRow},
+		{"no columns", NewPut([]byte("r")), ErrNoColumns},
+		{"empty family", NewPut([]byte("r")).Add("", "a", nil), ErrInvalidColumn},
+		{"empty family at", NewPut([]byte("r")).Add("cf", "a", nil).AddAt("", "b", 1, nil), ErrInvalidColumn},
+	} {
+		if tput, err := tc.b.Build(); !errors.Is(err, tc.want) || tput != nil {
+			t.Errorf("%s: Build() = %v, %v, want nil, %v", tc.name, tput, err, tc.want)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	// 不指定列时删除整行
+	tdelete, err := NewDelete([]byte("row")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(tdelete.Row) != "row" || tdelete.Columns != nil || tdelete.DeleteType != hbase.TDeleteType_DELETE_COLUMNS {
+		t.Errorf("Build() = %+v, want whole row delete", tdelete)
+	}
+
+	tdelete, err = NewDelete([]byte("row")).
+		Family("cf").
+		Column("cf", "a").
+		ColumnAt("cf", "b", 3).
+		Columns("cf2:c", "cf3").
+		LatestVersionOnly().
+		Timestamp(10).
+		Durability(hbase.TDurability_ASYNC_WAL).
+		Attribute("k", []byte("v")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantColumns := []string{"cf:", "cf:a", "cf:b", "cf2:c", "cf3:"}
+	if len(tdelete.Columns) != len(wantColumns) {
+		t.Fatalf("Columns = %v, want %v", tdelete.Columns, wantColumns)
+	}
+	for i, c := range tdelete.Columns {
+		if got := string(c.Family) + ":" + string(c.Qualifier); got != wantColumns[i] {
+			t.Errorf("Columns[%d] = %s, want %s", i, got, wantColumns[i])
+		}
+	}
+	if tdelete.Columns[2].GetTimestamp() != 3 || tdelete.Columns[1].Timestamp != nil {
+		t.Errorf("column timestamps = %v, %v", tdelete.Columns[1].Timestamp, tdelete.Columns[2].Timestamp)
+	}
+	if tdelete.DeleteType != hbase.TDeleteType_DELETE_COLUMN || tdelete.GetTimestamp() != 10 || tdelete.GetDurability() != hbase.TDurability_ASYNC_WAL {
+		t.Errorf("DeleteType = %v, Timestamp = %d, Durability = %v", tdelete.DeleteType, tdelete.GetTimestamp(), tdelete.GetDurability())
+	}
+	if string(tdelete.Attributes["k"]) != "v" {
+		t.Errorf("Attributes = %v", tdelete.Attributes)
+	}
+
+	for _, tc := range []struct {
+		name string
+		b    *DeleteBuilder
+		want error
+	}{
+		{"empty row", NewDelete([]byte{}), ErrEmptyRow},
+		{"family", NewDelete([]byte("r")).Family(""), ErrInvalidColumn},
+		{"column at", NewDelete([]byte("r")).ColumnAt("", "a", 1), ErrInvalidColumn},
+		{"columns", NewDelete([]byte("r")).Columns(":a"), ErrInvalidColumn},
+	} {
+		if tdelete, err := tc.b.Build(); !errors.Is(err, tc.want) || tdelete != nil {
+			t.Errorf("%s: Build() = %v, %v, want nil, %v", tc.name, tdelete, err, tc.want)
+		}
+	}
+}
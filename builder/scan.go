@@ -0,0 +1,232 @@
+// TScan构造器
+package builder
+
+import (
+	"github.com/Golang-Tools/aliexhbase/filter"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// ScanBuilder TScan构造器
+type ScanBuilder struct {
+	tscan     *hbase.TScan
+	prefix    []byte
+	hasPrefix bool
+	filter    string
+	errHolder
+}
+
+// NewScan 创建TScan构造器,默认扫描全表
+func NewScan() *ScanBuilder {
+	return &ScanBuilder{tscan: hbase.NewTScan()}
+}
+
+// StartRow 设置起始行(包含),反向扫描时为最大的行
+func (b *ScanBuilder) StartRow(row []byte) *ScanBuilder {
+	b.tscan.StartRow = row
+	return b
+}
+
+// StopRow 设置结束行(不包含),反向扫描时为最小的行
+func (b *ScanBuilder) StopRow(row []byte) *ScanBuilder {
+	b.tscan.StopRow = row
+	return b
+}
+
+// Prefix 只扫描以prefix开头的行,不能与StartRow/StopRow同时使用
+// 正向扫描时起始行为prefix,结束行为PrefixStopRow(prefix);
+// 反向扫描时起始行为PrefixStopRow(prefix),并加上PrefixFilter去掉不以prefix开头的行,离开前缀范围后服务端会提前结束扫描.
+// prefix全部为0xFF时结束行为空,即扫描到表尾
+func (b *ScanBuilder) Prefix(prefix []byte) *ScanBuilder {
+	b.prefix = prefix
+	b.hasPrefix = len(prefix) > 0
+	return b
+}
+
+// Family 扫描整个列族
+func (b *ScanBuilder) Family(family string) *ScanBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tscan.Columns = append(b.tscan.Columns, &hbase.TColumn{Family: []byte(family)})
+	return b
+}
+
+// Column 扫描指定列
+func (b *ScanBuilder) Column(family, qualifier string) *ScanBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tscan.Columns = append(b.tscan.Columns, &hbase.TColumn{Family: []byte(family), Qualifier: []byte(qualifier)})
+	return b
+}
+
+// Columns 扫描`family:qualifier`形式指定的多个列,只有列族时扫描整个列族
+func (b *ScanBuilder) Columns(columns ...string) *ScanBuilder {
+	cols, err := parseColumns(columns)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tscan.Columns = append(b.tscan.Columns, cols...)
+	return b
+}
+
+// Versions 设置每列最多返回的版本数
+func (b *ScanBuilder) Versions(n int32) *ScanBuilder {
+	if n <= 0 {
+		b.setErr(ErrInvalidVersions)
+		return b
+	}
+	b.tscan.MaxVersions = n
+	return b
+}
+
+// TimeRange 只扫描时间戳在[min,max)之间的版本
+func (b *ScanBuilder) TimeRange(min, max int64) *ScanBuilder {
+	tr, err := newTimeRange(min, max)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	b.tscan.TimeRange = tr
+	return b
+}
+
+// FamilyTimeRange 为单个列族设置时间范围[min,max)
+func (b *ScanBuilder) FamilyTimeRange(family string, min, max int64) *ScanBuilder {
+	if err := checkFamily(family); err != nil {
+		b.setErr(err)
+		return b
+	}
+	tr, err := newTimeRange(min, max)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	if b.tscan.ColFamTimeRangeMap == nil {
+		b.tscan.ColFamTimeRangeMap = map[string]*hbase.TTimeRange{}
+	}
+	b.tscan.ColFamTimeRangeMap[family] = tr
+	return b
+}
+
+// Filter 设置过滤器字符串
+func (b *ScanBuilder) Filter(filterString string) *ScanBuilder {
+	b.filter = filterString
+	return b
+}
+
+// FilterBy 使用filter包构造的过滤器,过滤器不合法时Build返回filter.Validate的错误
+func (b *ScanBuilder) FilterBy(f filter.Filter) *ScanBuilder {
+	filterString, err := filter.Build(f)
+	if err != nil {
+		b.setErr(err)
+		return b
+	}
+	return b.Filter(filterString)
+}
+
+// Caching 设置每次rpc获取的行数
+func (b *ScanBuilder) Caching(n int32) *ScanBuilder {
+	if n <= 0 {
+		b.setErr(ErrInvalidLimit)
+		return b
+	}
+	b.tscan.Caching = thrift.Int32Ptr(n)
+	return b
+}
+
+// BatchSize 设置每个结果最多包含的列数,宽行会被拆分为多个结果
+func (b *ScanBuilder) BatchSize(n int32) *ScanBuilder {
+	if n <= 0 {
+		b.setErr(ErrInvalidLimit)
+		return b
+	}
+	b.tscan.BatchSize = thrift.Int32Ptr(n)
+	return b
+}
+
+// Limit 设置最多返回的行数
+func (b *ScanBuilder) Limit(n int32) *ScanBuilder {
+	if n <= 0 {
+		b.setErr(ErrInvalidLimit)
+		return b
+	}
+	b.tscan.Limit = thrift.Int32Ptr(n)
+	return b
+}
+
+// Reverse 反向扫描
+func (b *ScanBuilder) Reverse() *ScanBuilder {
+	b.tscan.Reversed = thrift.BoolPtr(true)
+	return b
+}
+
+// CacheBlocks 设置是否缓存读取的数据块
+func (b *ScanBuilder) CacheBlocks(cache bool) *ScanBuilder {
+	b.tscan.CacheBlocks = thrift.BoolPtr(cache)
+	return b
+}
+
+// ReadType 设置读取方式
+func (b *ScanBuilder) ReadType(readType hbase.TReadType) *ScanBuilder {
+	b.tscan.ReadType = &readType
+	return b
+}
+
+// Consistency 设置一致性级别
+func (b *ScanBuilder) Consistency(consistency hbase.TConsistency) *ScanBuilder {
+	b.tscan.Consistency = &consistency
+	return b
+}
+
+// TargetReplica 设置读取的副本,需要配合TConsistency_TIMELINE使用
+func (b *ScanBuilder) TargetReplica(id int32) *ScanBuilder {
+	b.tscan.TargetReplicaId = thrift.Int32Ptr(id)
+	return b
+}
+
+// Authorizations 设置可见性标签
+func (b *ScanBuilder) Authorizations(labels ...string) *ScanBuilder {
+	b.tscan.Authorizations = &hbase.TAuthorization{Labels: labels}
+	return b
+}
+
+// Attribute 设置请求属性
+func (b *ScanBuilder) Attribute(key string, value []byte) *ScanBuilder {
+	b.tscan.Attributes = setAttribute(b.tscan.Attributes, key, value)
+	return b
+}
+
+// Build 构造TScan,返回构造过程中的第一个参数错误
+func (b *ScanBuilder) Build() (*hbase.TScan, error) {
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	tscan := *b.tscan
+	filterString := b.filter
+	if b.hasPrefix {
+		if len(tscan.StartRow) > 0 || len(tscan.StopRow) > 0 {
+			return nil, ErrPrefixWithRange
+		}
+		if tscan.GetReversed() {
+			tscan.StartRow = PrefixStopRow(b.prefix)
+			prefixFilter := filter.PrefixFilter{Prefix: b.prefix}.String()
+			if filterString == "" {
+				filterString = prefixFilter
+			} else {
+				filterString = prefixFilter + " AND (" + filterString + ")"
+			}
+		} else {
+			tscan.StartRow = b.prefix
+			tscan.StopRow = PrefixStopRow(b.prefix)
+		}
+	}
+	if filterString != "" {
+		tscan.FilterString = []byte(filterString)
+	}
+	return &tscan, nil
+}
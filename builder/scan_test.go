@@ -0,0 +1,154 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScanPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		prefix     string
+		reverse    bool
+		filter     string
+		wantStart  string
+		wantStop   string
+		wantFilter string
+	}{
+		{"forward", "user_", false, "", "user_", "user`", ""},
+		{"forward trailing 0xff", "a\xff", false, "", "a\xff", "b", ""},
+		// 全部为0xFF时没有结束行,扫描到表尾
+		{"forward all 0xff", "\xff\xff", false, "", "\xff\xff", "", ""},
+		{"forward with filter", "a", false, "KeyOnlyFilter()", "a", "b", "KeyOnlyFilter()"},
+		// 反向扫描从前缀范围之后开始,由PrefixFilter去掉不以前缀开头的行
+		{"reverse", "user_", true, "", "user`", "", "PrefixFilter('user_')"},
+		{"reverse trailing 0xff", "a\xff", true, "", "b", "", "PrefixFilter('a\xff')"},
+		{"reverse all 0xff", "\xff\xff", true, "", "", "", "PrefixFilter('\xff\xff')"},
+		{"reverse with filter", "a", true, "KeyOnlyFilter() OR FirstKeyOnlyFilter()", "b", "", "PrefixFilter('a') AND (KeyOnlyFilter() OR FirstKeyOnlyFilter())"},
+		{"reverse quoted", "it's", true, "", "it't", "", "PrefixFilter('it''s')"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewScan().Prefix([]byte(tc.prefix))
+			if tc.reverse {
+				b.Reverse()
+			}
+			if tc.filter != "" {
+				b.Filter(tc.filter)
+			}
+			tscan, err := b.Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(tscan.StartRow); got != tc.wantStart {
+				t.Errorf("StartRow = %q, want %q", got, tc.wantStart)
+			}
+			if got := string(tscan.StopRow); got != tc.wantStop {
+				t.Errorf("StopRow = %q, want %q", got, tc.wantStop)
+			}
+			if got := string(tscan.FilterString); got != tc.wantFilter {
+				t.Errorf("FilterString = %q, want %q", got, tc.wantFilter)
+			}
+			if tscan.GetReversed() != tc.reverse {
+				t.Errorf("Reversed = %v, want %v", tscan.GetReversed(), tc.reverse)
+			}
+		})
+	}
+}
+
+func TestScanEmptyPrefix(t *testing.T) {
+	// 空前缀等同于扫描全表
+	tscan, err := NewScan().Prefix(nil).Reverse().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tscan.StartRow != nil || tscan.StopRow != nil || tscan.FilterString != nil {
+		t.Fatalf("Build() = %+v, want full table scan", tscan)
+	}
+	// 空前缀不与起止行冲突
+	if _, err := NewScan().Prefix([]byte{}).StartRow([]byte("a")).Build(); err != nil {
+		t.Fatalf("Build() err = %v, want nil", err)
+	}
+}
+
+func TestScanPrefixWithRange(t *testing.T) {
+	for _, b := range []*ScanBuilder{
+		NewScan().Prefix([]byte("a")).StartRow([]byte("a1")),
+		NewScan().StopRow([]byte("b")).Prefix([]byte("a")),
+		NewScan().Prefix([]byte("a")).StartRow([]byte("a1")).Reverse(),
+	} {
+		if _, err := b.Build(); !errors.Is(err, ErrPrefixWithRange) {
+			t.Errorf("Build() err = %v, want ErrPrefixWithRange", err)
+		}
+	}
+}
+
+func TestScanBuildDoesNotModifyBuilder(t *testing.T) {
+	b := NewScan().Prefix([]byte("a")).Reverse().Filter("KeyOnlyFilter()")
+	first, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first.FilterString) != string(second.FilterString) || string(second.StartRow) != "b" {
+		t.Fatalf("second Build() = %+v, want same as first %+v", second, first)
+	}
+}
+
+func TestScanOptions(t *testing.T) {
+	tscan, err := NewScan().
+		StartRow([]byte("a")).
+		StopRow([]byte("z")).
+		Columns("cf:a", "cf2").
+		Family("cf3").
+		Column("cf4", "q").
+		Versions(2).
+		TimeRange(1, 10).
+		FamilyTimeRange("cf", 2, 5).
+		Caching(100).
+		BatchSize(10).
+		Limit(50).
+		CacheBlocks(false).
+		Attribute("k", []byte("v")).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tscan.Columns) != 4 || string(tscan.Columns[3].Qualifier) != "q" {
+		t.Errorf("Columns = %v", tscan.Columns)
+	}
+	if tscan.MaxVersions != 2 || tscan.TimeRange.MinStamp != 1 || tscan.TimeRange.MaxStamp != 10 {
+		t.Errorf("MaxVersions = %d, TimeRange = %v", tscan.MaxVersions, tscan.TimeRange)
+	}
+	if tr := tscan.ColFamTimeRangeMap["cf"]; tr == nil || tr.MinStamp != 2 || tr.MaxStamp != 5 {
+		t.Errorf("ColFamTimeRangeMap = %v", tscan.ColFamTimeRangeMap)
+	}
+	if tscan.GetCaching() != 100 || tscan.GetBatchSize() != 10 || tscan.GetLimit() != 50 || tscan.GetCacheBlocks() {
+		t.Errorf("Caching = %d, BatchSize = %d, Limit = %d, CacheBlocks = %v", tscan.GetCaching(), tscan.GetBatchSize(), tscan.GetLimit(), tscan.GetCacheBlocks())
+	}
+	if string(tscan.Attributes["k"]) != "v" {
+		t.Errorf("Attributes = %v", tscan.Attributes)
+	}
+
+	for _, tc := range []struct {
+		name string
+		b    *ScanBuilder
+		want error
+	}{
+		{"versions", NewScan().Versions(0), ErrInvalidVersions},
+		{"time range", NewScan().TimeRange(10, 1), ErrInvalidTimeRange},
+		{"family time range", NewScan().FamilyTimeRange("", 1, 10), ErrInvalidColumn},
+		{"caching", NewScan().Caching(0), ErrInvalidLimit},
+		{"batch size", NewScan().BatchSize(-1), ErrInvalidLimit},
+		{"limit", NewScan().Limit(0), ErrInvalidLimit},
+		{"column", NewScan().Columns(":a"), ErrInvalidColumn},
+		// 返回第一个错误
+		{"first error", NewScan().Limit(0).Versions(0), ErrInvalidLimit},
+	} {
+		if _, err := tc.b.Build(); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Build() err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -3,11 +3,9 @@ package aliexhbase
 
 import (
 	"context"
-	"net"
 	"time"
 
 	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
-	"github.com/apache/thrift/lib/go/thrift"
 )
 
 type Client struct {
@@ -18,10 +16,12 @@ type Client struct {
 func New(opts ...Option) (*Client, error) {
 	c := new(Client)
 	c.Opts = DefaultOptions
+	// 配置项会直接修改连接池配置,复制一份以免修改到默认配置
+	c.Opts.Poolconfig = c.Opts.Poolconfig.Clone()
 	for _, opt := range opts {
 		opt.Apply(&c.Opts)
 	}
-	if c.Opts.Poolconfig.Addr == "" || c.Opts.Poolconfig.User == "" || c.Opts.Poolconfig.Passwd == "" {
+	if c.Opts.Poolconfig.Addr == "" {
 		return nil, ErrClientCreateParamsNotEnough
 	}
 	c.pool = NewThriftPool(c.Opts.Poolconfig)
@@ -86,6 +86,14 @@ func (c *Client) Open() error {
 	return nil
 }
 
+// PoolStats 获取连接池统计信息
+func (c *Client) PoolStats() PoolStats {
+	if c.pool == nil {
+		return PoolStats{}
+	}
+	return c.pool.Stats()
+}
+
 //IsOpen 判断客户端是否已经开启
 func (c *Client) IsOpen() bool {
 	if c.pool == nil {
@@ -95,60 +103,64 @@ func (c *Client) IsOpen() bool {
 }
 
 // do 通过闭包中调用来处理连接池中的连接对象的上下文
-func (p *Client) do(fn func(conn *Conn) error) error {
-	var (
-		client *Conn
-		err    error
-	)
-	defer func() {
-		if client != nil {
-			if err == nil {
-				rErr := p.pool.Put(client)
-				if rErr != nil {
-					p.Opts.Logger.WithError(rErr).Error("Release Client error")
-				}
-			} else if _, ok := err.(net.Error); ok {
-				p.pool.CloseConn(client)
-			} else if _, ok = err.(thrift.TTransportException); ok {
-				p.pool.CloseConn(client)
-			} else {
-				if rErr := p.pool.Put(client); rErr != nil {
-					p.Opts.Logger.WithError(rErr).Error("Release Client error")
-				}
-			}
+// 请求会依次经过注册的拦截器,最后由invoke实际执行
+func (p *Client) do(ctx context.Context, op *Operation, fn func(ctx context.Context, conn *Conn) (interface{}, error)) error {
+	invoker := func(ctx context.Context, op *Operation) error {
+		return p.invoke(ctx, op, fn)
+	}
+	invoker = chainInterceptors(p.Opts.Interceptors, invoker)
+	if p.Opts.SlowLog.Threshold > 0 {
+		return p.slowLog(ctx, op, invoker)
+	}
+	return invoker(ctx, op)
+}
+
+// invoke 实际执行请求
+// 获取连接时会遵循ctx的取消和截止时间,请求失败时按重试策略重试,
+// 最终返回的错误会被包装为带有操作上下文的*exceptions.Error
+func (p *Client) invoke(ctx context.Context, op *Operation, fn func(ctx context.Context, conn *Conn) (interface{}, error)) error {
+	retry := &p.Opts.Retry
+	for attempt := 1; ; attempt++ {
+		err := p.attempt(ctx, op, fn)
+		if err == nil {
+			return nil
 		}
-	}()
+		if !retry.shouldRetry(ctx, op, attempt, err) {
+			return op.wrapError(err)
+		}
+		if !retry.wait(ctx, attempt) {
+			return op.wrapError(err)
+		}
+		p.Opts.Logger.WithError(err).WithField("operation", op.Name).WithField("attempt", attempt).Warn("Retry hbase operation")
+	}
+}
+
+// attempt 从连接池获取连接执行一次请求,网络错误或http状态码以外的thrift传输层错误时关闭连接,否则归还连接
+func (p *Client) attempt(ctx context.Context, op *Operation, fn func(ctx context.Context, conn *Conn) (interface{}, error)) error {
 	// 从连接池里获取链接
-	client, err = p.pool.Get()
+	start := nowFunc()
+	client, err := p.pool.GetContext(ctx)
+	op.PoolWait += nowFunc().Sub(start)
 	if err != nil {
 		return err
 	}
-	err = fn(client)
-	if err != nil {
-		_, ok := err.(net.Error)
-		if ok {
-			p.Opts.Logger.WithError(err).Error("Retry TCP error")
-			// 网络错误，重建连接
-			client, err = p.pool.Reconnect(client)
-			if err != nil {
-				return err
-			}
-			return fn(client)
-		}
-
-		_, ok = err.(thrift.TTransportException)
-		if ok {
-			p.Opts.Logger.WithError(err).Error("Retry TCP error")
-			// thrift传输层错误，也重建连接
-			client, err = p.pool.Reconnect(client)
-			if err != nil {
-				return err
-			}
-			return fn(client)
-		}
+	op.Attempts++
+	sent, received := client.bytes()
+	result, err := fn(ctx, client)
+	sentAfter, receivedAfter := client.bytes()
+	op.BytesSent += sentAfter - sent
+	op.BytesReceived += receivedAfter - received
+	if err != nil && isConnError(err) {
+		p.pool.CloseConn(client)
 		return err
 	}
-	return nil
+	if rErr := p.pool.Put(client); rErr != nil {
+		p.Opts.Logger.WithError(rErr).Error("Release Client error")
+	}
+	if err == nil {
+		op.Result = result
+	}
+	return err
 }
 
 // Test for the existence of columns in the table, as specified in the TGet.
@@ -160,10 +172,10 @@ func (p *Client) do(fn func(conn *Conn) error) error {
 //  - Tget: the TGet to check for
 func (p *Client) Exists(ctx context.Context, table []byte, tget *hbase.TGet) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "Exists", Table: table, Row: tget.GetRow(), Args: []interface{}{table, tget}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.Exists(ctx, table, tget)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -181,10 +193,10 @@ func (p *Client) Exists(ctx context.Context, table []byte, tget *hbase.TGet) (bo
 //  - Tgets: a list of TGets to check for
 func (p *Client) ExistsAll(ctx context.Context, table []byte, tgets []*hbase.TGet) ([]bool, error) {
 	var result []bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "ExistsAll", Table: table, Args: []interface{}{table, tgets}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.ExistsAll(ctx, table, tgets)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return nil, err
@@ -204,10 +216,10 @@ func (p *Client) ExistsAll(ctx context.Context, table []byte, tgets []*hbase.TGe
 //  - Tget: the TGet to fetch
 func (p *Client) Get(ctx context.Context, table []byte, tget *hbase.TGet) (*hbase.TResult_, error) {
 	var tResult_ *hbase.TResult_
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "Get", Table: table, Row: tget.GetRow(), Args: []interface{}{table, tget}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.Get(ctx, table, tget)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -230,10 +242,10 @@ func (p *Client) Get(ctx context.Context, table []byte, tget *hbase.TGet) (*hbas
 // or null if there was an error
 func (p *Client) GetMultiple(ctx context.Context, table []byte, tgets []*hbase.TGet) ([]*hbase.TResult_, error) {
 	var tResult_ []*hbase.TResult_
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetMultiple", Table: table, Args: []interface{}{table, tgets}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetMultiple(ctx, table, tgets)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -247,9 +259,9 @@ func (p *Client) GetMultiple(ctx context.Context, table []byte, tgets []*hbase.T
 //  - Table: the table to put data in
 //  - Tput: the TPut to put
 func (p *Client) Put(ctx context.Context, table []byte, tput *hbase.TPut) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "Put", Table: table, Row: tput.GetRow(), Args: []interface{}{table, tput}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.Put(ctx, table, tput)
-		return err2
+		return nil, err2
 	})
 	if err != nil {
 		return err
@@ -273,10 +285,10 @@ func (p *Client) Put(ctx context.Context, table []byte, tput *hbase.TPut) error
 //  - Tput: the TPut to put if the check succeeds
 func (p *Client) CheckAndPut(ctx context.Context, table []byte, row []byte, family []byte, qualifier []byte, value []byte, tput *hbase.TPut) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "CheckAndPut", Table: table, Row: row, Args: []interface{}{table, row, family, qualifier, value, tput}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.CheckAndPut(ctx, table, row, family, qualifier, value, tput)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -290,9 +302,9 @@ func (p *Client) CheckAndPut(ctx context.Context, table []byte, row []byte, fami
 //  - Table: the table to put data in
 //  - Tputs: a list of TPuts to commit
 func (p *Client) PutMultiple(ctx context.Context, table []byte, tputs []*hbase.TPut) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "PutMultiple", Table: table, Args: []interface{}{table, tputs}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.PutMultiple(ctx, table, tputs)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -306,9 +318,9 @@ func (p *Client) PutMultiple(ctx context.Context, table []byte, tputs []*hbase.T
 //  - Table: the table to delete from
 //  - Tdelete: the TDelete to delete
 func (p *Client) DeleteSingle(ctx context.Context, table []byte, tdelete *hbase.TDelete) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "DeleteSingle", Table: table, Row: tdelete.GetRow(), Args: []interface{}{table, tdelete}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.DeleteSingle(ctx, table, tdelete)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -324,10 +336,10 @@ func (p *Client) DeleteSingle(ctx context.Context, table []byte, tdelete *hbase.
 //  - Tdeletes: list of TDeletes to delete
 func (p *Client) DeleteMultiple(ctx context.Context, table []byte, tdeletes []*hbase.TDelete) ([]*hbase.TDelete, error) {
 	var tResult_ []*hbase.TDelete
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "DeleteMultiple", Table: table, Args: []interface{}{table, tdeletes}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.DeleteMultiple(ctx, table, tdeletes)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -351,10 +363,10 @@ func (p *Client) DeleteMultiple(ctx context.Context, table []byte, tdeletes []*h
 //  - Tdelete: the TDelete to execute if the check succeeds
 func (p *Client) CheckAndDelete(ctx context.Context, table []byte, row []byte, family []byte, qualifier []byte, value []byte, tdelete *hbase.TDelete) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "CheckAndDelete", Table: table, Row: row, Args: []interface{}{table, row, family, qualifier, value, tdelete}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.CheckAndDelete(ctx, table, row, family, qualifier, value, tdelete)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -367,10 +379,10 @@ func (p *Client) CheckAndDelete(ctx context.Context, table []byte, row []byte, f
 //  - Tincrement: the TIncrement to increment
 func (p *Client) Increment(ctx context.Context, table []byte, tincrement *hbase.TIncrement) (*hbase.TResult_, error) {
 	var tResult_ *hbase.TResult_
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "Increment", Table: table, Row: tincrement.GetRow(), Args: []interface{}{table, tincrement}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.Increment(ctx, table, tincrement)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -383,10 +395,10 @@ func (p *Client) Increment(ctx context.Context, table []byte, tincrement *hbase.
 //  - Tappend: the TAppend to append
 func (p *Client) Append(ctx context.Context, table []byte, tappend *hbase.TAppend) (*hbase.TResult_, error) {
 	var tResult_ *hbase.TResult_
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "Append", Table: table, Row: tappend.GetRow(), Args: []interface{}{table, tappend}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.Append(ctx, table, tappend)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -403,10 +415,10 @@ func (p *Client) Append(ctx context.Context, table []byte, tappend *hbase.TAppen
 //  - Tscan: the scan object to get a Scanner for
 func (p *Client) OpenScanner(ctx context.Context, table []byte, tscan *hbase.TScan) (int32, error) {
 	var tResult_ int32
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "OpenScanner", Table: table, Args: []interface{}{table, tscan}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.OpenScanner(ctx, table, tscan)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return 0, err
@@ -423,10 +435,10 @@ func (p *Client) OpenScanner(ctx context.Context, table []byte, tscan *hbase.TSc
 //  - NumRows: number of rows to return
 func (p *Client) GetScannerRows(ctx context.Context, scannerId int32, numRows int32) ([]*hbase.TResult_, error) {
 	var tResult_ []*hbase.TResult_
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetScannerRows", Args: []interface{}{scannerId, numRows}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetScannerRows(ctx, scannerId, numRows)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -441,9 +453,9 @@ func (p *Client) GetScannerRows(ctx context.Context, scannerId int32, numRows in
 // Parameters:
 //  - ScannerId: the Id of the Scanner to close *
 func (p *Client) CloseScanner(ctx context.Context, scannerId int32) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "CloseScanner", Args: []interface{}{scannerId}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.CloseScanner(ctx, scannerId)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -454,9 +466,9 @@ func (p *Client) CloseScanner(ctx context.Context, scannerId int32) error {
 //  - Table: table to apply the mutations
 //  - TrowMutations: mutations to apply
 func (p *Client) MutateRow(ctx context.Context, table []byte, trowMutations *hbase.TRowMutations) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "MutateRow", Table: table, Row: trowMutations.GetRow(), Args: []interface{}{table, trowMutations}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.MutateRow(ctx, table, trowMutations)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -472,10 +484,10 @@ func (p *Client) MutateRow(ctx context.Context, table []byte, trowMutations *hba
 //  - NumRows: number of rows to return
 func (p *Client) GetScannerResults(ctx context.Context, table []byte, tscan *hbase.TScan, numRows int32) ([]*hbase.TResult_, error) {
 	var tResult_ []*hbase.TResult_
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetScannerResults", Table: table, Args: []interface{}{table, tscan, numRows}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetScannerResults(ctx, table, tscan, numRows)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -495,10 +507,10 @@ func (p *Client) GetScannerResults(ctx context.Context, table []byte, tscan *hba
 //  - Reload
 func (p *Client) GetRegionLocation(ctx context.Context, table []byte, row []byte, reload bool) (*hbase.THRegionLocation, error) {
 	var tResult_ *hbase.THRegionLocation
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetRegionLocation", Table: table, Row: row, Args: []interface{}{table, row, reload}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetRegionLocation(ctx, table, row, reload)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -513,10 +525,10 @@ func (p *Client) GetRegionLocation(ctx context.Context, table []byte, row []byte
 //  - Table
 func (p *Client) GetAllRegionLocations(ctx context.Context, table []byte) ([]*hbase.THRegionLocation, error) {
 	var tResult_ []*hbase.THRegionLocation
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetAllRegionLocations", Table: table, Args: []interface{}{table}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetAllRegionLocations(ctx, table)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -540,10 +552,10 @@ func (p *Client) GetAllRegionLocations(ctx context.Context, table []byte) ([]*hb
 //  - RowMutations: row mutations to execute if the value matches
 func (p *Client) CheckAndMutate(ctx context.Context, table []byte, row []byte, family []byte, qualifier []byte, compareOp hbase.TCompareOp, value []byte, rowMutations *hbase.TRowMutations) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "CheckAndMutate", Table: table, Row: row, Args: []interface{}{table, row, family, qualifier, compareOp, value, rowMutations}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.CheckAndMutate(ctx, table, row, family, qualifier, compareOp, value, rowMutations)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -559,10 +571,10 @@ func (p *Client) CheckAndMutate(ctx context.Context, table []byte, row []byte, f
 //  - Table: the tablename of the table to get tableDescriptor
 func (p *Client) GetTableDescriptor(ctx context.Context, table *hbase.TTableName) (*hbase.TTableDescriptor, error) {
 	var tResult_ *hbase.TTableDescriptor
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetTableDescriptor", Table: tableNameBytes(table), Args: []interface{}{table}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetTableDescriptor(ctx, table)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -578,10 +590,10 @@ func (p *Client) GetTableDescriptor(ctx context.Context, table *hbase.TTableName
 //  - Tables: the tablename list of the tables to get tableDescriptor
 func (p *Client) GetTableDescriptors(ctx context.Context, tables []*hbase.TTableName) ([]*hbase.TTableDescriptor, error) {
 	var tResult_ []*hbase.TTableDescriptor
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetTableDescriptors", Args: []interface{}{tables}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetTableDescriptors(ctx, tables)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -597,10 +609,10 @@ func (p *Client) GetTableDescriptors(ctx context.Context, tables []*hbase.TTable
 //  - TableName: the tablename of the tables to check
 func (p *Client) TableExists(ctx context.Context, tableName *hbase.TTableName) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "TableExists", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.TableExists(ctx, tableName)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -617,10 +629,10 @@ func (p *Client) TableExists(ctx context.Context, tableName *hbase.TTableName) (
 //  - IncludeSysTables: set to false if match only against userspace tables
 func (p *Client) GetTableDescriptorsByPattern(ctx context.Context, regex string, includeSysTables bool) ([]*hbase.TTableDescriptor, error) {
 	var tResult_ []*hbase.TTableDescriptor
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetTableDescriptorsByPattern", Args: []interface{}{regex, includeSysTables}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetTableDescriptorsByPattern(ctx, regex, includeSysTables)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -636,10 +648,10 @@ func (p *Client) GetTableDescriptorsByPattern(ctx context.Context, regex string,
 //  - Name: The namesapce's name
 func (p *Client) GetTableDescriptorsByNamespace(ctx context.Context, name string) ([]*hbase.TTableDescriptor, error) {
 	var tResult_ []*hbase.TTableDescriptor
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetTableDescriptorsByNamespace", Args: []interface{}{name}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetTableDescriptorsByNamespace(ctx, name)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -656,10 +668,10 @@ func (p *Client) GetTableDescriptorsByNamespace(ctx context.Context, name string
 //  - IncludeSysTables: set to false if match only against userspace tables
 func (p *Client) GetTableNamesByPattern(ctx context.Context, regex string, includeSysTables bool) ([]*hbase.TTableName, error) {
 	var tResult_ []*hbase.TTableName
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetTableNamesByPattern", Args: []interface{}{regex, includeSysTables}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetTableNamesByPattern(ctx, regex, includeSysTables)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -675,10 +687,10 @@ func (p *Client) GetTableNamesByPattern(ctx context.Context, regex string, inclu
 //  - Name: The namesapce's name
 func (p *Client) GetTableNamesByNamespace(ctx context.Context, name string) ([]*hbase.TTableName, error) {
 	var tResult_ []*hbase.TTableName
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetTableNamesByNamespace", Args: []interface{}{name}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetTableNamesByNamespace(ctx, name)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -695,9 +707,9 @@ func (p *Client) GetTableNamesByNamespace(ctx context.Context, name string) ([]*
 //  - Desc: table descriptor for table
 //  - SplitKeys: rray of split keys for the initial regions of the table
 func (p *Client) CreateTable(ctx context.Context, desc *hbase.TTableDescriptor, splitKeys [][]byte) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "CreateTable", Table: tableDescriptorName(desc), Args: []interface{}{desc, splitKeys}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.CreateTable(ctx, desc, splitKeys)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -708,9 +720,9 @@ func (p *Client) CreateTable(ctx context.Context, desc *hbase.TTableDescriptor,
 // Parameters:
 //  - TableName: the tablename to delete
 func (p *Client) DeleteTable(ctx context.Context, tableName *hbase.TTableName) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "DeleteTable", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.DeleteTable(ctx, tableName)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -722,9 +734,9 @@ func (p *Client) DeleteTable(ctx context.Context, tableName *hbase.TTableName) e
 //  - TableName: the tablename to truncate
 //  - PreserveSplits: whether to  preserve previous splits
 func (p *Client) TruncateTable(ctx context.Context, tableName *hbase.TTableName, preserveSplits bool) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "TruncateTable", Table: tableNameBytes(tableName), Args: []interface{}{tableName, preserveSplits}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.TruncateTable(ctx, tableName, preserveSplits)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -735,9 +747,9 @@ func (p *Client) TruncateTable(ctx context.Context, tableName *hbase.TTableName,
 // Parameters:
 //  - TableName: the tablename to enable
 func (p *Client) EnableTable(ctx context.Context, tableName *hbase.TTableName) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "EnableTable", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.EnableTable(ctx, tableName)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -748,9 +760,9 @@ func (p *Client) EnableTable(ctx context.Context, tableName *hbase.TTableName) e
 // Parameters:
 //  - TableName: the tablename to disable
 func (p *Client) DisableTable(ctx context.Context, tableName *hbase.TTableName) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "DisableTable", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.DisableTable(ctx, tableName)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -763,10 +775,10 @@ func (p *Client) DisableTable(ctx context.Context, tableName *hbase.TTableName)
 //  - TableName: the tablename to check
 func (p *Client) IsTableEnabled(ctx context.Context, tableName *hbase.TTableName) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "IsTableEnabled", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.IsTableEnabled(ctx, tableName)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -782,10 +794,10 @@ func (p *Client) IsTableEnabled(ctx context.Context, tableName *hbase.TTableName
 //  - TableName: the tablename to check
 func (p *Client) IsTableDisabled(ctx context.Context, tableName *hbase.TTableName) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "IsTableDisabled", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.IsTableDisabled(ctx, tableName)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -801,10 +813,10 @@ func (p *Client) IsTableDisabled(ctx context.Context, tableName *hbase.TTableNam
 //  - TableName: the tablename to check
 func (p *Client) IsTableAvailable(ctx context.Context, tableName *hbase.TTableName) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "IsTableAvailable", Table: tableNameBytes(tableName), Args: []interface{}{tableName}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.IsTableAvailable(ctx, tableName)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -824,10 +836,10 @@ func (p *Client) IsTableAvailable(ctx context.Context, tableName *hbase.TTableNa
 //  - SplitKeys: keys to check if the table has been created with all split keys
 func (p *Client) IsTableAvailableWithSplit(ctx context.Context, tableName *hbase.TTableName, splitKeys [][]byte) (bool, error) {
 	var result bool
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "IsTableAvailableWithSplit", Table: tableNameBytes(tableName), Args: []interface{}{tableName, splitKeys}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		result, err2 = conn.IsTableAvailableWithSplit(ctx, tableName, splitKeys)
-		return err2
+		return result, err2
 	})
 	if err != nil {
 		return false, err
@@ -842,9 +854,9 @@ func (p *Client) IsTableAvailableWithSplit(ctx context.Context, tableName *hbase
 //  - TableName: the tablename to add column family to
 //  - Column: column family descriptor of column family to be added
 func (p *Client) AddColumnFamily(ctx context.Context, tableName *hbase.TTableName, column *hbase.TColumnFamilyDescriptor) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "AddColumnFamily", Table: tableNameBytes(tableName), Args: []interface{}{tableName, column}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.AddColumnFamily(ctx, tableName, column)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -856,9 +868,9 @@ func (p *Client) AddColumnFamily(ctx context.Context, tableName *hbase.TTableNam
 //  - TableName: the tablename to delete column family from
 //  - Column: name of column family to be deleted
 func (p *Client) DeleteColumnFamily(ctx context.Context, tableName *hbase.TTableName, column []byte) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "DeleteColumnFamily", Table: tableNameBytes(tableName), Args: []interface{}{tableName, column}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.DeleteColumnFamily(ctx, tableName, column)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -870,9 +882,9 @@ func (p *Client) DeleteColumnFamily(ctx context.Context, tableName *hbase.TTable
 //  - TableName: the tablename to modify column family
 //  - Column: column family descriptor of column family to be modified
 func (p *Client) ModifyColumnFamily(ctx context.Context, tableName *hbase.TTableName, column *hbase.TColumnFamilyDescriptor) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "ModifyColumnFamily", Table: tableNameBytes(tableName), Args: []interface{}{tableName, column}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.ModifyColumnFamily(ctx, tableName, column)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -883,9 +895,9 @@ func (p *Client) ModifyColumnFamily(ctx context.Context, tableName *hbase.TTable
 // Parameters:
 //  - Desc: the descriptor of the table to modify
 func (p *Client) ModifyTable(ctx context.Context, desc *hbase.TTableDescriptor) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "ModifyTable", Table: tableDescriptorName(desc), Args: []interface{}{desc}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.ModifyTable(ctx, desc)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -897,9 +909,9 @@ func (p *Client) ModifyTable(ctx context.Context, desc *hbase.TTableDescriptor)
 // Parameters:
 //  - NamespaceDesc: descriptor which describes the new namespace
 func (p *Client) CreateNamespace(ctx context.Context, namespaceDesc *hbase.TNamespaceDescriptor) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "CreateNamespace", Args: []interface{}{namespaceDesc}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.CreateNamespace(ctx, namespaceDesc)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -911,9 +923,9 @@ func (p *Client) CreateNamespace(ctx context.Context, namespaceDesc *hbase.TName
 // Parameters:
 //  - NamespaceDesc: descriptor which describes the new namespace
 func (p *Client) ModifyNamespace(ctx context.Context, namespaceDesc *hbase.TNamespaceDescriptor) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "ModifyNamespace", Args: []interface{}{namespaceDesc}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.ModifyNamespace(ctx, namespaceDesc)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -926,9 +938,9 @@ func (p *Client) ModifyNamespace(ctx context.Context, namespaceDesc *hbase.TName
 // Parameters:
 //  - Name: namespace name
 func (p *Client) DeleteNamespace(ctx context.Context, name string) error {
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "DeleteNamespace", Args: []interface{}{name}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		err2 := conn.DeleteNamespace(ctx, name)
-		return err2
+		return nil, err2
 	})
 	return err
 }
@@ -941,10 +953,10 @@ func (p *Client) DeleteNamespace(ctx context.Context, name string) error {
 //  - Name: name of namespace descriptor
 func (p *Client) GetNamespaceDescriptor(ctx context.Context, name string) (*hbase.TNamespaceDescriptor, error) {
 	var tResult_ *hbase.TNamespaceDescriptor
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "GetNamespaceDescriptor", Args: []interface{}{name}}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.GetNamespaceDescriptor(ctx, name)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
@@ -956,10 +968,10 @@ func (p *Client) GetNamespaceDescriptor(ctx context.Context, name string) (*hbas
 //
 func (p *Client) ListNamespaceDescriptors(ctx context.Context) ([]*hbase.TNamespaceDescriptor, error) {
 	var tResult_ []*hbase.TNamespaceDescriptor
-	err := p.do(func(conn *Conn) error {
+	err := p.do(ctx, &Operation{Name: "ListNamespaceDescriptors"}, func(ctx context.Context, conn *Conn) (interface{}, error) {
 		var err2 error
 		tResult_, err2 = conn.ListNamespaceDescriptors(ctx)
-		return err2
+		return tResult_, err2
 	})
 	if err != nil {
 		return nil, err
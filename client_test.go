@@ -0,0 +1,179 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// blockingHandler TableExists会阻塞到gate关闭的假服务端
+type blockingHandler struct {
+	hbase.THBaseService
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (h *blockingHandler) TableExists(ctx context.Context, tableName *hbase.TTableName) (bool, error) {
+	h.started <- struct{}{}
+	<-h.gate
+	return true, nil
+}
+
+func TestClientPoolWaitHonorsContext(t *testing.T) {
+	h := &blockingHandler{started: make(chan struct{}, 1), gate: make(chan struct{})}
+	c := newTestClient(t, h, WithMaxConns(1), WithTimeoutS(5))
+	table := &hbase.TTableName{Qualifier: []byte("t")}
+
+	// 唯一的连接被占用
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.TableExists(context.Background(), table)
+		done <- err
+	}()
+	<-h.started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.TableExists(ctx, table)
+		errc <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for c.PoolStats().Waiting != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("请求没有等待连接")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	err := <-errc
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	var e *exceptions.Error
+	if !errors.As(err, &e) || e.Op != "TableExists" {
+		t.Fatalf("err = %#v, want *exceptions.Error", err)
+	}
+
+	// 截止时间早于连接池的超时时间时按ctx的截止时间返回,并归类为超时
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = c.TableExists(ctx, table)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, exceptions.ErrTimeout) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("等待了%v才返回", elapsed)
+	}
+
+	close(h.gate)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if stats := c.PoolStats(); stats.OpenConnections != 1 || stats.Waiting != 0 || stats.Idle != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+// opHandler 在scanHandler的基础上实现Get和Put,Put第一次请求返回限流错误
+type opHandler struct {
+	*scanHandler
+	puts int
+}
+
+func (h *opHandler) Get(ctx context.Context, table []byte, tget *hbase.TGet) (*hbase.TResult_, error) {
+	return &hbase.TResult_{Row: tget.Row}, nil
+}
+
+func (h *opHandler) Put(ctx context.Context, table []byte, tput *hbase.TPut) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.puts++
+	if h.puts == 1 {
+		return &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.RegionTooBusyException: busy")}
+	}
+	return nil
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+		ops    []Operation
+	)
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, op *Operation, invoke Invoker) error {
+			mu.Lock()
+			events = append(events, name+" "+op.Name)
+			mu.Unlock()
+			err := invoke(ctx, op)
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, name+" "+op.Name+" done")
+			if name == "c" {
+				ops = append(ops, *op)
+			}
+			return err
+		}
+	}
+	c := newTestClient(t, &opHandler{scanHandler: newScanHandler(3)},
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}),
+		WithInterceptors(record("a"), record("b")),
+		WithInterceptors(record("c")),
+	)
+	ctx := context.Background()
+	if _, err := c.Get(ctx, []byte("t"), &hbase.TGet{Row: []byte("r1")}); err != nil {
+		t.Fatal(err)
+	}
+	// 先注册的拦截器在外层,第二次WithInterceptors追加在最内层
+	want := []string{"a Get", "b Get", "c Get", "c Get done", "b Get done", "a Get done"}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("拦截器调用顺序为%v,期望%v", events, want)
+	}
+	if err := c.Put(ctx, []byte("t"), &hbase.TPut{Row: []byte("r2")}); err != nil {
+		t.Fatal(err)
+	}
+	s := c.Scan(ctx, []byte("t"), nil)
+	if rows := collectRows(s); len(rows) != 3 {
+		t.Fatalf("扫描返回了%d行", len(rows))
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		table    string
+		row      string
+		attempts int
+	}{
+		{"Get", "t", "r1", 1},
+		// 第一次请求被限流,重试后成功
+		{"Put", "t", "r2", 2},
+		{"OpenScanner", "t", "", 1},
+		{"GetScannerRows", "", "", 1},
+	}
+	for _, tc := range cases {
+		var op *Operation
+		for i := range ops {
+			if ops[i].Name == tc.name {
+				op = &ops[i]
+				break
+			}
+		}
+		if op == nil {
+			t.Fatalf("拦截器没有收到%s操作", tc.name)
+		}
+		if string(op.Table) != tc.table || string(op.Row) != tc.row || op.Attempts != tc.attempts {
+			t.Errorf("%s: Table=%q Row=%q Attempts=%d, 期望Table=%q Row=%q Attempts=%d", tc.name, op.Table, op.Row, op.Attempts, tc.table, tc.row, tc.attempts)
+		}
+	}
+}
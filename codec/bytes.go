@@ -0,0 +1,261 @@
+// 单元格值的编解码
+// Encode/Decode系列函数与HBase Java客户端`org.apache.hadoop.hbase.util.Bytes`的toBytes/toXXX一致,
+// 整数和浮点数为大端序定长,bool为0xFF/0x00,时间为毫秒时间戳,BigDecimal为4字节scale加上unscaled值的补码;
+// Ordered系列函数与`org.apache.hadoop.hbase.util.OrderedBytes`一致,编码后的字节序与值的大小顺序相同,可以用于行键
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// 错误类型
+var (
+	//ErrInvalidLength 编码后的值长度不正确
+	ErrInvalidLength = errors.New("编码后的值长度不正确")
+	//ErrInvalidHeader OrderedBytes编码的类型头不正确
+	ErrInvalidHeader = errors.New("OrderedBytes编码的类型头不正确")
+	//ErrNotTerminated OrderedBytes编码缺少结束符
+	ErrNotTerminated = errors.New("OrderedBytes编码缺少结束符")
+	//ErrContainsTerminator 值中包含0x00,无法使用该编码
+	ErrContainsTerminator = errors.New("值中包含0x00,无法使用该编码")
+	//ErrColumnNotFound 结果中没有该列
+	ErrColumnNotFound = errors.New("结果中没有该列")
+)
+
+// checkLen 检查长度
+func checkLen(b []byte, n int) error {
+	if len(b) != n {
+		return fmt.Errorf("%w: 需要%d字节,实际为%d字节", ErrInvalidLength, n, len(b))
+	}
+	return nil
+}
+
+// EncodeInt64 编码int64,与Java的Bytes.toBytes(long)一致
+func EncodeInt64(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+// DecodeInt64 解码int64,与Java的Bytes.toLong一致
+func DecodeInt64(b []byte) (int64, error) {
+	if err := checkLen(b, 8); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// EncodeInt32 编码int32,与Java的Bytes.toBytes(int)一致
+func EncodeInt32(v int32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(v))
+	return b
+}
+
+// DecodeInt32 解码int32,与Java的Bytes.toInt一致
+func DecodeInt32(b []byte) (int32, error) {
+	if err := checkLen(b, 4); err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+// EncodeInt16 编码int16,与Java的Bytes.toBytes(short)一致
+func EncodeInt16(v int16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(v))
+	return b
+}
+
+// DecodeInt16 解码int16,与Java的Bytes.toShort一致
+func DecodeInt16(b []byte) (int16, error) {
+	if err := checkLen(b, 2); err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+// EncodeInt8 编码int8,与Java的byte一致
+func EncodeInt8(v int8) []byte {
+	return []byte{byte(v)}
+}
+
+// DecodeInt8 解码int8
+func DecodeInt8(b []byte) (int8, error) {
+	if err := checkLen(b, 1); err != nil {
+		return 0, err
+	}
+	return int8(b[0]), nil
+}
+
+// EncodeFloat64 编码float64,与Java的Bytes.toBytes(double)一致
+func EncodeFloat64(v float64) []byte {
+	return EncodeInt64(int64(math.Float64bits(v)))
+}
+
+// DecodeFloat64 解码float64,与Java的Bytes.toDouble一致
+func DecodeFloat64(b []byte) (float64, error) {
+	v, err := DecodeInt64(b)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(uint64(v)), nil
+}
+
+// EncodeFloat32 编码float32,与Java的Bytes.toBytes(float)一致
+func EncodeFloat32(v float32) []byte {
+	return EncodeInt32(int32(math.Float32bits(v)))
+}
+
+// DecodeFloat32 解码float32,与Java的Bytes.toFloat一致
+func DecodeFloat32(b []byte) (float32, error) {
+	v, err := DecodeInt32(b)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(uint32(v)), nil
+}
+
+// EncodeBool 编码bool,与Java的Bytes.toBytes(boolean)一致,true为0xFF,false为0x00
+func EncodeBool(v bool) []byte {
+	if v {
+		return []byte{0xFF}
+	}
+	return []byte{0x00}
+}
+
+// DecodeBool 解码bool,与Java的Bytes.toBoolean一致,非0即为true
+func DecodeBool(b []byte) (bool, error) {
+	if err := checkLen(b, 1); err != nil {
+		return false, err
+	}
+	return b[0] != 0, nil
+}
+
+// EncodeString 编码字符串,与Java的Bytes.toBytes(String)一致为UTF-8
+func EncodeString(v string) []byte {
+	return []byte(v)
+}
+
+// DecodeString 解码字符串
+func DecodeString(b []byte) string {
+	return string(b)
+}
+
+// EncodeTime 将时间编码为毫秒时间戳,与Java中常用的Bytes.toBytes(date.getTime())一致
+func EncodeTime(t time.Time) []byte {
+	return EncodeInt64(t.UnixMilli())
+}
+
+// DecodeTime 解码毫秒时间戳
+func DecodeTime(b []byte) (time.Time, error) {
+	v, err := DecodeInt64(b)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(v), nil
+}
+
+// Decimal 与Java的BigDecimal对应的十进制数,值为Unscaled * 10^-Scale
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+// String 以十进制字符串表示,与Java的BigDecimal.toPlainString一致
+func (d Decimal) String() string {
+	unscaled := d.Unscaled
+	if unscaled == nil {
+		unscaled = new(big.Int)
+	}
+	if d.Scale <= 0 {
+		s := unscaled.String()
+		if unscaled.Sign() != 0 {
+			for i := int32(0); i < -d.Scale; i++ {
+				s += "0"
+			}
+		}
+		return s
+	}
+	digits := new(big.Int).Abs(unscaled).String()
+	for int32(len(digits)) <= d.Scale {
+		digits = "0" + digits
+	}
+	point := int32(len(digits)) - d.Scale
+	s := digits[:point] + "." + digits[point:]
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Rat 转换为有理数
+func (d Decimal) Rat() *big.Rat {
+	unscaled := d.Unscaled
+	if unscaled == nil {
+		unscaled = new(big.Int)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs32(d.Scale))), nil)
+	if d.Scale >= 0 {
+		return new(big.Rat).SetFrac(unscaled, scale)
+	}
+	return new(big.Rat).SetInt(new(big.Int).Mul(unscaled, scale))
+}
+
+func abs32(v int32) int32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// EncodeDecimal 编码十进制数,与Java的Bytes.toBytes(BigDecimal)一致:4字节scale加上unscaled值的最短补码
+func EncodeDecimal(d Decimal) []byte {
+	unscaled := d.Unscaled
+	if unscaled == nil {
+		unscaled = new(big.Int)
+	}
+	return append(EncodeInt32(d.Scale), twosComplement(unscaled)...)
+}
+
+// DecodeDecimal 解码十进制数,与Java的Bytes.toBigDecimal一致
+func DecodeDecimal(b []byte) (Decimal, error) {
+	if len(b) < 5 {
+		return Decimal{}, fmt.Errorf("%w: 至少需要5字节,实际为%d字节", ErrInvalidLength, len(b))
+	}
+	scale, _ := DecodeInt32(b[:4])
+	return Decimal{Unscaled: fromTwosComplement(b[4:]), Scale: scale}, nil
+}
+
+// twosComplement 获取与Java的BigInteger.toByteArray一致的最短大端序补码
+func twosComplement(v *big.Int) []byte {
+	if v.Sign() >= 0 {
+		b := v.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0}, b...)
+		}
+		return b
+	}
+	// 负数的补码为 2^(8n) + v,n为能表示v的最少字节数
+	n := (new(big.Int).Not(v).BitLen())/8 + 1
+	mod := new(big.Int).Lsh(big.NewInt(1), uint(8*n))
+	b := new(big.Int).Add(mod, v).Bytes()
+	for len(b) < n {
+		b = append([]byte{0xFF}, b...)
+	}
+	return b
+}
+
+// fromTwosComplement 解析大端序补码
+func fromTwosComplement(b []byte) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	return v
+}
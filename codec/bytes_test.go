@@ -0,0 +1,128 @@
+package codec
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// 以下期望值为HBase Java客户端org.apache.hadoop.hbase.util.Bytes.toBytes的输出
+
+func TestBytesGolden(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"long 1", EncodeInt64(1), "0000000000000001"},
+		{"long -2", EncodeInt64(-2), "fffffffffffffffe"},
+		{"long MIN_VALUE", EncodeInt64(math.MinInt64), "8000000000000000"},
+		{"int 256", EncodeInt32(256), "00000100"},
+		{"int -1", EncodeInt32(-1), "ffffffff"},
+		{"short -1", EncodeInt16(-1), "ffff"},
+		{"short 4660", EncodeInt16(0x1234), "1234"},
+		{"byte -128", EncodeInt8(-128), "80"},
+		{"double 1.5", EncodeFloat64(1.5), "3ff8000000000000"},
+		{"double -2.0", EncodeFloat64(-2), "c000000000000000"},
+		{"double -0.0", EncodeFloat64(math.Copysign(0, -1)), "8000000000000000"},
+		{"double +Inf", EncodeFloat64(math.Inf(1)), "7ff0000000000000"},
+		{"float 1.5f", EncodeFloat32(1.5), "3fc00000"},
+		{"float -0.1f", EncodeFloat32(-0.1), "bdcccccd"},
+		{"boolean true", EncodeBool(true), "ff"},
+		{"boolean false", EncodeBool(false), "00"},
+		{"String abc", EncodeString("abc"), "616263"},
+		{"String 中文", EncodeString("中文"), "e4b8ade69687"},
+		{"String empty", EncodeString(""), ""},
+		{"Date 1000ms", EncodeTime(time.UnixMilli(1000)), "00000000000003e8"},
+	} {
+		if got := hex.EncodeToString(tc.got); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDecimalGolden(t *testing.T) {
+	for _, tc := range []struct {
+		// Java的new BigDecimal(text)
+		text     string
+		unscaled int64
+		scale    int32
+		want     string
+	}{
+		{"0", 0, 0, "0000000000"},
+		{"123.45", 12345, 2, "000000023039"},
+		{"-1.5", -15, 1, "00000001f1"},
+		{"128", 128, 0, "000000000080"},
+		{"-128", -128, 0, "0000000080"},
+		{"-129", -129, 0, "00000000ff7f"},
+		{"0.001", 1, 3, "0000000301"},
+		{"1E+3", 1, -3, "fffffffd01"},
+		{"-0.00", 0, 2, "0000000200"},
+	} {
+		d := Decimal{Unscaled: big.NewInt(tc.unscaled), Scale: tc.scale}
+		if got := hex.EncodeToString(EncodeDecimal(d)); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.text, got, tc.want)
+			continue
+		}
+		b, _ := hex.DecodeString(tc.want)
+		decoded, err := DecodeDecimal(b)
+		if err != nil {
+			t.Errorf("%s: %v", tc.text, err)
+			continue
+		}
+		if decoded.Scale != tc.scale || decoded.Unscaled.Int64() != tc.unscaled {
+			t.Errorf("%s: decoded %s scale %d", tc.text, decoded.Unscaled, decoded.Scale)
+		}
+	}
+	// 未设置Unscaled时为0
+	if got := hex.EncodeToString(EncodeDecimal(Decimal{})); got != "0000000000" {
+		t.Errorf("zero Decimal: got %s", got)
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	for _, tc := range []struct {
+		unscaled int64
+		scale    int32
+		want     string
+	}{
+		{12345, 2, "123.45"},
+		{-15, 1, "-1.5"},
+		{1, 3, "0.001"},
+		{-1, 3, "-0.001"},
+		{1, -3, "1000"},
+		{0, 2, "0.00"},
+	} {
+		d := Decimal{Unscaled: big.NewInt(tc.unscaled), Scale: tc.scale}
+		if got := d.String(); got != tc.want {
+			t.Errorf("%d scale %d: got %s, want %s", tc.unscaled, tc.scale, got, tc.want)
+		}
+	}
+}
+
+func TestBytesDecode(t *testing.T) {
+	if v, err := DecodeInt64(EncodeInt64(math.MinInt64)); err != nil || v != math.MinInt64 {
+		t.Errorf("DecodeInt64 = %d, %v", v, err)
+	}
+	if v, err := DecodeFloat64(EncodeFloat64(math.Copysign(0, -1))); err != nil || v != 0 || !math.Signbit(v) {
+		t.Errorf("DecodeFloat64(-0.0) = %v, %v", v, err)
+	}
+	if v, err := DecodeFloat32(EncodeFloat32(float32(math.NaN()))); err != nil || !math.IsNaN(float64(v)) {
+		t.Errorf("DecodeFloat32(NaN) = %v, %v", v, err)
+	}
+	// 与Java的Bytes.toBoolean一致,非0即为true
+	if v, err := DecodeBool([]byte{0x01}); err != nil || !v {
+		t.Errorf("DecodeBool(0x01) = %v, %v", v, err)
+	}
+	for _, b := range [][]byte{nil, {1, 2, 3}} {
+		if _, err := DecodeInt32(b); !errors.Is(err, ErrInvalidLength) {
+			t.Errorf("DecodeInt32(%x) err = %v", b, err)
+		}
+	}
+	if _, err := DecodeDecimal([]byte{0, 0, 0, 1}); !errors.Is(err, ErrInvalidLength) {
+		t.Errorf("DecodeDecimal err = %v", err)
+	}
+}
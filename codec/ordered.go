@@ -0,0 +1,286 @@
+// 保序编码
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Order 保序编码的排列方向
+type Order int
+
+const (
+	// Ascending 升序,编码后的字节序与值的顺序一致
+	Ascending Order = iota
+	// Descending 降序,在升序编码的基础上按位取反
+	Descending
+)
+
+// OrderedBytes的类型头,与HBase的`OrderedBytes`一致
+const (
+	orderedNull       byte = 0x05
+	orderedFixedInt8  byte = 0x29
+	orderedFixedInt16 byte = 0x2a
+	orderedFixedInt32 byte = 0x2b
+	orderedFixedInt64 byte = 0x2c
+	orderedFloat32    byte = 0x30
+	orderedFloat64    byte = 0x31
+	orderedText       byte = 0x34
+	orderedBlobCopy   byte = 0x38
+	orderedTerm       byte = 0x00
+)
+
+// Java中NaN的规范位模式
+const (
+	canonicalNaN32 uint32 = 0x7fc00000
+	canonicalNaN64 uint64 = 0x7ff8000000000000
+)
+
+// apply 按排列方向处理b,降序时按位取反
+func (o Order) apply(b []byte) {
+	if o != Descending {
+		return
+	}
+	for i := range b {
+		b[i] = ^b[i]
+	}
+}
+
+// orderOf 根据类型头判断排列方向,升序的类型头都小于0x80
+func orderOf(header byte) Order {
+	if header&0x80 != 0 {
+		return Descending
+	}
+	return Ascending
+}
+
+// readHeader 读取并校验类型头,返回排列方向
+func readHeader(b []byte, want byte) (Order, error) {
+	if len(b) == 0 {
+		return Ascending, fmt.Errorf("%w: 值为空", ErrInvalidLength)
+	}
+	ord := orderOf(b[0])
+	header := b[0]
+	if ord == Descending {
+		header = ^header
+	}
+	if header != want {
+		return ord, fmt.Errorf("%w: 需要0x%02x,实际为0x%02x", ErrInvalidHeader, want, header)
+	}
+	return ord, nil
+}
+
+// appendFixed 追加类型头和定长的大端序整数
+func appendFixed(dst []byte, header byte, u uint64, size int, ord Order) []byte {
+	start := len(dst)
+	dst = append(dst, header)
+	for i := size - 1; i >= 0; i-- {
+		dst = append(dst, byte(u>>(8*uint(i))))
+	}
+	ord.apply(dst[start:])
+	return dst
+}
+
+// decodeFixed 解码类型头和定长的大端序整数
+func decodeFixed(b []byte, header byte, size int) (uint64, int, error) {
+	ord, err := readHeader(b, header)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(b) < size+1 {
+		return 0, 0, fmt.Errorf("%w: 需要%d字节,实际为%d字节", ErrInvalidLength, size+1, len(b))
+	}
+	var u uint64
+	for _, c := range b[1 : size+1] {
+		if ord == Descending {
+			c = ^c
+		}
+		u = u<<8 | uint64(c)
+	}
+	return u, size + 1, nil
+}
+
+// AppendOrderedNull 追加NULL
+func AppendOrderedNull(dst []byte, ord Order) []byte {
+	start := len(dst)
+	dst = append(dst, orderedNull)
+	ord.apply(dst[start:])
+	return dst
+}
+
+// IsOrderedNull 判断b开头是否为NULL
+func IsOrderedNull(b []byte) bool {
+	_, err := readHeader(b, orderedNull)
+	return err == nil
+}
+
+// AppendOrderedInt8 追加FIXED_INT8编码的int8
+func AppendOrderedInt8(dst []byte, v int8, ord Order) []byte {
+	return appendFixed(dst, orderedFixedInt8, uint64(uint8(v)^0x80), 1, ord)
+}
+
+// DecodeOrderedInt8 解码b开头FIXED_INT8编码的int8,返回值和消耗的字节数
+func DecodeOrderedInt8(b []byte) (int8, int, error) {
+	u, n, err := decodeFixed(b, orderedFixedInt8, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int8(uint8(u) ^ 0x80), n, nil
+}
+
+// AppendOrderedInt16 追加FIXED_INT16编码的int16
+func AppendOrderedInt16(dst []byte, v int16, ord Order) []byte {
+	return appendFixed(dst, orderedFixedInt16, uint64(uint16(v)^0x8000), 2, ord)
+}
+
+// DecodeOrderedInt16 解码b开头FIXED_INT16编码的int16,返回值和消耗的字节数
+func DecodeOrderedInt16(b []byte) (int16, int, error) {
+	u, n, err := decodeFixed(b, orderedFixedInt16, 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int16(uint16(u) ^ 0x8000), n, nil
+}
+
+// AppendOrderedInt32 追加FIXED_INT32编码的int32
+func AppendOrderedInt32(dst []byte, v int32, ord Order) []byte {
+	return appendFixed(dst, orderedFixedInt32, uint64(uint32(v)^0x80000000), 4, ord)
+}
+
+// DecodeOrderedInt32 解码b开头FIXED_INT32编码的int32,返回值和消耗的字节数
+func DecodeOrderedInt32(b []byte) (int32, int, error) {
+	u, n, err := decodeFixed(b, orderedFixedInt32, 4)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int32(uint32(u) ^ 0x80000000), n, nil
+}
+
+// AppendOrderedInt64 追加FIXED_INT64编码的int64
+func AppendOrderedInt64(dst []byte, v int64, ord Order) []byte {
+	return appendFixed(dst, orderedFixedInt64, uint64(v)^(1<<63), 8, ord)
+}
+
+// DecodeOrderedInt64 解码b开头FIXED_INT64编码的int64,返回值和消耗的字节数
+func DecodeOrderedInt64(b []byte) (int64, int, error) {
+	u, n, err := decodeFixed(b, orderedFixedInt64, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(u ^ (1 << 63)), n, nil
+}
+
+// AppendOrderedFloat32 追加FIXED_FLOAT32编码的float32
+// 正数翻转符号位,负数按位取反,使编码后的字节序与数值顺序一致.
+// 与Java的Float.floatToIntBits一致,所有NaN都编码为0x7fc00000,排在正无穷之后
+func AppendOrderedFloat32(dst []byte, v float32, ord Order) []byte {
+	u := math.Float32bits(v)
+	if math.IsNaN(float64(v)) {
+		u = canonicalNaN32
+	}
+	u ^= uint32(int32(u)>>31) | 0x80000000
+	return appendFixed(dst, orderedFloat32, uint64(u), 4, ord)
+}
+
+// DecodeOrderedFloat32 解码b开头FIXED_FLOAT32编码的float32,返回值和消耗的字节数
+func DecodeOrderedFloat32(b []byte) (float32, int, error) {
+	u64, n, err := decodeFixed(b, orderedFloat32, 4)
+	if err != nil {
+		return 0, 0, err
+	}
+	u := uint32(u64)
+	u ^= uint32(^int32(u)>>31) | 0x80000000
+	return math.Float32frombits(u), n, nil
+}
+
+// AppendOrderedFloat64 追加FIXED_FLOAT64编码的float64
+// 正数翻转符号位,负数按位取反,使编码后的字节序与数值顺序一致.
+// 与Java的Double.doubleToLongBits一致,所有NaN都编码为0x7ff8000000000000,排在正无穷之后
+func AppendOrderedFloat64(dst []byte, v float64, ord Order) []byte {
+	u := math.Float64bits(v)
+	if math.IsNaN(v) {
+		u = canonicalNaN64
+	}
+	u ^= uint64(int64(u)>>63) | 1<<63
+	return appendFixed(dst, orderedFloat64, u, 8, ord)
+}
+
+// DecodeOrderedFloat64 解码b开头FIXED_FLOAT64编码的float64,返回值和消耗的字节数
+func DecodeOrderedFloat64(b []byte) (float64, int, error) {
+	u, n, err := decodeFixed(b, orderedFloat64, 8)
+	if err != nil {
+		return 0, 0, err
+	}
+	u ^= uint64(^int64(u)>>63) | 1<<63
+	return math.Float64frombits(u), n, nil
+}
+
+// AppendOrderedString 追加TEXT编码的字符串,格式为类型头 + UTF-8 + 结束符0x00
+// 字符串中不能包含0x00
+func AppendOrderedString(dst []byte, v string, ord Order) ([]byte, error) {
+	if strings.IndexByte(v, orderedTerm) >= 0 {
+		return dst, ErrContainsTerminator
+	}
+	start := len(dst)
+	dst = append(dst, orderedText)
+	dst = append(dst, v...)
+	dst = append(dst, orderedTerm)
+	ord.apply(dst[start:])
+	return dst, nil
+}
+
+// DecodeOrderedString 解码b开头TEXT编码的字符串,返回值和消耗的字节数
+func DecodeOrderedString(b []byte) (string, int, error) {
+	ord, err := readHeader(b, orderedText)
+	if err != nil {
+		return "", 0, err
+	}
+	term := orderedTerm
+	if ord == Descending {
+		term = ^term
+	}
+	end := bytes.IndexByte(b[1:], term)
+	if end < 0 {
+		return "", 0, ErrNotTerminated
+	}
+	v := append([]byte(nil), b[1:end+1]...)
+	ord.apply(v)
+	return string(v), end + 2, nil
+}
+
+// AppendOrderedBlobCopy 追加BLOB_COPY编码的字节串
+// 升序时不加结束符,只能作为最后一个字段;降序时以0x00结束(取反后为0xFF),值中不能包含0x00
+func AppendOrderedBlobCopy(dst []byte, v []byte, ord Order) ([]byte, error) {
+	if ord == Descending && bytes.IndexByte(v, orderedTerm) >= 0 {
+		return dst, ErrContainsTerminator
+	}
+	start := len(dst)
+	dst = append(dst, orderedBlobCopy)
+	dst = append(dst, v...)
+	if ord == Descending {
+		dst = append(dst, orderedTerm)
+	}
+	ord.apply(dst[start:])
+	return dst, nil
+}
+
+// DecodeOrderedBlobCopy 解码b开头BLOB_COPY编码的字节串,返回值和消耗的字节数
+// 升序时会消耗b中剩余的全部字节
+func DecodeOrderedBlobCopy(b []byte) ([]byte, int, error) {
+	ord, err := readHeader(b, orderedBlobCopy)
+	if err != nil {
+		return nil, 0, err
+	}
+	if ord == Ascending {
+		return append([]byte(nil), b[1:]...), len(b), nil
+	}
+	end := bytes.IndexByte(b[1:], ^orderedTerm)
+	if end < 0 {
+		return nil, 0, ErrNotTerminated
+	}
+	v := append([]byte(nil), b[1:end+1]...)
+	ord.apply(v)
+	return v, end + 2, nil
+}
@@ -0,0 +1,197 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+)
+
+// 以下期望值为HBase Java客户端org.apache.hadoop.hbase.util.OrderedBytes的输出
+
+func TestOrderedGolden(t *testing.T) {
+	mustString := func(v string, ord Order) []byte {
+		b, err := AppendOrderedString(nil, v, ord)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	mustBlob := func(v []byte, ord Order) []byte {
+		b, err := AppendOrderedBlobCopy(nil, v, ord)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	for _, tc := range []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{"encodeNull ASC", AppendOrderedNull(nil, Ascending), "05"},
+		{"encodeNull DESC", AppendOrderedNull(nil, Descending), "fa"},
+		{"encodeInt8 -1 ASC", AppendOrderedInt8(nil, -1, Ascending), "297f"},
+		{"encodeInt8 0 DESC", AppendOrderedInt8(nil, 0, Descending), "d67f"},
+		{"encodeInt16 1 ASC", AppendOrderedInt16(nil, 1, Ascending), "2a8001"},
+		{"encodeInt32 -1 ASC", AppendOrderedInt32(nil, -1, Ascending), "2b7fffffff"},
+		{"encodeInt32 MIN_VALUE ASC", AppendOrderedInt32(nil, math.MinInt32, Ascending), "2b00000000"},
+		{"encodeInt64 0 ASC", AppendOrderedInt64(nil, 0, Ascending), "2c8000000000000000"},
+		{"encodeInt64 0 DESC", AppendOrderedInt64(nil, 0, Descending), "d37fffffffffffffff"},
+		{"encodeInt64 MAX_VALUE ASC", AppendOrderedInt64(nil, math.MaxInt64, Ascending), "2cffffffffffffffff"},
+		{"encodeFloat32 1.0f ASC", AppendOrderedFloat32(nil, 1, Ascending), "30bf800000"},
+		{"encodeFloat32 -1.0f ASC", AppendOrderedFloat32(nil, -1, Ascending), "30407fffff"},
+		{"encodeFloat32 NaN ASC", AppendOrderedFloat32(nil, float32(math.NaN()), Ascending), "30ffc00000"},
+		{"encodeFloat64 1.0 ASC", AppendOrderedFloat64(nil, 1, Ascending), "31bff0000000000000"},
+		{"encodeFloat64 -1.0 ASC", AppendOrderedFloat64(nil, -1, Ascending), "31400fffffffffffff"},
+		{"encodeFloat64 0.0 ASC", AppendOrderedFloat64(nil, 0, Ascending), "318000000000000000"},
+		{"encodeFloat64 -0.0 ASC", AppendOrderedFloat64(nil, math.Copysign(0, -1), Ascending), "317fffffffffffffff"},
+		{"encodeFloat64 NaN ASC", AppendOrderedFloat64(nil, math.NaN(), Ascending), "31fff8000000000000"},
+		{"encodeFloat64 1.0 DESC", AppendOrderedFloat64(nil, 1, Descending), "ce400fffffffffffff"},
+		{"encodeString abc ASC", mustString("abc", Ascending), "3461626300"},
+		{"encodeString abc DESC", mustString("abc", Descending), "cb9e9d9cff"},
+		{"encodeString empty ASC", mustString("", Ascending), "3400"},
+		{"encodeBlobCopy 0102 ASC", mustBlob([]byte{1, 2}, Ascending), "380102"},
+		{"encodeBlobCopy 0102 DESC", mustBlob([]byte{1, 2}, Descending), "c7fefdff"},
+	} {
+		if got := hex.EncodeToString(tc.got); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// checkOrder 检查编码后的字节序与值的顺序一致,降序时相反
+func checkOrder(t *testing.T, name string, encoded [][]byte, ord Order) {
+	t.Helper()
+	for i := 1; i < len(encoded); i++ {
+		c := bytes.Compare(encoded[i-1], encoded[i])
+		if ord == Ascending && c >= 0 || ord == Descending && c <= 0 {
+			t.Errorf("%s: 第%d个编码%x与前一个%x顺序错误", name, i, encoded[i], encoded[i-1])
+		}
+	}
+}
+
+func TestOrderedFloat64SortOrder(t *testing.T) {
+	values := []float64{
+		math.Inf(-1), -math.MaxFloat64, -1.5, -1, -math.SmallestNonzeroFloat64,
+		math.Copysign(0, -1), 0, math.SmallestNonzeroFloat64, 1, 1.5, math.MaxFloat64, math.Inf(1),
+		// Java中NaN排在正无穷之后
+		math.NaN(),
+	}
+	for _, ord := range []Order{Ascending, Descending} {
+		encoded := make([][]byte, len(values))
+		for i, v := range values {
+			encoded[i] = AppendOrderedFloat64(nil, v, ord)
+			got, n, err := DecodeOrderedFloat64(encoded[i])
+			if err != nil || n != 9 {
+				t.Fatalf("decode %v: n=%d err=%v", v, n, err)
+			}
+			if math.IsNaN(v) {
+				if !math.IsNaN(got) {
+					t.Errorf("decode NaN = %v", got)
+				}
+			} else if got != v || math.Signbit(got) != math.Signbit(v) {
+				t.Errorf("decode %v = %v", v, got)
+			}
+		}
+		checkOrder(t, "float64", encoded, ord)
+	}
+	// 负号位的NaN也规范化为同一个编码
+	negNaN := math.Float64frombits(0xfff8000000000001)
+	if a, b := AppendOrderedFloat64(nil, negNaN, Ascending), AppendOrderedFloat64(nil, math.NaN(), Ascending); !bytes.Equal(a, b) {
+		t.Errorf("NaN编码不一致: %x != %x", a, b)
+	}
+}
+
+func TestOrderedFloat32SortOrder(t *testing.T) {
+	values := []float32{
+		float32(math.Inf(-1)), -math.MaxFloat32, -1, -math.SmallestNonzeroFloat32,
+		float32(math.Copysign(0, -1)), 0, math.SmallestNonzeroFloat32, 1, math.MaxFloat32, float32(math.Inf(1)),
+		float32(math.NaN()),
+	}
+	for _, ord := range []Order{Ascending, Descending} {
+		encoded := make([][]byte, len(values))
+		for i, v := range values {
+			encoded[i] = AppendOrderedFloat32(nil, v, ord)
+			got, _, err := DecodeOrderedFloat32(encoded[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !math.IsNaN(float64(v)) && (got != v || math.Signbit(float64(got)) != math.Signbit(float64(v))) {
+				t.Errorf("decode %v = %v", v, got)
+			}
+		}
+		checkOrder(t, "float32", encoded, ord)
+	}
+}
+
+func TestOrderedIntSortOrder(t *testing.T) {
+	values := []int64{math.MinInt64, math.MinInt32 - 1, -256, -1, 0, 1, 255, math.MaxInt32 + 1, math.MaxInt64}
+	for _, ord := range []Order{Ascending, Descending} {
+		encoded := make([][]byte, len(values))
+		for i, v := range values {
+			encoded[i] = AppendOrderedInt64(nil, v, ord)
+			if got, _, err := DecodeOrderedInt64(encoded[i]); err != nil || got != v {
+				t.Errorf("decode %d = %d, %v", v, got, err)
+			}
+		}
+		checkOrder(t, "int64", encoded, ord)
+
+		small := []int32{math.MinInt32, -1, 0, 1, math.MaxInt32}
+		encoded = encoded[:0]
+		for _, v := range small {
+			encoded = append(encoded, AppendOrderedInt32(nil, v, ord))
+		}
+		checkOrder(t, "int32", encoded, ord)
+
+		encoded = encoded[:0]
+		for _, v := range []int8{math.MinInt8, -1, 0, 1, math.MaxInt8} {
+			encoded = append(encoded, AppendOrderedInt8(nil, v, ord))
+		}
+		checkOrder(t, "int8", encoded, ord)
+	}
+}
+
+func TestOrderedStringSortOrder(t *testing.T) {
+	values := []string{"", "a", "ab", "abc", "b", "中"}
+	for _, ord := range []Order{Ascending, Descending} {
+		// NULL比所有值都小,升序时排在最前面,降序时排在最后面
+		encoded := [][]byte{AppendOrderedNull(nil, ord)}
+		for _, v := range values {
+			b, err := AppendOrderedString(nil, v, ord)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 后面跟着其他字段时顺序也不变
+			b = AppendOrderedInt32(b, 0, ord)
+			got, n, err := DecodeOrderedString(b)
+			if err != nil || got != v || n != len(b)-5 {
+				t.Errorf("decode %q = %q, %d, %v", v, got, n, err)
+			}
+			encoded = append(encoded, b)
+		}
+		checkOrder(t, "string", encoded, ord)
+	}
+}
+
+func TestOrderedErrors(t *testing.T) {
+	if _, err := AppendOrderedString(nil, "a\x00b", Ascending); !errors.Is(err, ErrContainsTerminator) {
+		t.Errorf("AppendOrderedString err = %v", err)
+	}
+	if _, err := AppendOrderedBlobCopy(nil, []byte{0}, Descending); !errors.Is(err, ErrContainsTerminator) {
+		t.Errorf("AppendOrderedBlobCopy err = %v", err)
+	}
+	if _, _, err := DecodeOrderedString([]byte{0x34, 'a'}); !errors.Is(err, ErrNotTerminated) {
+		t.Errorf("DecodeOrderedString err = %v", err)
+	}
+	if _, _, err := DecodeOrderedInt64(AppendOrderedInt32(nil, 1, Ascending)); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("DecodeOrderedInt64 err = %v", err)
+	}
+	if _, _, err := DecodeOrderedInt64([]byte{0x2c, 0x80}); !errors.Is(err, ErrInvalidLength) {
+		t.Errorf("DecodeOrderedInt64 err = %v", err)
+	}
+	if !IsOrderedNull(AppendOrderedNull(nil, Descending)) || IsOrderedNull(AppendOrderedInt8(nil, 0, Ascending)) {
+		t.Error("IsOrderedNull")
+	}
+}
@@ -0,0 +1,137 @@
+// 查询结果的读取
+package codec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// Result 对TResult_的封装,提供按列读取并按Java `Bytes`编码解码的方法
+type Result struct {
+	*hbase.TResult_
+}
+
+// WrapResult 封装查询结果,r可以为nil,此时所有列都不存在
+func WrapResult(r *hbase.TResult_) Result {
+	return Result{TResult_: r}
+}
+
+// Value 获取列的值,同一列有多个版本时返回时间戳最大的版本
+func (r Result) Value(family, qualifier string) ([]byte, bool) {
+	if r.TResult_ == nil {
+		return nil, false
+	}
+	var latest *hbase.TColumnValue
+	for _, cv := range r.ColumnValues {
+		if string(cv.Family) != family || string(cv.Qualifier) != qualifier {
+			continue
+		}
+		if latest == nil || cv.GetTimestamp() > latest.GetTimestamp() {
+			latest = cv
+		}
+	}
+	if latest == nil {
+		return nil, false
+	}
+	return latest.Value, true
+}
+
+// Has 判断结果中是否有该列
+func (r Result) Has(family, qualifier string) bool {
+	_, ok := r.Value(family, qualifier)
+	return ok
+}
+
+// GetBytes 获取列的原始值,列不存在时返回ErrColumnNotFound
+func (r Result) GetBytes(family, qualifier string) ([]byte, error) {
+	v, ok := r.Value(family, qualifier)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s:%s", ErrColumnNotFound, family, qualifier)
+	}
+	return v, nil
+}
+
+// column 获取列的值,解码失败时在错误中带上列名
+func column[T any](r Result, family, qualifier string, decode func([]byte) (T, error)) (T, error) {
+	var zero T
+	b, err := r.GetBytes(family, qualifier)
+	if err != nil {
+		return zero, err
+	}
+	v, err := decode(b)
+	if err != nil {
+		return zero, fmt.Errorf("列%s:%s: %w", family, qualifier, err)
+	}
+	return v, nil
+}
+
+// GetString 获取字符串列
+func (r Result) GetString(family, qualifier string) (string, error) {
+	b, err := r.GetBytes(family, qualifier)
+	return string(b), err
+}
+
+// GetInt64 获取Bytes.toBytes(long)编码的列
+func (r Result) GetInt64(family, qualifier string) (int64, error) {
+	return column(r, family, qualifier, DecodeInt64)
+}
+
+// GetInt32 获取Bytes.toBytes(int)编码的列
+func (r Result) GetInt32(family, qualifier string) (int32, error) {
+	return column(r, family, qualifier, DecodeInt32)
+}
+
+// GetInt16 获取Bytes.toBytes(short)编码的列
+func (r Result) GetInt16(family, qualifier string) (int16, error) {
+	return column(r, family, qualifier, DecodeInt16)
+}
+
+// GetFloat64 获取Bytes.toBytes(double)编码的列
+func (r Result) GetFloat64(family, qualifier string) (float64, error) {
+	return column(r, family, qualifier, DecodeFloat64)
+}
+
+// GetFloat32 获取Bytes.toBytes(float)编码的列
+func (r Result) GetFloat32(family, qualifier string) (float32, error) {
+	return column(r, family, qualifier, DecodeFloat32)
+}
+
+// GetBool 获取Bytes.toBytes(boolean)编码的列
+func (r Result) GetBool(family, qualifier string) (bool, error) {
+	return column(r, family, qualifier, DecodeBool)
+}
+
+// GetTime 获取毫秒时间戳编码的列
+func (r Result) GetTime(family, qualifier string) (time.Time, error) {
+	return column(r, family, qualifier, DecodeTime)
+}
+
+// GetDecimal 获取Bytes.toBytes(BigDecimal)编码的列
+func (r Result) GetDecimal(family, qualifier string) (Decimal, error) {
+	return column(r, family, qualifier, DecodeDecimal)
+}
+
+// Counter 读取Increment返回结果中计数器列的值
+// HBase的计数器固定为8字节的long,用Client.Increment的返回值调用即可得到自增后的值
+func Counter(r *hbase.TResult_, family, qualifier string) (int64, error) {
+	return WrapResult(r).GetInt64(family, qualifier)
+}
+
+// Counters 读取Increment返回结果中的全部计数器,键为"family:qualifier"
+func Counters(r *hbase.TResult_) (map[string]int64, error) {
+	counters := map[string]int64{}
+	if r == nil {
+		return counters, nil
+	}
+	for _, cv := range r.ColumnValues {
+		name := string(cv.Family) + ":" + string(cv.Qualifier)
+		v, err := DecodeInt64(cv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("列%s: %w", name, err)
+		}
+		counters[name] = v
+	}
+	return counters, nil
+}
@@ -0,0 +1,95 @@
+package codec
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+func cell(family, qualifier string, ts int64, value []byte) *hbase.TColumnValue {
+	return &hbase.TColumnValue{Family: []byte(family), Qualifier: []byte(qualifier), Value: value, Timestamp: thrift.Int64Ptr(ts)}
+}
+
+func TestResultGetters(t *testing.T) {
+	r := WrapResult(&hbase.TResult_{Row: []byte("r"), ColumnValues: []*hbase.TColumnValue{
+		cell("f", "n", 1, EncodeInt64(-42)),
+		cell("f", "t", 1, EncodeTime(time.UnixMilli(1700000000123))),
+		cell("f", "d", 1, EncodeDecimal(Decimal{Unscaled: big.NewInt(12345), Scale: 2})),
+		cell("f", "bad", 1, []byte{1, 2}),
+	}})
+	if v, err := r.GetInt64("f", "n"); err != nil || v != -42 {
+		t.Errorf("GetInt64 = %d, %v", v, err)
+	}
+	if v, err := r.GetTime("f", "t"); err != nil || !v.Equal(time.UnixMilli(1700000000123)) {
+		t.Errorf("GetTime = %v, %v", v, err)
+	}
+	if v, err := r.GetDecimal("f", "d"); err != nil || v.String() != "123.45" {
+		t.Errorf("GetDecimal = %v, %v", v, err)
+	}
+	if _, err := r.GetInt64("f", "bad"); !errors.Is(err, ErrInvalidLength) {
+		t.Errorf("解码长度错误的列: err = %v, want ErrInvalidLength", err)
+	}
+}
+
+func TestResultValueNewestVersion(t *testing.T) {
+	r := WrapResult(&hbase.TResult_{ColumnValues: []*hbase.TColumnValue{
+		cell("f", "q", 2, []byte("v2")),
+		cell("f", "q", 3, []byte("v3")),
+		cell("f", "q", 1, []byte("v1")),
+		cell("f", "other", 9, []byte("x")),
+	}})
+	if v, ok := r.Value("f", "q"); !ok || string(v) != "v3" {
+		t.Fatalf("Value = %q, %v, want v3", v, ok)
+	}
+}
+
+func TestResultColumnNotFound(t *testing.T) {
+	for name, r := range map[string]Result{
+		"nil":   WrapResult(nil),
+		"empty": WrapResult(&hbase.TResult_{}),
+		"other": WrapResult(&hbase.TResult_{ColumnValues: []*hbase.TColumnValue{cell("f", "a", 1, EncodeInt64(1))}}),
+	} {
+		if r.Has("f", "q") {
+			t.Errorf("%s: Has = true", name)
+		}
+		if _, err := r.GetInt64("f", "q"); !errors.Is(err, ErrColumnNotFound) {
+			t.Errorf("%s: GetInt64 err = %v, want ErrColumnNotFound", name, err)
+		}
+		if _, err := r.GetString("f", "q"); !errors.Is(err, ErrColumnNotFound) {
+			t.Errorf("%s: GetString err = %v, want ErrColumnNotFound", name, err)
+		}
+	}
+}
+
+func TestCounters(t *testing.T) {
+	// Increment返回自增后的计数器值
+	r := &hbase.TResult_{Row: []byte("r"), ColumnValues: []*hbase.TColumnValue{
+		cell("f", "hits", 5, EncodeInt64(10)),
+		cell("g", "misses", 5, EncodeInt64(-3)),
+	}}
+	if v, err := Counter(r, "f", "hits"); err != nil || v != 10 {
+		t.Errorf("Counter = %d, %v", v, err)
+	}
+	if _, err := Counter(r, "f", "none"); !errors.Is(err, ErrColumnNotFound) {
+		t.Errorf("Counter err = %v, want ErrColumnNotFound", err)
+	}
+	counters, err := Counters(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"f:hits": 10, "g:misses": -3}; !reflect.DeepEqual(counters, want) {
+		t.Fatalf("Counters = %v, want %v", counters, want)
+	}
+	if counters, err := Counters(nil); err != nil || len(counters) != 0 {
+		t.Fatalf("Counters(nil) = %v, %v", counters, err)
+	}
+	r.ColumnValues = append(r.ColumnValues, cell("f", "bad", 5, []byte{1}))
+	if _, err := Counters(r); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("Counters err = %v, want ErrInvalidLength", err)
+	}
+}
@@ -2,6 +2,7 @@
 package aliexhbase
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
@@ -17,31 +18,60 @@ type Conn struct {
 	t time.Time
 }
 
+// NewConn 使用阿里云增强版hbase的认证方式创建http传输层的连接
 func NewConn(addr, user, passwd string) (*Conn, error) {
-	conn := new(Conn)
-	transport, err := thrift.NewTHttpClient(addr)
-	// options := thrift.THttpClientOptions{
-	// 	Client: &http.Client{
+	return NewConnWithConfig(&ThriftPoolConfig{Addr: addr, User: user, Passwd: passwd})
+}
 
-	// 	}
-	// }
-	// transport, err := thrift.NewTHttpClientWithOptions()
-	if err != nil {
-		return nil, err
+// NewConnWithConfig 根据连接池配置中的传输层和协议设置创建连接
+func NewConnWithConfig(config *ThriftPoolConfig) (*Conn, error) {
+	var httpClient *http.Client
+	if config.Transport == Transport_HTTP {
+		httpClient = config.NewHTTPClient()
 	}
-	if err := transport.Open(); err != nil {
+	return newConn(config, httpClient)
+}
+
+// newConn 使用指定的http客户端创建连接,httpClient为nil时使用thrift默认的http客户端
+func newConn(config *ThriftPoolConfig, httpClient *http.Client) (*Conn, error) {
+	conn := new(Conn)
+	transport, err := newTransport(config, httpClient)
+	if err != nil {
 		return nil, err
 	}
-	protocolFactory := thrift.NewTBinaryProtocolFactoryDefault()
-	// 设置用户名密码
-	httClient := transport.(*thrift.THttpClient)
-	httClient.SetHeader("ACCESSKEYID", user)
-	httClient.SetHeader("ACCESSSIGNATURE", passwd)
-	conn.transport = transport
-	conn.THBaseServiceClient = hbase.NewTHBaseServiceClientFactory(transport, protocolFactory)
+	conn.transport = &countingTransport{TTransport: transport}
+	conn.THBaseServiceClient = hbase.NewTHBaseServiceClientFactory(conn.transport, config.Protocol.protocolFactory())
 	return conn, nil
 }
 
+// countingTransport 统计读写字节数的thrift传输层
+// 同一时间只有一个请求在使用连接,因此不需要加锁
+type countingTransport struct {
+	thrift.TTransport
+	read    int64
+	written int64
+}
+
+func (t *countingTransport) Read(p []byte) (int, error) {
+	n, err := t.TTransport.Read(p)
+	t.read += int64(n)
+	return n, err
+}
+
+func (t *countingTransport) Write(p []byte) (int, error) {
+	n, err := t.TTransport.Write(p)
+	t.written += int64(n)
+	return n, err
+}
+
+// bytes 获取连接累计发送和接收的字节数
+func (c *Conn) bytes() (sent, received int64) {
+	if t, ok := c.transport.(*countingTransport); ok {
+		return t.written, t.read
+	}
+	return 0, 0
+}
+
 // 检测连接是否有效
 func (c *Conn) Check() bool {
 	if c.transport == nil || c.THBaseServiceClient == nil {
@@ -0,0 +1,137 @@
+// 预分区建表
+package aliexhbase
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/filter"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/Golang-Tools/aliexhbase/split"
+)
+
+// defaultCreateTablePollInterval 等待表可用时默认的轮询间隔
+const defaultCreateTablePollInterval = 500 * time.Millisecond
+
+// defaultCreateTableWaitTimeout 等待表可用的默认超时
+const defaultCreateTableWaitTimeout = 60 * time.Second
+
+// createTableOptions 预分区建表配置
+type createTableOptions struct {
+	pollInterval time.Duration
+	waitTimeout  time.Duration
+}
+
+// CreateTableOption 预分区建表配置项
+type CreateTableOption func(*createTableOptions)
+
+// WithCreateTablePollInterval 设置检查表是否可用的间隔,默认为500ms
+func WithCreateTablePollInterval(PollInterval time.Duration) CreateTableOption {
+	return func(o *createTableOptions) {
+		o.pollInterval = PollInterval
+	}
+}
+
+// WithCreateTableWaitTimeout 设置等待表可用的最长时间,默认为60s
+func WithCreateTableWaitTimeout(WaitTimeout time.Duration) CreateTableOption {
+	return func(o *createTableOptions) {
+		o.waitTimeout = WaitTimeout
+	}
+}
+
+// CreateTableWithSplits 使用分割点预分区建表,并等待所有region上线
+// 分割点必须非空,按字节序升序排列且没有重复,否则返回split包中对应的错误且不会建表;
+// desc或desc.TableName为nil时返回exceptions.ErrIllegalArgument.
+// 建表后按间隔调用IsTableAvailableWithSplit,超时未就绪时返回ErrTableNotAvailable,此时表已经创建
+func (p *Client) CreateTableWithSplits(ctx context.Context, desc *hbase.TTableDescriptor, splitKeys [][]byte, opts ...CreateTableOption) error {
+	o := createTableOptions{pollInterval: defaultCreateTablePollInterval, waitTimeout: defaultCreateTableWaitTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = defaultCreateTablePollInterval
+	}
+	if desc == nil || desc.TableName == nil {
+		return fmt.Errorf("%w: 表描述和表名不能为nil", exceptions.ErrIllegalArgument)
+	}
+	if err := split.Validate(splitKeys); err != nil {
+		return err
+	}
+	if err := p.CreateTable(ctx, desc, splitKeys); err != nil {
+		return err
+	}
+	waitCtx := ctx
+	if o.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, o.waitTimeout)
+		defer cancel()
+	}
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+	// lastErr 最近一次不是由等待超时导致的检查错误
+	var lastErr error
+	for {
+		available, err := p.IsTableAvailableWithSplit(waitCtx, desc.TableName, splitKeys)
+		if err == nil && available {
+			return nil
+		}
+		if err != nil && waitCtx.Err() == nil {
+			lastErr = err
+		}
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if lastErr != nil {
+				return fmt.Errorf("%w: %s", ErrTableNotAvailable, lastErr)
+			}
+			return ErrTableNotAvailable
+		}
+	}
+}
+
+// SampleSplitKeys 扫描已有表的行键,按分位点生成regions个region的分割点,可以用于以相同的数据分布新建表
+// 扫描只返回行键,使用蓄水池抽样保留最多sampleSize个行键,sampleSize小于等于0时为regions*100.
+// 全表扫描的开销与表的大小成正比,可以通过tscan限制扫描范围,tscan为nil时扫描全表
+func (p *Client) SampleSplitKeys(ctx context.Context, table []byte, tscan *hbase.TScan, regions int, sampleSize int, opts ...ScanOption) ([][]byte, error) {
+	if regions < 1 {
+		return nil, split.ErrInvalidRegions
+	}
+	if sampleSize <= 0 {
+		sampleSize = regions * 100
+	}
+	if tscan == nil {
+		tscan = &hbase.TScan{}
+	}
+	keyOnly := *tscan
+	keyOnly.Columns = nil
+	f := filter.And(filter.FirstKeyOnlyFilter{}, filter.KeyOnlyFilter{}).String()
+	if len(tscan.FilterString) > 0 {
+		f = "(" + string(tscan.FilterString) + ") AND " + f
+	}
+	keyOnly.FilterString = []byte(f)
+	s := p.Scan(ctx, table, &keyOnly, opts...)
+	defer s.Close()
+	sample := make([][]byte, 0, sampleSize)
+	seen := 0
+	for s.Next() {
+		row := s.Result().Row
+		seen++
+		if len(sample) < sampleSize {
+			sample = append(sample, row)
+			continue
+		}
+		if i := rand.Intn(seen); i < sampleSize {
+			sample[i] = row
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return split.Sample(sample, regions)
+}
@@ -0,0 +1,167 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/Golang-Tools/aliexhbase/split"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+func TestSampleSplitKeysNilScan(t *testing.T) {
+	h := newScanHandler(100)
+	c := newTestClient(t, h)
+	// 样本大于行数时保留所有行键,分割点为精确的分位点
+	keys, err := c.SampleSplitKeys(context.Background(), []byte("t"), nil, 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%s", keys); got != "[row0025 row0050 row0075]" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+// createTableHandler 记录建表请求,第availableAfter次检查后表可用的假服务端
+type createTableHandler struct {
+	hbase.THBaseService
+	mu             sync.Mutex
+	creates        int
+	splitKeys      [][]byte
+	checks         int
+	availableAfter int
+	// 不为nil时检查返回该错误
+	checkErr error
+	// 第checks次检查时调用
+	onCheck func(checks int)
+}
+
+func (h *createTableHandler) CreateTable(ctx context.Context, desc *hbase.TTableDescriptor, splitKeys [][]byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.creates++
+	h.splitKeys = splitKeys
+	return nil
+}
+
+func (h *createTableHandler) IsTableAvailableWithSplit(ctx context.Context, tableName *hbase.TTableName, splitKeys [][]byte) (bool, error) {
+	h.mu.Lock()
+	h.checks++
+	checks := h.checks
+	h.mu.Unlock()
+	if h.onCheck != nil {
+		h.onCheck(checks)
+	}
+	if h.checkErr != nil {
+		return false, h.checkErr
+	}
+	return h.availableAfter > 0 && checks >= h.availableAfter, nil
+}
+
+func (h *createTableHandler) counts() (creates, checks int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.creates, h.checks
+}
+
+func testTableDescriptor() *hbase.TTableDescriptor {
+	return &hbase.TTableDescriptor{
+		TableName: &hbase.TTableName{Qualifier: []byte("t")},
+		Columns:   []*hbase.TColumnFamilyDescriptor{{Name: []byte("cf")}},
+	}
+}
+
+func TestCreateTableWithSplitsInvalidArguments(t *testing.T) {
+	keys := [][]byte{[]byte("a")}
+	for _, tc := range []struct {
+		name string
+		desc *hbase.TTableDescriptor
+		keys [][]byte
+		want error
+	}{
+		{"unsorted", testTableDescriptor(), [][]byte{[]byte("b"), []byte("a")}, split.ErrSplitKeysNotSorted},
+		{"duplicate", testTableDescriptor(), [][]byte{[]byte("a"), []byte("b"), []byte("b")}, split.ErrDuplicateSplitKey},
+		{"empty", testTableDescriptor(), [][]byte{[]byte("a"), {}}, split.ErrEmptySplitKey},
+		{"nil desc", nil, keys, exceptions.ErrIllegalArgument},
+		{"nil table name", &hbase.TTableDescriptor{Columns: testTableDescriptor().Columns}, keys, exceptions.ErrIllegalArgument},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &createTableHandler{availableAfter: 1}
+			c := newTestClient(t, h)
+			err := c.CreateTableWithSplits(context.Background(), tc.desc, tc.keys)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("错误为%v,期望%v", err, tc.want)
+			}
+			if creates, checks := h.counts(); creates != 0 || checks != 0 {
+				t.Fatalf("参数错误时仍然建表%d次,检查%d次", creates, checks)
+			}
+		})
+	}
+}
+
+func TestCreateTableWithSplitsWaitsForAvailable(t *testing.T) {
+	h := &createTableHandler{availableAfter: 3}
+	c := newTestClient(t, h)
+	keys := [][]byte{[]byte("a"), []byte("m")}
+	err := c.CreateTableWithSplits(context.Background(), testTableDescriptor(), keys, WithCreateTablePollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	creates, checks := h.counts()
+	if creates != 1 || checks != 3 {
+		t.Fatalf("建表%d次,检查%d次,期望建表1次,检查3次", creates, checks)
+	}
+	if fmt.Sprintf("%s", h.splitKeys) != "[a m]" {
+		t.Fatalf("建表的分割点为%s", h.splitKeys)
+	}
+}
+
+func TestCreateTableWithSplitsTimeout(t *testing.T) {
+	h := &createTableHandler{}
+	c := newTestClient(t, h)
+	err := c.CreateTableWithSplits(context.Background(), testTableDescriptor(), [][]byte{[]byte("a")},
+		WithCreateTablePollInterval(time.Millisecond), WithCreateTableWaitTimeout(30*time.Millisecond))
+	if err != ErrTableNotAvailable {
+		t.Fatalf("检查没有出错时超时的错误为%v,期望ErrTableNotAvailable", err)
+	}
+
+	h = &createTableHandler{checkErr: &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.ipc.ServerNotRunningYetException: master initializing")}}
+	c = newTestClient(t, h, WithRetryPolicy(RetryPolicy{}))
+	err = c.CreateTableWithSplits(context.Background(), testTableDescriptor(), [][]byte{[]byte("a")},
+		WithCreateTablePollInterval(time.Millisecond), WithCreateTableWaitTimeout(30*time.Millisecond))
+	if !errors.Is(err, ErrTableNotAvailable) {
+		t.Fatalf("错误为%v,期望ErrTableNotAvailable", err)
+	}
+	if !strings.Contains(err.Error(), "master initializing") {
+		t.Fatalf("错误%q中没有最近一次检查的错误", err)
+	}
+	if _, checks := h.counts(); checks < 2 {
+		t.Fatalf("超时前只检查了%d次", checks)
+	}
+}
+
+func TestCreateTableWithSplitsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &createTableHandler{onCheck: func(checks int) {
+		if checks == 2 {
+			cancel()
+		}
+	}}
+	c := newTestClient(t, h)
+	start := time.Now()
+	err := c.CreateTableWithSplits(ctx, testTableDescriptor(), [][]byte{[]byte("a")}, WithCreateTablePollInterval(time.Millisecond))
+	if err != context.Canceled {
+		t.Fatalf("错误为%v,期望context.Canceled", err)
+	}
+	// 调用方取消时立即返回,不等待默认的60s超时
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("取消后%v才返回", elapsed)
+	}
+}
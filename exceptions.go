@@ -21,4 +21,34 @@ var (
 	ErrClientCreateParamsNotEnough = errors.New("Client 对象创建参数不全")
 	//ErrClientPoolNotSet Client 未设置连接池
 	ErrClientPoolNotSet = errors.New("Client 未设置连接池")
+	//ErrUnsupportedScheme 不支持的url schema
+	ErrUnsupportedScheme = errors.New("不支持的url schema,可选的有http,https,thrift,thrift+buffered,thrift+framed")
+	//ErrUnsupportedProtocol 不支持的thrift协议
+	ErrUnsupportedProtocol = errors.New("不支持的thrift协议,可选的有binary,compact")
+	//ErrScanResumeBatchSize 可恢复的扫描不支持设置BatchSize
+	ErrScanResumeBatchSize = errors.New("可恢复的扫描不支持设置BatchSize,中断时无法确定行内的扫描位置")
+	//ErrParallelScanUnsupported 并行扫描不支持的扫描设置
+	ErrParallelScanUnsupported = errors.New("并行扫描不支持反向扫描,Limit和BatchSize")
+	//ErrScanTokenInvalid 分页扫描的令牌无效
+	ErrScanTokenInvalid = errors.New("分页扫描的令牌无效或与表不匹配")
+	//ErrScanPageSize 分页扫描的每页行数无效
+	ErrScanPageSize = errors.New("分页扫描的每页行数必须大于0")
+	//ErrScanPageBatchSize 分页扫描不支持设置BatchSize
+	ErrScanPageBatchSize = errors.New("分页扫描不支持设置BatchSize")
+	//ErrBatchWriterClosed 批量写入器已经关闭
+	ErrBatchWriterClosed = errors.New("批量写入器已经关闭")
+	//ErrNilMutation 批量写入的变更或其中的列为nil
+	ErrNilMutation = errors.New("批量写入的变更或其中的列为nil")
+	//ErrDeleteNotApplied DeleteMultiple返回了未执行的删除
+	ErrDeleteNotApplied = errors.New("删除未被执行")
+	//ErrBatchResultMismatch 批量读取返回的结果数与请求数不一致
+	ErrBatchResultMismatch = errors.New("批量读取返回的结果数与请求数不一致")
+	//ErrRowNotFound 行不存在
+	ErrRowNotFound = errors.New("行不存在")
+	//ErrScanIntoDest ScanInto的目标类型错误
+	ErrScanIntoDest = errors.New("ScanInto的目标必须是结构体切片或结构体指针切片的指针")
+	//ErrTableNotAvailable 建表后等待超时,表仍未可用
+	ErrTableNotAvailable = errors.New("建表后等待超时,表仍未可用")
+	//ErrTLSCAFileNoCert TLS CA文件中没有有效的证书
+	ErrTLSCAFileNoCert = errors.New("TLS CA文件中没有有效的证书")
 )
@@ -0,0 +1,213 @@
+// hbase操作的错误定义
+// 服务端返回的TIOError,TIllegalArgument以及传输层错误会被归类为本包中定义的错误类型,
+// 可以使用errors.Is判断错误类型,使用errors.As获取带有操作上下文的*Error或者原始错误
+package exceptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+//错误类型
+var (
+	//ErrTableNotFound 表不存在
+	ErrTableNotFound = errors.New("表不存在")
+	//ErrTableExists 表已存在
+	ErrTableExists = errors.New("表已存在")
+	//ErrNamespaceNotFound 命名空间不存在
+	ErrNamespaceNotFound = errors.New("命名空间不存在")
+	//ErrTableDisabled 表已被禁用
+	ErrTableDisabled = errors.New("表已被禁用")
+	//ErrScannerExpired 服务端扫描器不存在或租约已过期
+	ErrScannerExpired = errors.New("扫描器已失效")
+	//ErrRegionUnavailable region不可用,通常发生在region迁移,分裂或下线期间
+	ErrRegionUnavailable = errors.New("region不可用")
+	//ErrThrottled 请求被限流或服务端繁忙
+	ErrThrottled = errors.New("请求被限流")
+	//ErrAuthFailed 认证或鉴权失败
+	ErrAuthFailed = errors.New("认证失败")
+	//ErrTimeout 请求超时
+	ErrTimeout = errors.New("请求超时")
+	//ErrIllegalArgument 请求参数错误
+	ErrIllegalArgument = errors.New("请求参数错误")
+	//ErrIO 无法进一步归类的服务端IO错误
+	ErrIO = errors.New("服务端IO错误")
+	//ErrTransport 无法进一步归类的传输层错误
+	ErrTransport = errors.New("传输层错误")
+)
+
+// Error 带有操作上下文的hbase错误
+type Error struct {
+	// 错误类型,为本包中定义的错误类型之一,无法归类时为nil
+	Kind error
+	// 出错的操作名
+	Op string
+	// 出错的表名
+	Table []byte
+	// 出错的行
+	Row []byte
+	// 原始错误
+	Err error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("aliexhbase ")
+	b.WriteString(e.Op)
+	if len(e.Table) > 0 || len(e.Row) > 0 {
+		b.WriteString("(")
+		if len(e.Table) > 0 {
+			fmt.Fprintf(&b, "table=%s", e.Table)
+		}
+		if len(e.Row) > 0 {
+			if len(e.Table) > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "row=%q", e.Row)
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(": ")
+	if e.Kind != nil {
+		b.WriteString(e.Kind.Error())
+		b.WriteString(": ")
+	}
+	b.WriteString(message(e.Err))
+	return b.String()
+}
+
+// Unwrap 返回原始错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 判断错误类型
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// Wrap 为错误归类并附加操作上下文,err为nil时返回nil,已经包装过的错误不会重复包装
+func Wrap(err error, op string, table, row []byte) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{
+		Kind:  Classify(err),
+		Op:    op,
+		Table: table,
+		Row:   row,
+		Err:   err,
+	}
+}
+
+// message 获取错误信息,thrift生成的错误类型在Message为空时也能给出有意义的信息
+func message(err error) string {
+	switch e := err.(type) {
+	case *hbase.TIOError:
+		return "TIOError: " + e.GetMessage()
+	case *hbase.TIllegalArgument:
+		return "TIllegalArgument: " + e.GetMessage()
+	default:
+		return err.Error()
+	}
+}
+
+// kindPatterns TIOError错误信息中的关键字与错误类型的对应关系,按顺序匹配
+// 服务端会把java异常的类名和信息放在TIOError的message中
+var kindPatterns = []struct {
+	kind     error
+	keywords []string
+}{
+	{ErrTableNotFound, []string{"tablenotfoundexception"}},
+	{ErrNamespaceNotFound, []string{"namespacenotfoundexception"}},
+	{ErrTableExists, []string{"tableexistsexception"}},
+	{ErrTableDisabled, []string{"tablenotenabledexception", "is disabled"}},
+	{ErrScannerExpired, []string{"unknownscannerexception", "leaseexception", "scannertimeoutexception", "outoforderscannernextexception", "invalid scanner id"}},
+	{ErrThrottled, []string{"throttlingexception", "quotaexceededexception", "regiontoobusyexception", "calldroppedexception", "callqueuetoobigexception", "serverbusy", "throttled"}},
+	{ErrRegionUnavailable, []string{"notservingregionexception", "regionofflineexception", "regionmovedexception", "noserverforregionexception", "regionopeningexception", "regionserverstoppedexception", "servernotrunningyetexception"}},
+	{ErrAuthFailed, []string{"accessdeniedexception", "access denied", "permission denied", "authenticationexception", "authentication failed", "unauthorized"}},
+	{ErrTimeout, []string{"calltimeoutexception", "sockettimeoutexception", "timeoutioexception", "operationtimeoutexception", "timed out"}},
+}
+
+// classifyMessage 根据错误信息中的关键字判断错误类型,无法判断时返回nil
+func classifyMessage(msg string) error {
+	msg = strings.ToLower(msg)
+	for _, p := range kindPatterns {
+		for _, k := range p.keywords {
+			if strings.Contains(msg, k) {
+				return p.kind
+			}
+		}
+	}
+	return nil
+}
+
+// httpStatusKinds http传输层返回的状态码与错误类型的对应关系
+var httpStatusKinds = map[string]error{
+	"HTTP Response code: 400": ErrIllegalArgument,
+	"HTTP Response code: 401": ErrAuthFailed,
+	"HTTP Response code: 403": ErrAuthFailed,
+	"HTTP Response code: 429": ErrThrottled,
+	"HTTP Response code: 504": ErrTimeout,
+}
+
+// Classify 判断错误的类型,返回本包中定义的错误类型之一,无法归类时返回nil
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	var ioErr *hbase.TIOError
+	if errors.As(err, &ioErr) {
+		if kind := classifyMessage(ioErr.GetMessage()); kind != nil {
+			return kind
+		}
+		return ErrIO
+	}
+	var argErr *hbase.TIllegalArgument
+	if errors.As(err, &argErr) {
+		// 扫描器id无效时thrift服务端返回的是TIllegalArgument
+		if kind := classifyMessage(argErr.GetMessage()); kind == ErrScannerExpired {
+			return kind
+		}
+		return ErrIllegalArgument
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	if tErr, ok := err.(thrift.TTransportException); ok {
+		if tErr.TypeId() == thrift.TIMED_OUT {
+			return ErrTimeout
+		}
+		if kind, ok := httpStatusKinds[tErr.Error()]; ok {
+			return kind
+		}
+		if inner := tErr.Err(); inner != nil && inner != err {
+			if kind := Classify(inner); kind != nil && kind != ErrTransport {
+				return kind
+			}
+		}
+		return ErrTransport
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrTimeout
+		}
+		return ErrTransport
+	}
+	return nil
+}
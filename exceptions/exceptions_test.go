@@ -0,0 +1,103 @@
+package exceptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+func ioError(msg string) error {
+	return &hbase.TIOError{Message: thrift.StringPtr(msg)}
+}
+
+// timeoutError 超时的net.Error
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"unknown", errors.New("boom"), nil},
+		{"table not found", ioError("org.apache.hadoop.hbase.TableNotFoundException: ns:t"), ErrTableNotFound},
+		{"namespace not found", ioError("org.apache.hadoop.hbase.NamespaceNotFoundException: ns"), ErrNamespaceNotFound},
+		{"table exists", ioError("org.apache.hadoop.hbase.TableExistsException: t"), ErrTableExists},
+		{"table disabled", ioError("org.apache.hadoop.hbase.TableNotEnabledException: t"), ErrTableDisabled},
+		{"table is disabled", ioError("t is disabled."), ErrTableDisabled},
+		{"scanner expired", ioError("org.apache.hadoop.hbase.UnknownScannerException: Unknown scanner '42'"), ErrScannerExpired},
+		{"lease", ioError("org.apache.hadoop.hbase.regionserver.LeaseException: lease '42' does not exist"), ErrScannerExpired},
+		{"throttled", ioError("org.apache.hadoop.hbase.quotas.RpcThrottlingException: number of requests exceeded"), ErrThrottled},
+		{"busy", ioError("org.apache.hadoop.hbase.RegionTooBusyException: Over memstore limit"), ErrThrottled},
+		{"not serving", ioError("org.apache.hadoop.hbase.NotServingRegionException: t,,1.abc is not online"), ErrRegionUnavailable},
+		{"moved", ioError("org.apache.hadoop.hbase.exceptions.RegionMovedException: Region moved to: host"), ErrRegionUnavailable},
+		{"access denied", ioError("org.apache.hadoop.hbase.security.AccessDeniedException: Insufficient permissions"), ErrAuthFailed},
+		{"call timeout", ioError("org.apache.hadoop.hbase.ipc.CallTimeoutException: Call id=1"), ErrTimeout},
+		{"io", ioError("java.io.IOException: something"), ErrIO},
+		{"empty io", &hbase.TIOError{}, ErrIO},
+		{"invalid scanner id", &hbase.TIllegalArgument{Message: thrift.StringPtr("Invalid scanner Id")}, ErrScannerExpired},
+		{"illegal argument", &hbase.TIllegalArgument{Message: thrift.StringPtr("No columns to insert")}, ErrIllegalArgument},
+		{"deadline", context.DeadlineExceeded, ErrTimeout},
+		{"wrapped deadline", fmt.Errorf("wait: %w", context.DeadlineExceeded), ErrTimeout},
+		{"canceled", context.Canceled, nil},
+		{"transport timeout", thrift.NewTTransportException(thrift.TIMED_OUT, "read timeout"), ErrTimeout},
+		{"http 401", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 401"), ErrAuthFailed},
+		{"http 403", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 403"), ErrAuthFailed},
+		{"http 400", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 400"), ErrIllegalArgument},
+		{"http 429", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 429"), ErrThrottled},
+		{"http 504", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 504"), ErrTimeout},
+		{"http 500", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 500"), ErrTransport},
+		{"transport", thrift.NewTTransportException(thrift.NOT_OPEN, "not open"), ErrTransport},
+		// 传输层错误内部的原始错误可以进一步归类
+		{"transport wrapping deadline", thrift.NewTTransportExceptionFromError(fmt.Errorf("post: %w", context.DeadlineExceeded)), ErrTimeout},
+		{"transport wrapping net", thrift.NewTTransportExceptionFromError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), ErrTransport},
+		{"net timeout", &net.OpError{Op: "read", Err: timeoutError{}}, ErrTimeout},
+		{"net", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrTransport},
+		// 已经包装过的错误使用包装时的归类
+		{"wrapped", fmt.Errorf("outer: %w", Wrap(ioError("TableNotFoundException"), "Get", nil, nil)), ErrTableNotFound},
+		{"wrapped unknown", Wrap(errors.New("boom"), "Get", nil, nil), nil},
+	} {
+		if got := Classify(tc.err); got != tc.want {
+			t.Errorf("%s: Classify = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil, "Get", nil, nil) != nil {
+		t.Fatal("Wrap(nil) != nil")
+	}
+	cause := ioError("org.apache.hadoop.hbase.TableNotFoundException: t")
+	err := Wrap(cause, "Get", []byte("t"), []byte("r1"))
+	if !errors.Is(err, ErrTableNotFound) || errors.Is(err, ErrIO) {
+		t.Fatalf("errors.Is failed for %v", err)
+	}
+	var ioErr *hbase.TIOError
+	if !errors.As(err, &ioErr) || ioErr != cause {
+		t.Fatal("errors.As没有拿到原始错误")
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "Get" || string(e.Table) != "t" || string(e.Row) != "r1" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if got, want := err.Error(), `aliexhbase Get(table=t, row="r1"): 表不存在: TIOError: org.apache.hadoop.hbase.TableNotFoundException: t`; got != want {
+		t.Fatalf("Error() = %s, want %s", got, want)
+	}
+	// 不会重复包装
+	if again := Wrap(fmt.Errorf("retry: %w", err), "Put", nil, nil); !errors.As(again, &e) || e.Op != "Get" {
+		t.Fatalf("重复包装了 %v", again)
+	}
+	if got, want := Wrap(errors.New("boom"), "ListNamespaceDescriptors", nil, nil).Error(), "aliexhbase ListNamespaceDescriptors: boom"; got != want {
+		t.Fatalf("Error() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,398 @@
+// HBase过滤器语言的类型化表示
+// 过滤器以结构体构造,String方法生成可以直接用于TGet.FilterString/TScan.FilterString的过滤器字符串,
+// 单引号转义,比较器前缀等由本包处理;Parse可以将已有的过滤器字符串解析回结构体:
+//
+//	f := filter.And(
+//		filter.PrefixFilter{Prefix: []byte("user_")},
+//		filter.SingleColumnValueFilter{Family: []byte("info"), Qualifier: []byte("age"), Op: filter.GreaterOrEqual, Comparator: filter.Binary(codec.EncodeInt32(18))},
+//	)
+//	s, err := filter.Build(f)
+//	tscan.FilterString = []byte(s)
+//
+// 组合运算的优先级从高到低为SKIP/WHILE,AND,OR
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 错误类型
+var (
+	//ErrSyntax 过滤器字符串语法错误
+	ErrSyntax = errors.New("过滤器字符串语法错误")
+	//ErrInvalidArguments 过滤器参数错误
+	ErrInvalidArguments = errors.New("过滤器参数错误")
+	//ErrEmptyFilterList 过滤器列表为空
+	ErrEmptyFilterList = errors.New("过滤器列表不能为空")
+	//ErrNilFilter 过滤器为nil
+	ErrNilFilter = errors.New("过滤器不能为nil")
+)
+
+// Filter 过滤器
+type Filter interface {
+	// String 生成过滤器字符串
+	String() string
+}
+
+// CompareOp 比较运算符
+type CompareOp string
+
+// 比较运算符
+const (
+	Less           CompareOp = "<"
+	LessOrEqual    CompareOp = "<="
+	Equal          CompareOp = "="
+	NotEqual       CompareOp = "!="
+	GreaterOrEqual CompareOp = ">="
+	Greater        CompareOp = ">"
+)
+
+// valid 判断是否为合法的比较运算符
+func (op CompareOp) valid() bool {
+	switch op {
+	case Less, LessOrEqual, Equal, NotEqual, GreaterOrEqual, Greater:
+		return true
+	}
+	return false
+}
+
+// ComparatorType 比较器类型
+type ComparatorType string
+
+// 比较器类型
+const (
+	// ComparatorBinary 按字节序比较
+	ComparatorBinary ComparatorType = "binary"
+	// ComparatorBinaryPrefix 按字节序比较值的前缀
+	ComparatorBinaryPrefix ComparatorType = "binaryprefix"
+	// ComparatorRegex 正则匹配,只支持Equal和NotEqual
+	ComparatorRegex ComparatorType = "regexstring"
+	// ComparatorSubstring 子串匹配,不区分大小写,只支持Equal和NotEqual
+	ComparatorSubstring ComparatorType = "substring"
+)
+
+// Comparator 比较器
+type Comparator struct {
+	Type  ComparatorType
+	Value []byte
+}
+
+// Binary 按字节序与value比较
+func Binary(value []byte) Comparator {
+	return Comparator{Type: ComparatorBinary, Value: value}
+}
+
+// BinaryPrefix 按字节序与value比较值的前缀
+func BinaryPrefix(value []byte) Comparator {
+	return Comparator{Type: ComparatorBinaryPrefix, Value: value}
+}
+
+// Regex 使用java正则表达式匹配
+func Regex(pattern string) Comparator {
+	return Comparator{Type: ComparatorRegex, Value: []byte(pattern)}
+}
+
+// Substring 不区分大小写地匹配子串
+func Substring(substr string) Comparator {
+	return Comparator{Type: ComparatorSubstring, Value: []byte(substr)}
+}
+
+// String 生成带引号的比较器参数
+func (c Comparator) String() string {
+	return quote(append([]byte(string(c.Type)+":"), c.Value...))
+}
+
+// quote 将字节串写为单引号括起的字面量,单引号写两次
+func quote(b []byte) string {
+	return "'" + strings.ReplaceAll(string(b), "'", "''") + "'"
+}
+
+// call 生成`Name(arg1, arg2)`形式的过滤器字符串
+func call(name string, args ...string) string {
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// KeyOnlyFilter 只返回键,不返回值
+type KeyOnlyFilter struct{}
+
+func (f KeyOnlyFilter) String() string {
+	return call("KeyOnlyFilter")
+}
+
+// FirstKeyOnlyFilter 每行只返回第一列
+type FirstKeyOnlyFilter struct{}
+
+func (f FirstKeyOnlyFilter) String() string {
+	return call("FirstKeyOnlyFilter")
+}
+
+// PrefixFilter 只返回行键以Prefix开头的行
+type PrefixFilter struct {
+	Prefix []byte
+}
+
+func (f PrefixFilter) String() string {
+	return call("PrefixFilter", quote(f.Prefix))
+}
+
+// ColumnPrefixFilter 只返回列名以Prefix开头的列
+type ColumnPrefixFilter struct {
+	Prefix []byte
+}
+
+func (f ColumnPrefixFilter) String() string {
+	return call("ColumnPrefixFilter", quote(f.Prefix))
+}
+
+// ColumnRangeFilter 只返回列名在MinColumn和MaxColumn之间的列
+type ColumnRangeFilter struct {
+	MinColumn    []byte
+	MinInclusive bool
+	MaxColumn    []byte
+	MaxInclusive bool
+}
+
+func (f ColumnRangeFilter) String() string {
+	return call("ColumnRangeFilter", quote(f.MinColumn), strconv.FormatBool(f.MinInclusive), quote(f.MaxColumn), strconv.FormatBool(f.MaxInclusive))
+}
+
+// PageFilter 每个region最多返回PageSize行,多个region的结果合并后可能超过PageSize
+type PageFilter struct {
+	PageSize int64
+}
+
+func (f PageFilter) String() string {
+	return call("PageFilter", strconv.FormatInt(f.PageSize, 10))
+}
+
+// TimestampsFilter 只返回时间戳在Timestamps中的版本
+type TimestampsFilter struct {
+	Timestamps []int64
+}
+
+func (f TimestampsFilter) String() string {
+	args := make([]string, len(f.Timestamps))
+	for i, ts := range f.Timestamps {
+		args[i] = strconv.FormatInt(ts, 10)
+	}
+	return call("TimestampsFilter", args...)
+}
+
+// RowFilter 按行键比较
+type RowFilter struct {
+	Op         CompareOp
+	Comparator Comparator
+}
+
+func (f RowFilter) String() string {
+	return call("RowFilter", string(f.Op), f.Comparator.String())
+}
+
+// FamilyFilter 按列族比较
+type FamilyFilter struct {
+	Op         CompareOp
+	Comparator Comparator
+}
+
+func (f FamilyFilter) String() string {
+	return call("FamilyFilter", string(f.Op), f.Comparator.String())
+}
+
+// QualifierFilter 按列名比较
+type QualifierFilter struct {
+	Op         CompareOp
+	Comparator Comparator
+}
+
+func (f QualifierFilter) String() string {
+	return call("QualifierFilter", string(f.Op), f.Comparator.String())
+}
+
+// ValueFilter 按值比较
+type ValueFilter struct {
+	Op         CompareOp
+	Comparator Comparator
+}
+
+func (f ValueFilter) String() string {
+	return call("ValueFilter", string(f.Op), f.Comparator.String())
+}
+
+// SingleColumnValueFilter 按指定列的值过滤整行
+type SingleColumnValueFilter struct {
+	Family     []byte
+	Qualifier  []byte
+	Op         CompareOp
+	Comparator Comparator
+	// 为true时过滤掉没有该列的行,默认保留
+	FilterIfMissing bool
+	// 为true时比较该列的所有版本,默认只比较最新版本
+	AllVersions bool
+}
+
+func (f SingleColumnValueFilter) String() string {
+	args := []string{quote(f.Family), quote(f.Qualifier), string(f.Op), f.Comparator.String()}
+	if f.FilterIfMissing || f.AllVersions {
+		args = append(args, strconv.FormatBool(f.FilterIfMissing), strconv.FormatBool(!f.AllVersions))
+	}
+	return call("SingleColumnValueFilter", args...)
+}
+
+// GenericFilter 本包没有对应类型的过滤器,Args为参数在过滤器字符串中的原文,例如`'abc'`,`10`,`true`或`=`
+type GenericFilter struct {
+	Name string
+	Args []string
+}
+
+func (f GenericFilter) String() string {
+	return call(f.Name, f.Args...)
+}
+
+// ListOp 过滤器列表的组合方式
+type ListOp string
+
+// 过滤器列表的组合方式
+const (
+	// OpAnd 所有过滤器都通过
+	OpAnd ListOp = "AND"
+	// OpOr 任意过滤器通过
+	OpOr ListOp = "OR"
+)
+
+// FilterList 过滤器列表
+type FilterList struct {
+	Op      ListOp
+	Filters []Filter
+}
+
+// And 组合为所有过滤器都需要通过的列表
+func And(filters ...Filter) FilterList {
+	return FilterList{Op: OpAnd, Filters: filters}
+}
+
+// Or 组合为任意过滤器通过即可的列表
+func Or(filters ...Filter) FilterList {
+	return FilterList{Op: OpOr, Filters: filters}
+}
+
+// operand 生成作为组合运算操作数的字符串,列表需要加括号
+func operand(f Filter) string {
+	if _, ok := f.(FilterList); ok {
+		return "(" + f.String() + ")"
+	}
+	return f.String()
+}
+
+// String 生成以Op连接的过滤器字符串,空列表生成空字符串,需要通过Validate或Build检查
+func (f FilterList) String() string {
+	parts := make([]string, len(f.Filters))
+	for i, child := range f.Filters {
+		parts[i] = operand(child)
+	}
+	return strings.Join(parts, " "+string(f.Op)+" ")
+}
+
+// SkipFilter 被包装的过滤器过滤掉某一列时跳过整行
+type SkipFilter struct {
+	Filter Filter
+}
+
+// Skip 包装为SkipFilter
+func Skip(f Filter) SkipFilter {
+	return SkipFilter{Filter: f}
+}
+
+func (f SkipFilter) String() string {
+	return "SKIP " + operand(f.Filter)
+}
+
+// WhileFilter 被包装的过滤器第一次过滤掉数据时结束扫描
+type WhileFilter struct {
+	Filter Filter
+}
+
+// While 包装为WhileFilter
+func While(f Filter) WhileFilter {
+	return WhileFilter{Filter: f}
+}
+
+func (f WhileFilter) String() string {
+	return "WHILE " + operand(f.Filter)
+}
+
+// Validate 检查过滤器能否生成合法的过滤器字符串:
+// 过滤器不能为nil,列表不能为空且组合方式必须为AND或OR,比较运算符必须合法,
+// 比较器类型必须是本包定义的四种之一,正则和子串比较器只能使用Equal和NotEqual,
+// PageFilter的PageSize不能为负数,TimestampsFilter至少有一个时间戳,GenericFilter的名字不能为空
+func Validate(f Filter) error {
+	switch f := f.(type) {
+	case nil:
+		return ErrNilFilter
+	case FilterList:
+		if f.Op != OpAnd && f.Op != OpOr {
+			return fmt.Errorf("%w: 组合方式%q不合法", ErrInvalidArguments, f.Op)
+		}
+		if len(f.Filters) == 0 {
+			return ErrEmptyFilterList
+		}
+		for _, child := range f.Filters {
+			if err := Validate(child); err != nil {
+				return err
+			}
+		}
+	case SkipFilter:
+		return Validate(f.Filter)
+	case WhileFilter:
+		return Validate(f.Filter)
+	case RowFilter:
+		return validCompare("RowFilter", f.Op, f.Comparator)
+	case FamilyFilter:
+		return validCompare("FamilyFilter", f.Op, f.Comparator)
+	case QualifierFilter:
+		return validCompare("QualifierFilter", f.Op, f.Comparator)
+	case ValueFilter:
+		return validCompare("ValueFilter", f.Op, f.Comparator)
+	case SingleColumnValueFilter:
+		return validCompare("SingleColumnValueFilter", f.Op, f.Comparator)
+	case PageFilter:
+		if f.PageSize < 0 {
+			return fmt.Errorf("%w: PageFilter的PageSize%d为负数", ErrInvalidArguments, f.PageSize)
+		}
+	case TimestampsFilter:
+		if len(f.Timestamps) == 0 {
+			return fmt.Errorf("%w: TimestampsFilter没有时间戳", ErrInvalidArguments)
+		}
+	case GenericFilter:
+		if f.Name == "" {
+			return fmt.Errorf("%w: GenericFilter的名字为空", ErrInvalidArguments)
+		}
+	}
+	return nil
+}
+
+// validCompare 检查比较运算符和比较器类型,以及比较器是否支持该运算符
+func validCompare(name string, op CompareOp, c Comparator) error {
+	if !op.valid() {
+		return fmt.Errorf("%w: %s的比较运算符%q不合法", ErrInvalidArguments, name, op)
+	}
+	switch c.Type {
+	case ComparatorBinary, ComparatorBinaryPrefix, ComparatorRegex, ComparatorSubstring:
+	default:
+		return fmt.Errorf("%w: %s的比较器类型%q不合法", ErrInvalidArguments, name, c.Type)
+	}
+	if (c.Type == ComparatorRegex || c.Type == ComparatorSubstring) && op != Equal && op != NotEqual {
+		return fmt.Errorf("%w: %s的%s比较器不支持比较运算符%q", ErrInvalidArguments, name, c.Type, op)
+	}
+	return nil
+}
+
+// Build 检查过滤器并生成过滤器字符串
+func Build(f Filter) (string, error) {
+	if err := Validate(f); err != nil {
+		return "", err
+	}
+	return f.String(), nil
+}
@@ -0,0 +1,165 @@
+package filter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want Filter
+	}{
+		{"KeyOnlyFilter()", KeyOnlyFilter{}},
+		{"FirstKeyOnlyFilter()", FirstKeyOnlyFilter{}},
+		{"PrefixFilter('user_')", PrefixFilter{Prefix: []byte("user_")}},
+		{"PrefixFilter('it''s')", PrefixFilter{Prefix: []byte("it's")}},
+		{"ColumnPrefixFilter('ab')", ColumnPrefixFilter{Prefix: []byte("ab")}},
+		{"ColumnRangeFilter('a', true, 'c', false)", ColumnRangeFilter{MinColumn: []byte("a"), MinInclusive: true, MaxColumn: []byte("c")}},
+		{"PageFilter(10)", PageFilter{PageSize: 10}},
+		{"TimestampsFilter(1, 2, 3)", TimestampsFilter{Timestamps: []int64{1, 2, 3}}},
+		{"RowFilter(<=, 'binary:row1')", RowFilter{Op: LessOrEqual, Comparator: Binary([]byte("row1"))}},
+		{"FamilyFilter(=, 'binaryprefix:cf')", FamilyFilter{Op: Equal, Comparator: BinaryPrefix([]byte("cf"))}},
+		{"QualifierFilter(!=, 'regexstring:^a.*')", QualifierFilter{Op: NotEqual, Comparator: Regex("^a.*")}},
+		{"ValueFilter(=, 'substring:a''b')", ValueFilter{Op: Equal, Comparator: Substring("a'b")}},
+		{"ValueFilter(>, 'binary:a:b')", ValueFilter{Op: Greater, Comparator: Binary([]byte("a:b"))}},
+		{"SingleColumnValueFilter('cf', 'q', >=, 'binary:10')", SingleColumnValueFilter{Family: []byte("cf"), Qualifier: []byte("q"), Op: GreaterOrEqual, Comparator: Binary([]byte("10"))}},
+		{"SingleColumnValueFilter('cf', 'q', <, 'binary:10', true, true)", SingleColumnValueFilter{Family: []byte("cf"), Qualifier: []byte("q"), Op: Less, Comparator: Binary([]byte("10")), FilterIfMissing: true}},
+		{"SingleColumnValueFilter('cf', 'q', <, 'binary:10', false, false)", SingleColumnValueFilter{Family: []byte("cf"), Qualifier: []byte("q"), Op: Less, Comparator: Binary([]byte("10")), AllVersions: true}},
+		{"ColumnCountGetFilter(4)", GenericFilter{Name: "ColumnCountGetFilter", Args: []string{"4"}}},
+		{"DependentColumnFilter('cf', 'q', true, =, 'binary:x')", GenericFilter{Name: "DependentColumnFilter", Args: []string{"'cf'", "'q'", "true", "=", "'binary:x'"}}},
+		{"PrefixFilter('a') AND KeyOnlyFilter()", And(PrefixFilter{Prefix: []byte("a")}, KeyOnlyFilter{})},
+		{"PrefixFilter('a') OR PrefixFilter('b') OR PrefixFilter('c')", Or(PrefixFilter{Prefix: []byte("a")}, PrefixFilter{Prefix: []byte("b")}, PrefixFilter{Prefix: []byte("c")})},
+		{"SKIP ValueFilter(=, 'binary:0')", Skip(ValueFilter{Op: Equal, Comparator: Binary([]byte("0"))})},
+		{"WHILE RowFilter(<, 'binary:m')", While(RowFilter{Op: Less, Comparator: Binary([]byte("m"))})},
+	} {
+		f, err := Parse(tc.s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(f, tc.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tc.s, f, tc.want)
+		}
+		s, err := Build(f)
+		if err != nil {
+			t.Errorf("Build(%q): %v", tc.s, err)
+			continue
+		}
+		if s != tc.s {
+			t.Errorf("String() = %q, want %q", s, tc.s)
+		}
+		again, err := Parse(s)
+		if err != nil || !reflect.DeepEqual(again, f) {
+			t.Errorf("Parse(String()) = %#v, %v, want %#v", again, err, f)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	a := PrefixFilter{Prefix: []byte("a")}
+	b := PrefixFilter{Prefix: []byte("b")}
+	c := PrefixFilter{Prefix: []byte("c")}
+	for _, tc := range []struct {
+		s string
+		// 解析结果
+		want Filter
+		// 重新生成的过滤器字符串,优先级相同时省略不必要的括号
+		canonical string
+	}{
+		{"PrefixFilter('a') AND PrefixFilter('b') OR PrefixFilter('c')", Or(And(a, b), c), "(PrefixFilter('a') AND PrefixFilter('b')) OR PrefixFilter('c')"},
+		{"PrefixFilter('a') OR PrefixFilter('b') AND PrefixFilter('c')", Or(a, And(b, c)), "PrefixFilter('a') OR (PrefixFilter('b') AND PrefixFilter('c'))"},
+		{"(PrefixFilter('a') OR PrefixFilter('b')) AND PrefixFilter('c')", And(Or(a, b), c), "(PrefixFilter('a') OR PrefixFilter('b')) AND PrefixFilter('c')"},
+		{"SKIP PrefixFilter('a') AND PrefixFilter('b')", And(Skip(a), b), "SKIP PrefixFilter('a') AND PrefixFilter('b')"},
+		{"SKIP (PrefixFilter('a') AND PrefixFilter('b'))", Skip(And(a, b)), "SKIP (PrefixFilter('a') AND PrefixFilter('b'))"},
+		{"WHILE PrefixFilter('a') OR SKIP PrefixFilter('b')", Or(While(a), Skip(b)), "WHILE PrefixFilter('a') OR SKIP PrefixFilter('b')"},
+		{"SKIP WHILE PrefixFilter('a')", Skip(While(a)), "SKIP WHILE PrefixFilter('a')"},
+		{"((PrefixFilter('a')))", a, "PrefixFilter('a')"},
+		{"PrefixFilter('a') AND (PrefixFilter('b') AND PrefixFilter('c'))", And(a, And(b, c)), "PrefixFilter('a') AND (PrefixFilter('b') AND PrefixFilter('c'))"},
+	} {
+		f, err := Parse(tc.s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(f, tc.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tc.s, f, tc.want)
+			continue
+		}
+		if s := f.String(); s != tc.canonical {
+			t.Errorf("String() = %q, want %q", s, tc.canonical)
+		}
+		if again, err := Parse(tc.canonical); err != nil || !reflect.DeepEqual(again, f) {
+			t.Errorf("Parse(%q) = %#v, %v, want %#v", tc.canonical, again, err, f)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want error
+	}{
+		{"", ErrSyntax},
+		{"PrefixFilter('a'", ErrSyntax},
+		{"PrefixFilter('a) ", ErrSyntax},
+		{"PrefixFilter('a') AND", ErrSyntax},
+		{"PrefixFilter('a') PrefixFilter('b')", ErrSyntax},
+		{"()", ErrSyntax},
+		{"RowFilter(=<, 'binary:a')", ErrSyntax},
+		{"PrefixFilter()", ErrInvalidArguments},
+		{"PrefixFilter(1)", ErrInvalidArguments},
+		{"PageFilter('a')", ErrInvalidArguments},
+		{"RowFilter(=, 'unknown:a')", ErrInvalidArguments},
+		{"RowFilter('binary:a', =)", ErrInvalidArguments},
+		{"ColumnRangeFilter('a', yes, 'c', false)", ErrInvalidArguments},
+	} {
+		if _, err := Parse(tc.s); !errors.Is(err, tc.want) {
+			t.Errorf("Parse(%q) err = %v, want %v", tc.s, err, tc.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	a := PrefixFilter{Prefix: []byte("a")}
+	for _, tc := range []struct {
+		name string
+		f    Filter
+		want error
+	}{
+		{"nil", nil, ErrNilFilter},
+		{"empty and", And(), ErrEmptyFilterList},
+		{"empty or", Or(), ErrEmptyFilterList},
+		{"nested empty", Or(a, And()), ErrEmptyFilterList},
+		{"skip empty", Skip(And()), ErrEmptyFilterList},
+		{"while nil", While(nil), ErrNilFilter},
+		{"nil in list", And(a, nil), ErrNilFilter},
+		{"invalid list op", FilterList{Op: "XOR", Filters: []Filter{a}}, ErrInvalidArguments},
+		{"invalid compare op", RowFilter{Op: "==", Comparator: Binary([]byte("a"))}, ErrInvalidArguments},
+		{"missing compare op", SingleColumnValueFilter{Family: []byte("cf"), Qualifier: []byte("q"), Comparator: Binary([]byte("a"))}, ErrInvalidArguments},
+		{"regex less", RowFilter{Op: Less, Comparator: Regex("^a")}, ErrInvalidArguments},
+		{"substring greater", SingleColumnValueFilter{Family: []byte("cf"), Qualifier: []byte("q"), Op: Greater, Comparator: Substring("a")}, ErrInvalidArguments},
+		{"nested regex", And(a, ValueFilter{Op: GreaterOrEqual, Comparator: Regex("a")}), ErrInvalidArguments},
+		{"empty generic name", GenericFilter{Args: []string{"'a'"}}, ErrInvalidArguments},
+		{"regex not equal", QualifierFilter{Op: NotEqual, Comparator: Regex("^a")}, nil},
+		{"substring equal", FamilyFilter{Op: Equal, Comparator: Substring("a")}, nil},
+		{"generic", GenericFilter{Name: "ColumnCountGetFilter", Args: []string{"2"}}, nil},
+		{"zero comparator", RowFilter{Op: Equal}, ErrInvalidArguments},
+		{"unknown comparator", ValueFilter{Op: Equal, Comparator: Comparator{Type: "long", Value: []byte("1")}}, ErrInvalidArguments},
+		{"negative page size", PageFilter{PageSize: -1}, ErrInvalidArguments},
+		{"zero page size", PageFilter{}, nil},
+		{"empty timestamps", TimestampsFilter{}, ErrInvalidArguments},
+		{"timestamps", TimestampsFilter{Timestamps: []int64{1, 2}}, nil},
+		{"binary prefix", QualifierFilter{Op: Less, Comparator: BinaryPrefix([]byte("a"))}, nil},
+		{"valid", Or(a, Skip(And(a, KeyOnlyFilter{}))), nil},
+	} {
+		err := Validate(tc.f)
+		if tc.want == nil && err != nil || !errors.Is(err, tc.want) {
+			t.Errorf("%s: Validate() = %v, want %v", tc.name, err, tc.want)
+		}
+		if _, err := Build(tc.f); !errors.Is(err, tc.want) {
+			t.Errorf("%s: Build() = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// 过滤器字符串的解析
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenString
+	tokenOp
+	tokenWord
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	// 原文
+	raw string
+	// 字符串字面量去掉引号和转义后的值
+	value []byte
+	pos   int
+}
+
+// lex 将过滤器字符串切分为词法单元
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, raw: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, raw: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, raw: ",", pos: i})
+			i++
+		case c == '\'':
+			start := i
+			var value []byte
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("%w: 位置%d的字符串没有结束", ErrSyntax, start)
+				}
+				if s[i] == '\'' {
+					// 两个单引号表示一个单引号
+					if i+1 < len(s) && s[i+1] == '\'' {
+						value = append(value, '\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				value = append(value, s[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, raw: s[start:i], value: value, pos: start})
+		case c == '<' || c == '>' || c == '=' || c == '!':
+			start := i
+			i++
+			if i < len(s) && s[i] == '=' {
+				i++
+			}
+			op := CompareOp(s[start:i])
+			if !op.valid() {
+				return nil, fmt.Errorf("%w: 位置%d的比较运算符%q不合法", ErrSyntax, start, op)
+			}
+			tokens = append(tokens, token{kind: tokenOp, raw: s[start:i], pos: start})
+		default:
+			start := i
+			for i < len(s) && isWordChar(s[i]) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("%w: 位置%d的字符%q不合法", ErrSyntax, i, c)
+			}
+			tokens = append(tokens, token{kind: tokenWord, raw: s[start:i], pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(s)}), nil
+}
+
+// isWordChar 判断是否为过滤器名,数字或关键字中的字符
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// parser 递归下降解析器
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// expect 读取指定类型的词法单元
+func (p *parser) expect(kind tokenKind, desc string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, fmt.Errorf("%w: 位置%d需要%s", ErrSyntax, t.pos, desc)
+	}
+	return t, nil
+}
+
+// isKeyword 判断下一个词法单元是否为关键字
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && t.raw == keyword
+}
+
+// Parse 将过滤器字符串解析为过滤器
+// 本包没有对应类型的过滤器解析为GenericFilter,连续的同一种组合运算合并为一个FilterList
+func Parse(s string) (Filter, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: 位置%d有多余的内容%q", ErrSyntax, t.pos, t.raw)
+	}
+	return f, nil
+}
+
+// parseOr 解析OR连接的表达式
+func (p *parser) parseOr() (Filter, error) {
+	return p.parseList(OpOr, p.parseAnd)
+}
+
+// parseAnd 解析AND连接的表达式
+func (p *parser) parseAnd() (Filter, error) {
+	return p.parseList(OpAnd, p.parseUnary)
+}
+
+// parseList 解析以op连接的操作数
+func (p *parser) parseList(op ListOp, operand func() (Filter, error)) (Filter, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword(string(op)) {
+		return first, nil
+	}
+	list := FilterList{Op: op, Filters: []Filter{first}}
+	for p.isKeyword(string(op)) {
+		p.next()
+		f, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		list.Filters = append(list.Filters, f)
+	}
+	return list, nil
+}
+
+// parseUnary 解析SKIP/WHILE
+func (p *parser) parseUnary() (Filter, error) {
+	switch {
+	case p.isKeyword("SKIP"):
+		p.next()
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Skip(f), nil
+	case p.isKeyword("WHILE"):
+		p.next()
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return While(f), nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary 解析括号中的表达式或单个过滤器
+func (p *parser) parsePrimary() (Filter, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLParen:
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	case tokenWord:
+		if _, err := p.expect(tokenLParen, "'('"); err != nil {
+			return nil, err
+		}
+		var args []token
+		if p.peek().kind != tokenRParen {
+			for {
+				arg := p.next()
+				if arg.kind != tokenString && arg.kind != tokenOp && arg.kind != tokenWord {
+					return nil, fmt.Errorf("%w: 位置%d需要参数", ErrSyntax, arg.pos)
+				}
+				args = append(args, arg)
+				if p.peek().kind != tokenComma {
+					break
+				}
+				p.next()
+			}
+		}
+		if _, err := p.expect(tokenRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return newFilter(t.raw, args)
+	}
+	return nil, fmt.Errorf("%w: 位置%d需要过滤器", ErrSyntax, t.pos)
+}
+
+// args 按位置读取过滤器参数
+type args struct {
+	name   string
+	tokens []token
+	err    error
+}
+
+func (a *args) fail(i int, desc string) {
+	if a.err == nil {
+		a.err = fmt.Errorf("%w: %s的第%d个参数需要%s", ErrInvalidArguments, a.name, i+1, desc)
+	}
+}
+
+func (a *args) bytes(i int) []byte {
+	if a.tokens[i].kind != tokenString {
+		a.fail(i, "字符串")
+		return nil
+	}
+	return a.tokens[i].value
+}
+
+func (a *args) int64(i int) int64 {
+	v, err := strconv.ParseInt(a.tokens[i].raw, 10, 64)
+	if a.tokens[i].kind != tokenWord || err != nil {
+		a.fail(i, "整数")
+	}
+	return v
+}
+
+func (a *args) bool(i int) bool {
+	v, err := strconv.ParseBool(strings.ToLower(a.tokens[i].raw))
+	if a.tokens[i].kind != tokenWord || err != nil {
+		a.fail(i, "true或false")
+	}
+	return v
+}
+
+func (a *args) op(i int) CompareOp {
+	if a.tokens[i].kind != tokenOp {
+		a.fail(i, "比较运算符")
+	}
+	return CompareOp(a.tokens[i].raw)
+}
+
+func (a *args) comparator(i int) Comparator {
+	t, value, found := strings.Cut(string(a.bytes(i)), ":")
+	c := Comparator{Type: ComparatorType(strings.ToLower(t)), Value: []byte(value)}
+	switch c.Type {
+	case ComparatorBinary, ComparatorBinaryPrefix, ComparatorRegex, ComparatorSubstring:
+		if found {
+			return c
+		}
+	}
+	a.fail(i, "binary,binaryprefix,regexstring或substring比较器")
+	return c
+}
+
+// newFilter 根据过滤器名和参数构造过滤器
+func newFilter(name string, tokens []token) (Filter, error) {
+	a := &args{name: name, tokens: tokens}
+	count := func(n ...int) error {
+		for _, c := range n {
+			if len(tokens) == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s的参数个数为%d", ErrInvalidArguments, name, len(tokens))
+	}
+	var f Filter
+	switch name {
+	case "KeyOnlyFilter":
+		if err := count(0); err != nil {
+			return nil, err
+		}
+		f = KeyOnlyFilter{}
+	case "FirstKeyOnlyFilter":
+		if err := count(0); err != nil {
+			return nil, err
+		}
+		f = FirstKeyOnlyFilter{}
+	case "PrefixFilter":
+		if err := count(1); err != nil {
+			return nil, err
+		}
+		f = PrefixFilter{Prefix: a.bytes(0)}
+	case "ColumnPrefixFilter":
+		if err := count(1); err != nil {
+			return nil, err
+		}
+		f = ColumnPrefixFilter{Prefix: a.bytes(0)}
+	case "ColumnRangeFilter":
+		if err := count(4); err != nil {
+			return nil, err
+		}
+		f = ColumnRangeFilter{MinColumn: a.bytes(0), MinInclusive: a.bool(1), MaxColumn: a.bytes(2), MaxInclusive: a.bool(3)}
+	case "PageFilter":
+		if err := count(1); err != nil {
+			return nil, err
+		}
+		f = PageFilter{PageSize: a.int64(0)}
+	case "TimestampsFilter":
+		timestamps := make([]int64, len(tokens))
+		for i := range tokens {
+			timestamps[i] = a.int64(i)
+		}
+		f = TimestampsFilter{Timestamps: timestamps}
+	case "RowFilter", "FamilyFilter", "QualifierFilter", "ValueFilter":
+		if err := count(2); err != nil {
+			return nil, err
+		}
+		op, c := a.op(0), a.comparator(1)
+		switch name {
+		case "RowFilter":
+			f = RowFilter{Op: op, Comparator: c}
+		case "FamilyFilter":
+			f = FamilyFilter{Op: op, Comparator: c}
+		case "QualifierFilter":
+			f = QualifierFilter{Op: op, Comparator: c}
+		default:
+			f = ValueFilter{Op: op, Comparator: c}
+		}
+	case "SingleColumnValueFilter":
+		if err := count(4, 6); err != nil {
+			return nil, err
+		}
+		scvf := SingleColumnValueFilter{Family: a.bytes(0), Qualifier: a.bytes(1), Op: a.op(2), Comparator: a.comparator(3)}
+		if len(tokens) == 6 {
+			scvf.FilterIfMissing = a.bool(4)
+			scvf.AllVersions = !a.bool(5)
+		}
+		f = scvf
+	default:
+		raw := make([]string, len(tokens))
+		for i, t := range tokens {
+			raw[i] = t.raw
+		}
+		f = GenericFilter{Name: name, Args: raw}
+	}
+	if a.err != nil {
+		return nil, a.err
+	}
+	return f, nil
+}
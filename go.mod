@@ -1,8 +1,10 @@
 module github.com/Golang-Tools/aliexhbase
 
-go 1.16
+go 1.20
 
 require (
 	github.com/apache/thrift v0.13.0
 	github.com/sirupsen/logrus v1.8.1
 )
+
+require golang.org/x/sys v0.0.0-20191026070338-33540a1f6037 // indirect
@@ -0,0 +1,174 @@
+// 字段值的编码
+package mapper
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/codec"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(codec.Decimal{})
+)
+
+// encodeValue 按HBase Java客户端`Bytes.toBytes`的方式编码值
+func encodeValue(v reflect.Value) ([]byte, error) {
+	switch v.Type() {
+	case timeType:
+		return codec.EncodeTime(v.Interface().(time.Time)), nil
+	case decimalType:
+		return codec.EncodeDecimal(v.Interface().(codec.Decimal)), nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.String:
+		return codec.EncodeString(v.String()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Bytes(), nil
+		}
+	case reflect.Bool:
+		return codec.EncodeBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeInt(v.Int(), intSize(v.Type())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// 无符号数按位宽当作有符号数编码,与Java中同宽度的类型字节一致
+		return encodeInt(int64(v.Uint()), intSize(v.Type())), nil
+	case reflect.Float32:
+		return codec.EncodeFloat32(float32(v.Float())), nil
+	case reflect.Float64:
+		return codec.EncodeFloat64(v.Float()), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// decodeValue 按HBase Java客户端`Bytes.toXXX`的方式解码值
+func decodeValue(v reflect.Value, b []byte) error {
+	switch v.Type() {
+	case timeType:
+		t, err := codec.DecodeTime(b)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case decimalType:
+		d, err := codec.DecodeDecimal(b)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		v.Set(reflect.ValueOf(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), b)
+	case reflect.String:
+		v.SetString(codec.DecodeString(b))
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+	case reflect.Bool:
+		x, err := codec.DecodeBool(b)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		v.SetBool(x)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := decodeInt(b, intSize(v.Type()))
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := decodeInt(b, intSize(v.Type()))
+		if err != nil {
+			return err
+		}
+		// 去掉符号扩展
+		size := intSize(v.Type())
+		v.SetUint(uint64(x) & (math.MaxUint64 >> (64 - 8*uint(size))))
+		return nil
+	case reflect.Float32:
+		x, err := codec.DecodeFloat32(b)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		v.SetFloat(float64(x))
+		return nil
+	case reflect.Float64:
+		x, err := codec.DecodeFloat64(b)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidValue, err)
+		}
+		v.SetFloat(x)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// intSize 整数类型编码后的字节数,int和uint与Java的long一致为8字节
+func intSize(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Int, reflect.Uint:
+		return 8
+	}
+	return int(t.Size())
+}
+
+// encodeInt 将整数编码为size字节
+func encodeInt(x int64, size int) []byte {
+	switch size {
+	case 1:
+		return codec.EncodeInt8(int8(x))
+	case 2:
+		return codec.EncodeInt16(int16(x))
+	case 4:
+		return codec.EncodeInt32(int32(x))
+	}
+	return codec.EncodeInt64(x)
+}
+
+// decodeInt 解码size字节的整数,结果按位宽做符号扩展
+func decodeInt(b []byte, size int) (int64, error) {
+	var (
+		x   int64
+		err error
+	)
+	switch size {
+	case 1:
+		var v int8
+		v, err = codec.DecodeInt8(b)
+		x = int64(v)
+	case 2:
+		var v int16
+		v, err = codec.DecodeInt16(b)
+		x = int64(v)
+	case 4:
+		var v int32
+		v, err = codec.DecodeInt32(b)
+		x = int64(v)
+	default:
+		x, err = codec.DecodeInt64(b)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidValue, err)
+	}
+	return x, nil
+}
@@ -0,0 +1,377 @@
+// hbase行与go结构体之间的映射
+// 通过结构体字段的`hbase`标签声明映射关系:
+//
+//	type User struct {
+//		ID      string            `hbase:",rowkey"`
+//		Name    string            `hbase:"info:name"`
+//		Age     int32             `hbase:"info:age,omitempty"`
+//		Profile *Profile          `hbase:"info:profile,json"`
+//		Tags    map[string][]byte `hbase:"tags"`
+//		Ignored string            `hbase:"-"`
+//	}
+//
+// 标签形式为`family:qualifier`,`,rowkey`表示行键,只有列族的`family`(或`family:*`)用于map[string][]byte类型的字段,
+// 收集该列族中没有映射到其他字段的所有列.可选项omitempty表示零值不写入,json表示使用json编码.
+// 数值和时间的编码与HBase Java客户端的`Bytes.toBytes`一致:整数和浮点数为大端序定长,bool为0xFF/0x00,时间为毫秒时间戳
+package mapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// 错误类型
+var (
+	//ErrNotStructPointer 参数不是结构体指针
+	ErrNotStructPointer = errors.New("参数必须是非nil的结构体指针")
+	//ErrNotStruct 参数不是结构体或结构体指针
+	ErrNotStruct = errors.New("参数必须是结构体或结构体指针")
+	//ErrNoRowKey 结构体没有声明行键字段
+	ErrNoRowKey = errors.New("结构体没有声明`hbase:\",rowkey\"`字段")
+	//ErrEmptyRowKey 行键为空
+	ErrEmptyRowKey = errors.New("行键为空")
+	//ErrUnsupportedType 字段类型不支持
+	ErrUnsupportedType = errors.New("字段类型不支持")
+	//ErrInvalidValue 单元格的值无法解码为字段类型
+	ErrInvalidValue = errors.New("单元格的值无法解码为字段类型")
+	//ErrInvalidTag 标签格式错误
+	ErrInvalidTag = errors.New("hbase标签格式错误")
+)
+
+// tagName 结构体标签名
+const tagName = "hbase"
+
+// field 结构体字段的映射信息
+type field struct {
+	name      string
+	index     []int
+	family    []byte
+	qualifier []byte
+	omitEmpty bool
+	json      bool
+}
+
+// structInfo 结构体的映射信息
+type structInfo struct {
+	rowKey *field
+	// 普通列,key为`family:qualifier`
+	columns  []*field
+	byColumn map[string]*field
+	// 收集整个列族的map字段,key为列族
+	families map[string]*field
+	// 按声明顺序排列的列族字段
+	familyFields []*field
+}
+
+var structCache sync.Map
+
+var bytesMapType = reflect.TypeOf(map[string][]byte(nil))
+
+// getStructInfo 解析结构体的映射信息,结果会被缓存
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if info, ok := structCache.Load(t); ok {
+		return info.(*structInfo), nil
+	}
+	info := &structInfo{byColumn: map[string]*field{}, families: map[string]*field{}}
+	if err := info.parse(t, nil); err != nil {
+		return nil, err
+	}
+	structCache.Store(t, info)
+	return info, nil
+}
+
+// parse 解析结构体字段,匿名嵌入的结构体字段会被展开
+func (info *structInfo) parse(t reflect.Type, index []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(tagName)
+		idx := append(append([]int{}, index...), i)
+		if !ok {
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				if err := info.parse(sf.Type, idx); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+		f, err := parseTag(sf, tag)
+		if err != nil {
+			return err
+		}
+		f.index = idx
+		switch {
+		case f.family == nil:
+			if info.rowKey != nil {
+				return fmt.Errorf("%w: 字段%s和%s都声明为行键", ErrInvalidTag, info.rowKey.name, f.name)
+			}
+			info.rowKey = f
+		case f.qualifier == nil:
+			if sf.Type != bytesMapType {
+				return fmt.Errorf("%w: 字段%s只声明了列族,类型必须为map[string][]byte", ErrInvalidTag, f.name)
+			}
+			if other, ok := info.families[string(f.family)]; ok {
+				return fmt.Errorf("%w: 字段%s和%s映射到同一列族%s", ErrInvalidTag, other.name, f.name, f.family)
+			}
+			info.families[string(f.family)] = f
+			info.familyFields = append(info.familyFields, f)
+		default:
+			key := string(f.family) + ":" + string(f.qualifier)
+			if other, ok := info.byColumn[key]; ok {
+				return fmt.Errorf("%w: 字段%s和%s映射到同一列%s", ErrInvalidTag, other.name, f.name, key)
+			}
+			info.byColumn[key] = f
+			info.columns = append(info.columns, f)
+		}
+	}
+	return nil
+}
+
+// parseTag 解析字段标签
+func parseTag(sf reflect.StructField, tag string) (*field, error) {
+	parts := strings.Split(tag, ",")
+	f := &field{name: sf.Name}
+	rowKey := false
+	for _, opt := range parts[1:] {
+		switch strings.TrimSpace(opt) {
+		case "rowkey":
+			rowKey = true
+		case "omitempty":
+			f.omitEmpty = true
+		case "json":
+			f.json = true
+		case "":
+		default:
+			return nil, fmt.Errorf("%w: 字段%s的选项%q未知", ErrInvalidTag, sf.Name, opt)
+		}
+	}
+	column := strings.TrimSpace(parts[0])
+	if rowKey {
+		if column != "" {
+			return nil, fmt.Errorf("%w: 行键字段%s不能声明列", ErrInvalidTag, sf.Name)
+		}
+		return f, nil
+	}
+	if column == "" {
+		return nil, fmt.Errorf("%w: 字段%s没有声明列", ErrInvalidTag, sf.Name)
+	}
+	i := strings.IndexByte(column, ':')
+	if i < 0 {
+		f.family = []byte(column)
+		return f, nil
+	}
+	f.family = []byte(column[:i])
+	if q := column[i+1:]; q != "" && q != "*" {
+		f.qualifier = []byte(q)
+	}
+	if len(f.family) == 0 {
+		return nil, fmt.Errorf("%w: 字段%s的列族为空", ErrInvalidTag, sf.Name)
+	}
+	return f, nil
+}
+
+// structValue 获取结构体的reflect.Value,v可以是结构体或结构体指针
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, ErrNotStruct
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, ErrNotStruct
+	}
+	return rv, nil
+}
+
+// RowKey 获取结构体的行键
+func RowKey(v interface{}) ([]byte, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	if info.rowKey == nil {
+		return nil, ErrNoRowKey
+	}
+	row, err := encodeField(info.rowKey, rv.FieldByIndex(info.rowKey.index))
+	if err != nil {
+		return nil, err
+	}
+	if len(row) == 0 {
+		return nil, ErrEmptyRowKey
+	}
+	return row, nil
+}
+
+// Marshal 将结构体编码为TPut,v可以是结构体或结构体指针
+// nil指针,nil切片和nil map字段不会写入,声明了omitempty的字段为零值时不会写入
+func Marshal(v interface{}) (*hbase.TPut, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+	row, err := RowKey(v)
+	if err != nil {
+		return nil, err
+	}
+	tput := &hbase.TPut{Row: row}
+	for _, f := range info.columns {
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if isNil(fv) {
+			continue
+		}
+		value, err := encodeField(f, fv)
+		if err != nil {
+			return nil, err
+		}
+		tput.ColumnValues = append(tput.ColumnValues, &hbase.TColumnValue{Family: f.family, Qualifier: f.qualifier, Value: value})
+	}
+	for _, f := range info.familyFields {
+		values := rv.FieldByIndex(f.index).Interface().(map[string][]byte)
+		qualifiers := make([]string, 0, len(values))
+		for q := range values {
+			// 已经映射到其他字段的列以字段为准
+			if _, ok := info.byColumn[string(f.family)+":"+q]; ok {
+				continue
+			}
+			qualifiers = append(qualifiers, q)
+		}
+		sort.Strings(qualifiers)
+		for _, q := range qualifiers {
+			value := values[q]
+			if f.omitEmpty && len(value) == 0 {
+				continue
+			}
+			tput.ColumnValues = append(tput.ColumnValues, &hbase.TColumnValue{Family: f.family, Qualifier: []byte(q), Value: value})
+		}
+	}
+	return tput, nil
+}
+
+// Unmarshal 将TResult_解码到结构体指针v中,没有对应字段的列会被忽略,结果中不存在的列对应的字段保持不变
+func Unmarshal(result *hbase.TResult_, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrNotStructPointer
+	}
+	rv = rv.Elem()
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return err
+	}
+	if info.rowKey != nil && result.Row != nil {
+		if err := decodeField(info.rowKey, rv.FieldByIndex(info.rowKey.index), result.Row); err != nil {
+			return err
+		}
+	}
+	for _, cv := range result.ColumnValues {
+		if f, ok := info.byColumn[string(cv.Family)+":"+string(cv.Qualifier)]; ok {
+			if err := decodeField(f, rv.FieldByIndex(f.index), cv.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		if f, ok := info.families[string(cv.Family)]; ok {
+			fv := rv.FieldByIndex(f.index)
+			if fv.IsNil() {
+				fv.Set(reflect.MakeMap(bytesMapType))
+			}
+			fv.SetMapIndex(reflect.ValueOf(string(cv.Qualifier)), reflect.ValueOf(cv.Value))
+		}
+	}
+	return nil
+}
+
+// Columns 获取结构体映射的列,用于只读取需要的列;映射了整个列族的字段只返回列族
+func Columns(v interface{}) ([]*hbase.TColumn, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return columnsOf(rv.Type())
+}
+
+// ColumnsOf 获取结构体类型映射的列,t可以是结构体或结构体指针类型
+func ColumnsOf(t reflect.Type) ([]*hbase.TColumn, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, ErrNotStruct
+	}
+	return columnsOf(t)
+}
+
+func columnsOf(t reflect.Type) ([]*hbase.TColumn, error) {
+	info, err := getStructInfo(t)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]*hbase.TColumn, 0, len(info.columns)+len(info.families))
+	for _, f := range info.familyFields {
+		columns = append(columns, &hbase.TColumn{Family: f.family})
+	}
+	for _, f := range info.columns {
+		if _, ok := info.families[string(f.family)]; ok {
+			continue
+		}
+		columns = append(columns, &hbase.TColumn{Family: f.family, Qualifier: f.qualifier})
+	}
+	return columns, nil
+}
+
+// isNil 判断可以为nil的字段是否为nil
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// encodeField 编码字段的值
+func encodeField(f *field, v reflect.Value) ([]byte, error) {
+	if f.json {
+		return json.Marshal(v.Interface())
+	}
+	b, err := encodeValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("字段%s: %w", f.name, err)
+	}
+	return b, nil
+}
+
+// decodeField 解码单元格的值到字段
+func decodeField(f *field, v reflect.Value, b []byte) error {
+	if f.json {
+		if err := json.Unmarshal(b, v.Addr().Interface()); err != nil {
+			return fmt.Errorf("字段%s: %w", f.name, err)
+		}
+		return nil
+	}
+	if err := decodeValue(v, b); err != nil {
+		return fmt.Errorf("字段%s: %w", f.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,458 @@
+package mapper
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/codec"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+type testProfile struct {
+	City string `json:"city"`
+}
+
+type testUser struct {
+	ID      string            `hbase:",rowkey"`
+	Name    string            `hbase:"info:name"`
+	Age     int32             `hbase:"info:age,omitempty"`
+	Nick    *string           `hbase:"info:nick"`
+	Profile *testProfile      `hbase:"info:profile,json"`
+	Created time.Time         `hbase:"info:created"`
+	Tags    map[string][]byte `hbase:"tags"`
+	Ignored string            `hbase:"-"`
+	hidden  string            `hbase:"info:hidden"`
+}
+
+// cellString 将TColumnValue格式化为`family:qualifier=hex`
+func cellString(cv *hbase.TColumnValue) string {
+	return string(cv.Family) + ":" + string(cv.Qualifier) + "=" + hex.EncodeToString(cv.Value)
+}
+
+func cellStrings(tput *hbase.TPut) []string {
+	cells := []string{}
+	for _, cv := range tput.ColumnValues {
+		cells = append(cells, cellString(cv))
+	}
+	return cells
+}
+
+func hexString(s string) string {
+	return hex.EncodeToString([]byte(s))
+}
+
+func TestMarshal(t *testing.T) {
+	nick := "bob"
+	u := testUser{
+		ID:      "u1",
+		Name:    "Bob",
+		Age:     30,
+		Nick:    &nick,
+		Profile: &testProfile{City: "hz"},
+		Created: time.UnixMilli(1000),
+		Tags:    map[string][]byte{"b": []byte("2"), "a": []byte("1")},
+		Ignored: "ignored",
+		hidden:  "hidden",
+	}
+	for _, v := range []interface{}{u, &u} {
+		tput, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(tput.Row) != "u1" {
+			t.Fatalf("行键为%q,期望u1", tput.Row)
+		}
+		// 普通列按声明顺序,列族map中的列按列名排序
+		want := []string{
+			"info:name=" + hexString("Bob"),
+			"info:age=0000001e",
+			"info:nick=" + hexString("bob"),
+			"info:profile=" + hexString(`{"city":"hz"}`),
+			"info:created=00000000000003e8",
+			"tags:a=" + hexString("1"),
+			"tags:b=" + hexString("2"),
+		}
+		if got := cellStrings(tput); !reflect.DeepEqual(got, want) {
+			t.Fatalf("编码结果为%v,期望%v", got, want)
+		}
+	}
+}
+
+func TestMarshalOmitEmptyAndNil(t *testing.T) {
+	tput, err := Marshal(&testUser{ID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// omitempty的零值,nil指针和nil map不写入;没有omitempty的零值照常写入
+	want := []string{
+		"info:name=",
+		"info:created=" + hex.EncodeToString(codec.EncodeTime(time.Time{})),
+	}
+	if got := cellStrings(tput); !reflect.DeepEqual(got, want) {
+		t.Fatalf("编码结果为%v,期望%v", got, want)
+	}
+
+	type omitFamily struct {
+		ID    []byte            `hbase:",rowkey"`
+		Attrs map[string][]byte `hbase:"a:*,omitempty"`
+	}
+	tput, err = Marshal(omitFamily{ID: []byte{0}, Attrs: map[string][]byte{"empty": {}, "x": []byte("1")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cellStrings(tput), []string{"a:x=" + hexString("1")}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("编码结果为%v,期望%v", got, want)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	result := &hbase.TResult_{
+		Row: []byte("u1"),
+		ColumnValues: []*hbase.TColumnValue{
+			{Family: []byte("info"), Qualifier: []byte("name"), Value: []byte("Bob")},
+			{Family: []byte("info"), Qualifier: []byte("age"), Value: []byte{0, 0, 0, 30}},
+			{Family: []byte("info"), Qualifier: []byte("nick"), Value: []byte("bob")},
+			{Family: []byte("info"), Qualifier: []byte("profile"), Value: []byte(`{"city":"hz"}`)},
+			{Family: []byte("info"), Qualifier: []byte("created"), Value: []byte{0, 0, 0, 0, 0, 0, 3, 0xe8}},
+			{Family: []byte("info"), Qualifier: []byte("unknown"), Value: []byte("x")},
+			{Family: []byte("tags"), Qualifier: []byte("a"), Value: []byte("1")},
+			{Family: []byte("other"), Qualifier: []byte("a"), Value: []byte("x")},
+		},
+	}
+	u := testUser{Ignored: "kept"}
+	if err := Unmarshal(result, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != "u1" || u.Name != "Bob" || u.Age != 30 || u.Ignored != "kept" || u.hidden != "" {
+		t.Fatalf("解码结果为%+v", u)
+	}
+	if u.Nick == nil || *u.Nick != "bob" {
+		t.Fatalf("nil指针字段没有被分配: %v", u.Nick)
+	}
+	if u.Profile == nil || u.Profile.City != "hz" {
+		t.Fatalf("json字段解码结果为%+v", u.Profile)
+	}
+	if !u.Created.Equal(time.UnixMilli(1000)) {
+		t.Fatalf("时间字段为%v,期望%v", u.Created, time.UnixMilli(1000))
+	}
+	if !reflect.DeepEqual(u.Tags, map[string][]byte{"a": []byte("1")}) {
+		t.Fatalf("列族字段为%v", u.Tags)
+	}
+
+	// 结果中不存在的列对应的字段保持不变,nil指针保持nil
+	u = testUser{Name: "old"}
+	if err := Unmarshal(&hbase.TResult_{Row: []byte("u2")}, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.ID != "u2" || u.Name != "old" || u.Nick != nil || u.Profile != nil || u.Tags != nil {
+		t.Fatalf("解码结果为%+v", u)
+	}
+}
+
+func TestFamilyCatchAll(t *testing.T) {
+	type wide struct {
+		ID    []byte            `hbase:",rowkey"`
+		Name  string            `hbase:"info:name"`
+		Info  map[string][]byte `hbase:"info:*"`
+		Other map[string][]byte `hbase:"other"`
+	}
+	// 已经映射到其他字段的列以字段为准
+	tput, err := Marshal(wide{ID: []byte("r"), Name: "field", Info: map[string][]byte{"name": []byte("map"), "extra": []byte("e")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"info:name=" + hexString("field"), "info:extra=" + hexString("e")}
+	if got := cellStrings(tput); !reflect.DeepEqual(got, want) {
+		t.Fatalf("编码结果为%v,期望%v", got, want)
+	}
+
+	var w wide
+	err = Unmarshal(&hbase.TResult_{Row: []byte("r"), ColumnValues: []*hbase.TColumnValue{
+		{Family: []byte("info"), Qualifier: []byte("name"), Value: []byte("n")},
+		{Family: []byte("info"), Qualifier: []byte("extra"), Value: []byte("e")},
+		{Family: []byte("other"), Qualifier: []byte(""), Value: []byte("o")},
+	}}, &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "n" || !reflect.DeepEqual(w.Info, map[string][]byte{"extra": []byte("e")}) || !reflect.DeepEqual(w.Other, map[string][]byte{"": []byte("o")}) {
+		t.Fatalf("解码结果为%+v", w)
+	}
+
+	// 只读取列族,不再单独读取该列族中的列
+	columns, err := Columns(&w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, c := range columns {
+		got = append(got, string(c.Family)+":"+string(c.Qualifier))
+	}
+	if want := []string{"info:", "other:"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("读取的列为%v,期望%v", got, want)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := ColumnsOf(reflect.TypeOf(&testUser{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, c := range columns {
+		got = append(got, string(c.Family)+":"+string(c.Qualifier))
+	}
+	want := []string{"tags:", "info:name", "info:age", "info:nick", "info:profile", "info:created"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("读取的列为%v,期望%v", got, want)
+	}
+	if _, err := ColumnsOf(reflect.TypeOf(1)); !errors.Is(err, ErrNotStruct) {
+		t.Fatalf("非结构体类型的错误为%v,期望ErrNotStruct", err)
+	}
+}
+
+func TestIntWidths(t *testing.T) {
+	type ints struct {
+		ID  int64   `hbase:",rowkey"`
+		I8  int8    `hbase:"n:i8"`
+		I16 int16   `hbase:"n:i16"`
+		I32 int32   `hbase:"n:i32"`
+		I64 int64   `hbase:"n:i64"`
+		I   int     `hbase:"n:i"`
+		U8  uint8   `hbase:"n:u8"`
+		U16 uint16  `hbase:"n:u16"`
+		U32 uint32  `hbase:"n:u32"`
+		U64 uint64  `hbase:"n:u64"`
+		U   uint    `hbase:"n:u"`
+		F32 float32 `hbase:"n:f32"`
+		F64 float64 `hbase:"n:f64"`
+		B   bool    `hbase:"n:b"`
+	}
+	v := ints{
+		ID:  1,
+		I8:  -1,
+		I16: -2,
+		I32: math.MinInt32,
+		I64: -3,
+		I:   256,
+		U8:  math.MaxUint8,
+		U16: 0x8001,
+		U32: math.MaxUint32,
+		U64: math.MaxUint64,
+		U:   1 << 63,
+		F32: 1.5,
+		F64: -2,
+		B:   true,
+	}
+	tput, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(tput.Row); got != "0000000000000001" {
+		t.Fatalf("行键为%s", got)
+	}
+	// 与Java中同宽度的byte/short/int/long一致,int和uint按long编码
+	want := []string{
+		"n:i8=ff",
+		"n:i16=fffe",
+		"n:i32=80000000",
+		"n:i64=fffffffffffffffd",
+		"n:i=0000000000000100",
+		"n:u8=ff",
+		"n:u16=8001",
+		"n:u32=ffffffff",
+		"n:u64=ffffffffffffffff",
+		"n:u=8000000000000000",
+		"n:f32=3fc00000",
+		"n:f64=c000000000000000",
+		"n:b=ff",
+	}
+	if got := cellStrings(tput); !reflect.DeepEqual(got, want) {
+		t.Fatalf("编码结果为%v,期望%v", got, want)
+	}
+
+	// 解码时有符号数做符号扩展,无符号数不做
+	var decoded ints
+	if err := Unmarshal(&hbase.TResult_{Row: tput.Row, ColumnValues: tput.ColumnValues}, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != v {
+		t.Fatalf("解码结果为%+v,期望%+v", decoded, v)
+	}
+
+	// 长度与字段位宽不一致
+	for _, cv := range []*hbase.TColumnValue{
+		{Family: []byte("n"), Qualifier: []byte("i32"), Value: []byte{0, 1}},
+		{Family: []byte("n"), Qualifier: []byte("u16"), Value: []byte{0, 0, 0, 1}},
+		{Family: []byte("n"), Qualifier: []byte("i"), Value: []byte{1, 2, 3, 4}},
+		{Family: []byte("n"), Qualifier: []byte("f64"), Value: []byte{1}},
+		{Family: []byte("n"), Qualifier: []byte("b"), Value: []byte{}},
+	} {
+		err := Unmarshal(&hbase.TResult_{ColumnValues: []*hbase.TColumnValue{cv}}, &decoded)
+		if !errors.Is(err, ErrInvalidValue) {
+			t.Fatalf("%s长度为%d时的错误为%v,期望ErrInvalidValue", cv.Qualifier, len(cv.Value), err)
+		}
+	}
+}
+
+func TestTimeFields(t *testing.T) {
+	type times struct {
+		ID      string     `hbase:",rowkey"`
+		At      time.Time  `hbase:"t:at"`
+		Updated *time.Time `hbase:"t:updated"`
+	}
+	at := time.Date(2020, 1, 2, 3, 4, 5, 678000000, time.UTC)
+	tput, err := Marshal(times{ID: "r", At: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 毫秒时间戳1577934245678,nil指针不写入
+	if got, want := cellStrings(tput), []string{"t:at=0000016f6435cf2e"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("编码结果为%v,期望%v", got, want)
+	}
+
+	var v times
+	tput.ColumnValues = append(tput.ColumnValues, &hbase.TColumnValue{Family: []byte("t"), Qualifier: []byte("updated"), Value: tput.ColumnValues[0].Value})
+	if err := Unmarshal(&hbase.TResult_{Row: tput.Row, ColumnValues: tput.ColumnValues}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.At.Equal(at) || v.Updated == nil || !v.Updated.Equal(at) {
+		t.Fatalf("解码结果为%+v,期望%v", v, at)
+	}
+
+	err = Unmarshal(&hbase.TResult_{ColumnValues: []*hbase.TColumnValue{{Family: []byte("t"), Qualifier: []byte("at"), Value: []byte{1, 2, 3}}}}, &v)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Fatalf("错误为%v,期望ErrInvalidValue", err)
+	}
+}
+
+func TestRowKey(t *testing.T) {
+	type noRowKey struct {
+		Name string `hbase:"info:name"`
+	}
+	type byteRowKey struct {
+		ID []byte `hbase:",rowkey"`
+	}
+	type embedded struct {
+		byteRowKey
+		Name string `hbase:"info:name"`
+	}
+	for _, tc := range []struct {
+		name string
+		v    interface{}
+		want string
+		err  error
+	}{
+		{"string", testUser{ID: "u1"}, "u1", nil},
+		{"pointer", &byteRowKey{ID: []byte{0, 1}}, "\x00\x01", nil},
+		{"embedded", embedded{byteRowKey: byteRowKey{ID: []byte("e")}}, "e", nil},
+		{"empty", testUser{}, "", ErrEmptyRowKey},
+		{"nil bytes", byteRowKey{}, "", ErrEmptyRowKey},
+		{"no rowkey", noRowKey{Name: "n"}, "", ErrNoRowKey},
+		{"nil pointer", (*testUser)(nil), "", ErrNotStruct},
+		{"not struct", "u1", "", ErrNotStruct},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			row, err := RowKey(tc.v)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("错误为%v,期望%v", err, tc.err)
+			}
+			if string(row) != tc.want {
+				t.Fatalf("行键为%q,期望%q", row, tc.want)
+			}
+			if _, err := Marshal(tc.v); !errors.Is(err, tc.err) {
+				t.Fatalf("Marshal的错误为%v,期望%v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestInvalidTags(t *testing.T) {
+	type duplicateColumn struct {
+		ID string `hbase:",rowkey"`
+		A  string `hbase:"info:name"`
+		B  string `hbase:"info:name"`
+	}
+	type duplicateRowKey struct {
+		A string `hbase:",rowkey"`
+		B string `hbase:",rowkey"`
+	}
+	type duplicateFamily struct {
+		ID string            `hbase:",rowkey"`
+		A  map[string][]byte `hbase:"info"`
+		B  map[string][]byte `hbase:"info:*"`
+	}
+	type familyNotMap struct {
+		ID string `hbase:",rowkey"`
+		A  string `hbase:"info"`
+	}
+	type unknownOption struct {
+		ID string `hbase:",rowkey"`
+		A  string `hbase:"info:a,omitzero"`
+	}
+	type noColumn struct {
+		ID string `hbase:",rowkey"`
+		A  string `hbase:",omitempty"`
+	}
+	type rowKeyWithColumn struct {
+		ID string `hbase:"info:id,rowkey"`
+	}
+	type emptyFamily struct {
+		ID string `hbase:",rowkey"`
+		A  string `hbase:":a"`
+	}
+	for _, v := range []interface{}{
+		duplicateColumn{}, duplicateRowKey{}, duplicateFamily{}, familyNotMap{},
+		unknownOption{}, noColumn{}, rowKeyWithColumn{}, emptyFamily{},
+	} {
+		name := reflect.TypeOf(v).Name()
+		t.Run(name, func(t *testing.T) {
+			if _, err := Marshal(v); !errors.Is(err, ErrInvalidTag) {
+				t.Fatalf("Marshal的错误为%v,期望ErrInvalidTag", err)
+			}
+			ptr := reflect.New(reflect.TypeOf(v)).Interface()
+			if err := Unmarshal(&hbase.TResult_{}, ptr); !errors.Is(err, ErrInvalidTag) {
+				t.Fatalf("Unmarshal的错误为%v,期望ErrInvalidTag", err)
+			}
+			if _, err := Columns(v); !errors.Is(err, ErrInvalidTag) {
+				t.Fatalf("Columns的错误为%v,期望ErrInvalidTag", err)
+			}
+		})
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	type unsupported struct {
+		ID   string `hbase:",rowkey"`
+		Nums []int  `hbase:"info:nums"`
+	}
+	if _, err := Marshal(unsupported{ID: "r", Nums: []int{1}}); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("Marshal的错误为%v,期望ErrUnsupportedType", err)
+	}
+	var v unsupported
+	err := Unmarshal(&hbase.TResult_{ColumnValues: []*hbase.TColumnValue{{Family: []byte("info"), Qualifier: []byte("nums"), Value: []byte{1}}}}, &v)
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("Unmarshal的错误为%v,期望ErrUnsupportedType", err)
+	}
+}
+
+func TestUnmarshalNotStructPointer(t *testing.T) {
+	var nilUser *testUser
+	for _, v := range []interface{}{testUser{}, nilUser, new(int), nil} {
+		if err := Unmarshal(&hbase.TResult_{}, v); !errors.Is(err, ErrNotStructPointer) {
+			t.Fatalf("%T的错误为%v,期望ErrNotStructPointer", v, err)
+		}
+	}
+}
+
+func TestUnmarshalInvalidJSON(t *testing.T) {
+	var u testUser
+	err := Unmarshal(&hbase.TResult_{ColumnValues: []*hbase.TColumnValue{{Family: []byte("info"), Qualifier: []byte("profile"), Value: []byte("{")}}}, &u)
+	if err == nil {
+		t.Fatal("json格式错误时没有返回错误")
+	}
+}
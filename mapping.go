@@ -0,0 +1,85 @@
+// 结构体映射
+package aliexhbase
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/Golang-Tools/aliexhbase/mapper"
+)
+
+// GetInto 读取一行并解码到结构体指针v中,只读取结构体映射的列,行不存在时返回ErrRowNotFound
+// 映射规则见mapper包
+func (p *Client) GetInto(ctx context.Context, table []byte, row []byte, v interface{}) error {
+	columns, err := mapper.Columns(v)
+	if err != nil {
+		return err
+	}
+	result, err := p.Get(ctx, table, &hbase.TGet{Row: row, Columns: columns})
+	if err != nil {
+		return err
+	}
+	if len(result.ColumnValues) == 0 {
+		return ErrRowNotFound
+	}
+	if result.Row == nil {
+		result.Row = row
+	}
+	return mapper.Unmarshal(result, v)
+}
+
+// PutStruct 将结构体编码为TPut后写入,v可以是结构体或结构体指针,映射规则见mapper包
+func (p *Client) PutStruct(ctx context.Context, table []byte, v interface{}) error {
+	tput, err := mapper.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return p.Put(ctx, table, tput)
+}
+
+// ScanInto 扫描表并将每一行解码后追加到dest指向的切片中,dest的类型为*[]T或*[]*T,T为结构体
+// tscan没有指定列时只读取结构体映射的列,tscan为nil时扫描全表,映射规则见mapper包
+func (p *Client) ScanInto(ctx context.Context, table []byte, tscan *hbase.TScan, dest interface{}, opts ...ScanOption) error {
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() || dv.Elem().Kind() != reflect.Slice {
+		return ErrScanIntoDest
+	}
+	slice := dv.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	structType := elemType
+	if isPtr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return ErrScanIntoDest
+	}
+	if tscan == nil {
+		tscan = &hbase.TScan{}
+	}
+	if len(tscan.Columns) == 0 {
+		columns, err := mapper.ColumnsOf(structType)
+		if err != nil {
+			return err
+		}
+		ts := *tscan
+		ts.Columns = columns
+		tscan = &ts
+	}
+	s := p.Scan(ctx, table, tscan, opts...)
+	defer s.Close()
+	for s.Next() {
+		item := reflect.New(structType)
+		if err := mapper.Unmarshal(s.Result(), item.Interface()); err != nil {
+			return err
+		}
+		if isPtr {
+			slice = reflect.Append(slice, item)
+		} else {
+			slice = reflect.Append(slice, item.Elem())
+		}
+	}
+	dv.Elem().Set(slice)
+	return s.Err()
+}
@@ -0,0 +1,132 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/Golang-Tools/aliexhbase/mapper"
+)
+
+// rowHandler 按行保存单元格的假服务端
+type rowHandler struct {
+	hbase.THBaseService
+	mu      sync.Mutex
+	rows    map[string][]*hbase.TColumnValue
+	lastGet *hbase.TGet
+}
+
+func newRowHandler() *rowHandler {
+	return &rowHandler{rows: map[string][]*hbase.TColumnValue{}}
+}
+
+// Get 与服务端一致,行不存在时返回没有单元格的TResult_
+func (h *rowHandler) Get(ctx context.Context, table []byte, tget *hbase.TGet) (*hbase.TResult_, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastGet = tget
+	cells, ok := h.rows[string(tget.Row)]
+	if !ok {
+		return &hbase.TResult_{}, nil
+	}
+	return &hbase.TResult_{Row: tget.Row, ColumnValues: cells}, nil
+}
+
+func (h *rowHandler) Put(ctx context.Context, table []byte, tput *hbase.TPut) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rows[string(tput.Row)] = append(h.rows[string(tput.Row)], tput.ColumnValues...)
+	return nil
+}
+
+type mappedUser struct {
+	ID   string            `hbase:",rowkey"`
+	Name string            `hbase:"info:name"`
+	Age  int32             `hbase:"info:age,omitempty"`
+	Tags map[string][]byte `hbase:"tags"`
+}
+
+func TestPutStructGetInto(t *testing.T) {
+	h := newRowHandler()
+	c := newTestClient(t, h)
+	ctx := context.Background()
+	want := mappedUser{ID: "u1", Name: "Bob", Age: 30, Tags: map[string][]byte{"a": []byte("1")}}
+	if err := c.PutStruct(ctx, []byte("t"), want); err != nil {
+		t.Fatal(err)
+	}
+	var got mappedUser
+	if err := c.GetInto(ctx, []byte("t"), []byte("u1"), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("读取结果为%+v,期望%+v", got, want)
+	}
+	// 只读取结构体映射的列
+	columns, err := mapper.Columns(&got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(h.lastGet.Columns, columns) {
+		t.Fatalf("读取的列为%v,期望%v", h.lastGet.Columns, columns)
+	}
+}
+
+func TestGetIntoRowNotFound(t *testing.T) {
+	h := newRowHandler()
+	c := newTestClient(t, h)
+	got := mappedUser{Name: "old"}
+	err := c.GetInto(context.Background(), []byte("t"), []byte("missing"), &got)
+	if !errors.Is(err, ErrRowNotFound) {
+		t.Fatalf("行不存在时的错误为%v,期望ErrRowNotFound", err)
+	}
+	if got.ID != "" || got.Name != "old" {
+		t.Fatalf("行不存在时修改了结构体: %+v", got)
+	}
+}
+
+func TestGetIntoInvalidDest(t *testing.T) {
+	h := newRowHandler()
+	c := newTestClient(t, h)
+	if err := c.GetInto(context.Background(), []byte("t"), []byte("u1"), "u1"); !errors.Is(err, mapper.ErrNotStruct) {
+		t.Fatalf("错误为%v,期望mapper.ErrNotStruct", err)
+	}
+	if h.lastGet != nil {
+		t.Fatal("目标类型错误时仍然发送了请求")
+	}
+}
+
+func TestScanInto(t *testing.T) {
+	type rowOnly struct {
+		ID string `hbase:",rowkey"`
+	}
+	c := newTestClient(t, newScanHandler(3))
+	var values []rowOnly
+	if err := c.ScanInto(context.Background(), []byte("t"), &hbase.TScan{}, &values); err != nil {
+		t.Fatal(err)
+	}
+	if want := []rowOnly{{"row0000"}, {"row0001"}, {"row0002"}}; !reflect.DeepEqual(values, want) {
+		t.Fatalf("扫描结果为%v,期望%v", values, want)
+	}
+	var pointers []*rowOnly
+	if err := c.ScanInto(context.Background(), []byte("t"), &hbase.TScan{}, &pointers); err != nil {
+		t.Fatal(err)
+	}
+	if len(pointers) != 3 || pointers[2].ID != "row0002" {
+		t.Fatalf("扫描结果为%v", pointers)
+	}
+	values = nil
+	if err := c.ScanInto(context.Background(), []byte("t"), nil, &values); err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 {
+		t.Fatalf("tscan为nil时的扫描结果为%v", values)
+	}
+	for _, dest := range []interface{}{values, &[]int{}, (*[]rowOnly)(nil)} {
+		if err := c.ScanInto(context.Background(), []byte("t"), &hbase.TScan{}, dest); !errors.Is(err, ErrScanIntoDest) {
+			t.Fatalf("目标为%T时的错误为%v,期望ErrScanIntoDest", dest, err)
+		}
+	}
+}
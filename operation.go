@@ -0,0 +1,117 @@
+// hbase操作定义
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// Operation 描述一次通过Client发起的hbase操作
+type Operation struct {
+	// 操作名,与Client上对应的方法名一致
+	Name string
+	// 操作的表名,形式为`namespace:table`,与表无关的操作为空
+	Table []byte
+	// 操作的行,只有单行操作会设置
+	Row []byte
+	// 请求参数,与Client对应方法除ctx外的参数一一对应
+	Args []interface{}
+	// 请求结果,操作成功后设置,没有返回值的操作为nil
+	Result interface{}
+	// 实际发出请求的次数,包括重试
+	Attempts int
+	// 从连接池获取连接的累计等待时间
+	PoolWait time.Duration
+	// 累计发送的字节数
+	BytesSent int64
+	// 累计接收的字节数
+	BytesReceived int64
+}
+
+// Invoker 执行hbase操作,拦截器通过调用它继续执行后续的拦截器和实际的请求
+type Invoker func(ctx context.Context, op *Operation) error
+
+// Interceptor 拦截器,包裹在每个Client操作外层,可以用来实现日志,监控,链路追踪,故障注入等功能
+// 拦截器可以修改传给invoke的ctx,invoke返回后可以通过op获取请求结果,重试次数和连接池等待时间
+// 不调用invoke时请求不会被实际发出
+type Interceptor func(ctx context.Context, op *Operation, invoke Invoker) error
+
+// chainInterceptors 将拦截器串联起来,先注册的拦截器在外层
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, op *Operation) error {
+			return interceptor(ctx, op, next)
+		}
+	}
+	return invoker
+}
+
+// nonIdempotentOperations 非幂等的操作,重复执行可能导致结果不一致,默认不会重试
+var nonIdempotentOperations = map[string]bool{
+	// 重复执行会重复累加
+	"Increment": true,
+	"Append":    true,
+	// 第一次请求可能已经生效,重试时检查条件不再满足
+	"CheckAndPut":    true,
+	"CheckAndDelete": true,
+	"CheckAndMutate": true,
+	// 服务端的scanner已经前进,重试会跳过上一次请求已经返回的行
+	"GetScannerRows": true,
+	// 第一次请求可能已经生效,重试时会报错或者清空新写入的数据
+	"CreateTable":     true,
+	"DeleteTable":     true,
+	"TruncateTable":   true,
+	"CreateNamespace": true,
+	"DeleteNamespace": true,
+}
+
+// Idempotent 操作是否幂等,幂等的操作可以安全的重试
+func (o *Operation) Idempotent() bool {
+	return !nonIdempotentOperations[o.Name]
+}
+
+// tableNameBytes 将表名转换为`namespace:table`的形式,默认命名空间省略命名空间部分
+func tableNameBytes(tableName *hbase.TTableName) []byte {
+	if tableName == nil {
+		return nil
+	}
+	if len(tableName.Ns) == 0 || string(tableName.Ns) == "default" {
+		return tableName.Qualifier
+	}
+	name := make([]byte, 0, len(tableName.Ns)+1+len(tableName.Qualifier))
+	name = append(name, tableName.Ns...)
+	name = append(name, ':')
+	return append(name, tableName.Qualifier...)
+}
+
+// tableDescriptorName 获取表描述中的表名
+func tableDescriptorName(desc *hbase.TTableDescriptor) []byte {
+	if desc == nil {
+		return nil
+	}
+	return tableNameBytes(desc.TableName)
+}
+
+// wrapError 为操作失败的错误归类并附加操作上下文
+func (o *Operation) wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	kind := exceptions.Classify(err)
+	// 连接池等待超时
+	if errors.Is(err, ErrOverMax) {
+		kind = exceptions.ErrTimeout
+	}
+	return &exceptions.Error{
+		Kind:  kind,
+		Op:    o.Name,
+		Table: o.Table,
+		Row:   o.Row,
+		Err:   err,
+	}
+}
@@ -0,0 +1,99 @@
+package aliexhbase
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// tableHandler 只实现TableExists的假服务端
+type tableHandler struct {
+	hbase.THBaseService
+}
+
+func (tableHandler) TableExists(ctx context.Context, tableName *hbase.TTableName) (bool, error) {
+	return string(tableName.Qualifier) == "t", nil
+}
+
+// newTLSServer 启动https的假服务端,返回服务端和写入了其证书的CA文件
+func newTLSServer(t *testing.T, h hbase.THBaseService) (*httptest.Server, string) {
+	t.Helper()
+	processor := hbase.NewTHBaseServiceProcessor(h)
+	factory := thrift.NewTBinaryProtocolFactoryDefault()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(thrift.NewThriftHandlerFunc(processor, factory, factory)))
+	// 不信任证书的客户端会导致握手失败,不输出服务端日志
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return srv, caFile
+}
+
+func TestTLSOptionsDoNotModifySharedConfig(t *testing.T) {
+	_, caFile := newTLSServer(t, tableHandler{})
+	shared := &tls.Config{ServerName: "shared"}
+	o := Options{}
+	for _, opt := range []Option{
+		WithTLSConfig(shared),
+		WithCAFile(caFile),
+		WithTLSServerName("example.com"),
+		WithInsecureSkipVerify(),
+	} {
+		opt.Apply(&o)
+	}
+	if shared.ServerName != "shared" || shared.RootCAs != nil || shared.InsecureSkipVerify {
+		t.Fatalf("调用方的TLS配置被修改了: %+v", shared)
+	}
+	cfg := o.Poolconfig.TLSConfig
+	if cfg == shared || cfg.ServerName != "example.com" || cfg.RootCAs == nil || !cfg.InsecureSkipVerify {
+		t.Fatalf("unexpected TLS config %+v", cfg)
+	}
+
+	o = Options{}
+	WithTLSConfig(nil).Apply(&o)
+	if o.Poolconfig.TLSConfig != nil {
+		t.Fatalf("TLSConfig = %+v, want nil", o.Poolconfig.TLSConfig)
+	}
+}
+
+func TestTLSCAFile(t *testing.T) {
+	srv, caFile := newTLSServer(t, tableHandler{})
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	shared := &tls.Config{}
+	// httptest的证书签发给127.0.0.1和example.com
+	c, err := New(WithURL(srv.URL), WithLogger(logger), WithMaxAttempts(1), WithTLSConfig(shared), WithCAFile(caFile), WithTLSServerName("example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.HardClose()
+	ok, err := c.TableExists(context.Background(), &hbase.TTableName{Qualifier: []byte("t")})
+	if err != nil || !ok {
+		t.Fatalf("TableExists() = %v, %v", ok, err)
+	}
+
+	// 不信任服务端证书时握手失败
+	c2, err := New(WithURL(srv.URL), WithLogger(logger), WithMaxAttempts(1), WithTLSConfig(shared))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.HardClose()
+	if _, err := c2.TableExists(context.Background(), &hbase.TTableName{Qualifier: []byte("t")}); err == nil {
+		t.Fatal("没有校验服务端证书")
+	}
+}
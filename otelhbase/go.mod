@@ -0,0 +1,26 @@
+module github.com/Golang-Tools/aliexhbase/otelhbase
+
+go 1.25.0
+
+require (
+	github.com/Golang-Tools/aliexhbase v0.0.2
+	go.opentelemetry.io/otel v1.46.0
+	go.opentelemetry.io/otel/sdk v1.46.0
+	go.opentelemetry.io/otel/trace v1.46.0
+)
+
+require (
+	github.com/apache/thrift v0.13.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/sirupsen/logrus v1.8.1 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
+	go.opentelemetry.io/otel/metric v1.46.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
+
+// 拦截器接口尚未包含在已发布的aliexhbase版本中,发布新版本前本模块只能在仓库内通过replace使用,
+// 发布后需要把上面的require更新到包含Interceptor的版本
+replace github.com/Golang-Tools/aliexhbase => ../
@@ -0,0 +1,179 @@
+// hbase操作的OpenTelemetry链路追踪
+// 使用`aliexhbase.New(aliexhbase.WithURL(...), otelhbase.WithTracing())`为每个操作创建span,
+// http传输层还会通过请求头传递链路上下文,便于与网关日志关联
+package otelhbase
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/Golang-Tools/aliexhbase"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 创建tracer时使用的名字
+const instrumentationName = "github.com/Golang-Tools/aliexhbase/otelhbase"
+
+// 自定义的span属性
+var (
+	// dbSystemKey 旧版本语义约定中的数据库类型,部分后端仍然依赖它
+	dbSystemKey = attribute.Key("db.system")
+	// retryCountKey 重试次数
+	retryCountKey = attribute.Key("hbase.retry_count")
+	// poolWaitKey 从连接池获取连接的等待时间,单位s
+	poolWaitKey = attribute.Key("hbase.pool.wait_time")
+)
+
+// config 链路追踪配置
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// Option 链路追踪配置项
+type Option interface {
+	apply(*config)
+}
+
+type funcOption func(*config)
+
+func (f funcOption) apply(c *config) {
+	f(c)
+}
+
+// WithTracerProvider 指定使用的TracerProvider,默认使用全局的TracerProvider
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return funcOption(func(c *config) {
+		c.tracerProvider = tp
+	})
+}
+
+// WithPropagators 指定传递链路上下文使用的Propagator,默认使用全局的Propagator
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return funcOption(func(c *config) {
+		c.propagators = p
+	})
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	if c.propagators == nil {
+		c.propagators = otel.GetTextMapPropagator()
+	}
+	return c
+}
+
+// splitTable 将`namespace:table`形式的表名拆分为命名空间和表名
+func splitTable(table []byte) (namespace, name string) {
+	if i := bytes.IndexByte(table, ':'); i >= 0 {
+		return string(table[:i]), string(table[i+1:])
+	}
+	return "", string(table)
+}
+
+// returnedRows 获取批量读取和扫描操作返回的行数
+func returnedRows(result interface{}) (int, bool) {
+	switch r := result.(type) {
+	case []*hbase.TResult_:
+		return len(r), true
+	case []bool:
+		return len(r), true
+	default:
+		return 0, false
+	}
+}
+
+// NewInterceptor 创建为每个操作创建span的拦截器
+// span遵循数据库语义约定,记录表名,操作名,扫描和批量读取返回的行数,重试次数和连接池等待时间
+func NewInterceptor(opts ...Option) aliexhbase.Interceptor {
+	c := newConfig(opts)
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+	return func(ctx context.Context, op *aliexhbase.Operation, invoke aliexhbase.Invoker) error {
+		namespace, table := splitTable(op.Table)
+		spanName := op.Name
+		attrs := []attribute.KeyValue{
+			semconv.DBSystemNameHBase,
+			dbSystemKey.String("hbase"),
+			semconv.DBOperationName(op.Name),
+		}
+		if table != "" {
+			spanName += " " + table
+			attrs = append(attrs, semconv.DBCollectionName(table))
+		}
+		if namespace != "" {
+			attrs = append(attrs, semconv.DBNamespace(namespace))
+		}
+		ctx, span := tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		defer span.End()
+
+		err := invoke(ctx, op)
+		if op.Attempts > 1 {
+			span.SetAttributes(retryCountKey.Int(op.Attempts - 1))
+		}
+		span.SetAttributes(poolWaitKey.Float64(op.PoolWait.Seconds()))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		if rows, ok := returnedRows(op.Result); ok {
+			span.SetAttributes(semconv.DBResponseReturnedRows(rows))
+		}
+		return nil
+	}
+}
+
+// transport 将链路上下文注入请求头的RoundTripper
+type transport struct {
+	base        http.RoundTripper
+	propagators propagation.TextMapPropagator
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper不能修改原请求
+	r := req.Clone(req.Context())
+	t.propagators.Inject(r.Context(), propagation.HeaderCarrier(r.Header))
+	return t.base.RoundTrip(r)
+}
+
+// NewTransport 创建将链路上下文注入请求头的RoundTripper,base为nil时使用http.DefaultTransport
+func NewTransport(base http.RoundTripper, opts ...Option) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base, propagators: newConfig(opts).propagators}
+}
+
+// HTTPMiddleware 创建将链路上下文注入请求头的http传输层中间件
+func HTTPMiddleware(opts ...Option) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		return NewTransport(base, opts...)
+	}
+}
+
+type tracingOption struct {
+	opts []Option
+}
+
+func (t *tracingOption) Apply(o *aliexhbase.Options) {
+	aliexhbase.WithInterceptors(NewInterceptor(t.opts...)).Apply(o)
+	aliexhbase.WithHTTPMiddlewares(HTTPMiddleware(t.opts...)).Apply(o)
+}
+
+// WithTracing 开启链路追踪的客户端配置项,同时注册拦截器和注入链路上下文的http传输层中间件
+func WithTracing(opts ...Option) aliexhbase.Option {
+	return &tracingOption{opts: opts}
+}
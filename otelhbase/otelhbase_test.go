@@ -0,0 +1,143 @@
+package otelhbase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newRecorder() (*tracetest.SpanRecorder, trace.TracerProvider) {
+	sr := tracetest.NewSpanRecorder()
+	return sr, sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+}
+
+func attrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestInterceptorSpan(t *testing.T) {
+	sr, tp := newRecorder()
+	interceptor := NewInterceptor(WithTracerProvider(tp))
+	op := &aliexhbase.Operation{Name: "GetMultiple", Table: []byte("ns:users")}
+	err := interceptor(context.Background(), op, func(ctx context.Context, op *aliexhbase.Operation) error {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("invoke拿到的ctx中没有span")
+		}
+		op.Attempts = 3
+		op.PoolWait = 250 * time.Millisecond
+		op.Result = []*hbase.TResult_{{}, {}}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := sr.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("记录了%d个span", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GetMultiple users" || span.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("span %q kind %v", span.Name(), span.SpanKind())
+	}
+	if span.Status().Code != codes.Unset {
+		t.Fatalf("status = %v", span.Status())
+	}
+	got := attrs(span)
+	for key, want := range map[attribute.Key]attribute.Value{
+		"db.system.name":            attribute.StringValue("hbase"),
+		"db.system":                 attribute.StringValue("hbase"),
+		"db.operation.name":         attribute.StringValue("GetMultiple"),
+		"db.collection.name":        attribute.StringValue("users"),
+		"db.namespace":              attribute.StringValue("ns"),
+		"db.response.returned_rows": attribute.IntValue(2),
+		"hbase.retry_count":         attribute.IntValue(2),
+		"hbase.pool.wait_time":      attribute.Float64Value(0.25),
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key].Emit(), want.Emit())
+		}
+	}
+}
+
+func TestInterceptorSpanError(t *testing.T) {
+	sr, tp := newRecorder()
+	interceptor := NewInterceptor(WithTracerProvider(tp))
+	failure := errors.New("boom")
+	op := &aliexhbase.Operation{Name: "ListNamespaceDescriptors"}
+	err := interceptor(context.Background(), op, func(ctx context.Context, op *aliexhbase.Operation) error {
+		op.Attempts = 1
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("err = %v", err)
+	}
+	span := sr.Ended()[0]
+	// 与表无关的操作只用操作名作为span名
+	if span.Name() != "ListNamespaceDescriptors" {
+		t.Fatalf("span name %q", span.Name())
+	}
+	if span.Status().Code != codes.Error || span.Status().Description != "boom" {
+		t.Fatalf("status = %+v", span.Status())
+	}
+	if len(span.Events()) != 1 || span.Events()[0].Name != "exception" {
+		t.Fatalf("events = %+v", span.Events())
+	}
+	got := attrs(span)
+	for _, key := range []attribute.Key{"db.collection.name", "db.namespace", "hbase.retry_count", "db.response.returned_rows"} {
+		if _, ok := got[key]; ok {
+			t.Errorf("不应该有属性%s", key)
+		}
+	}
+}
+
+func TestTransportInjectsTraceContext(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	_, tp := newRecorder()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+	client := &http.Client{Transport: NewTransport(nil, WithPropagators(propagation.TraceContext{}))}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	h := <-headers
+	want := "00-" + span.SpanContext().TraceID().String() + "-" + span.SpanContext().SpanID().String() + "-01"
+	if got := h.Get("Traceparent"); got != want {
+		t.Fatalf("traceparent = %q, want %q", got, want)
+	}
+	// 不能修改原请求
+	if req.Header.Get("Traceparent") != "" {
+		t.Fatal("原请求被修改了")
+	}
+}
+
+func TestWithTracing(t *testing.T) {
+	o := aliexhbase.Options{}
+	WithTracing().Apply(&o)
+	if len(o.Interceptors) != 1 || o.Poolconfig == nil || len(o.Poolconfig.HTTPMiddlewares) != 1 {
+		t.Fatalf("WithTracing没有同时注册拦截器和http中间件: %d %+v", len(o.Interceptors), o.Poolconfig)
+	}
+}
@@ -0,0 +1,242 @@
+// 按region并行扫描
+package aliexhbase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// defaultParallelScanConcurrency 并行扫描默认的并发数
+const defaultParallelScanConcurrency = 4
+
+// defaultParallelScanMaxAttempts 并行扫描时每个region默认的最大尝试次数
+const defaultParallelScanMaxAttempts = 3
+
+// RegionProgress 并行扫描中单个region的扫描进度
+type RegionProgress struct {
+	// region在本次扫描的所有region中的序号
+	Index int
+	// 本次扫描的region总数
+	Total int
+	// region的信息
+	Region *hbase.THRegionInfo
+	// 与扫描范围求交集后该region实际的扫描范围,StopRow为空表示扫描到表尾
+	StartRow []byte
+	StopRow  []byte
+	// 已经返回的行数
+	Rows int64
+	// 当前是第几次尝试
+	Attempt int
+	// 扫描是否已经成功完成
+	Done bool
+	// 本次尝试的错误,重试前和最终失败时设置
+	Err error
+}
+
+// parallelScanOptions 并行扫描配置
+type parallelScanOptions struct {
+	concurrency int
+	maxAttempts int
+	progress    func(RegionProgress)
+	scanOpts    []ScanOption
+}
+
+// ParallelScanOption 并行扫描配置项
+type ParallelScanOption func(*parallelScanOptions)
+
+// WithParallelScanConcurrency 设置同时扫描的region数,默认为4
+func WithParallelScanConcurrency(Concurrency int) ParallelScanOption {
+	return func(o *parallelScanOptions) {
+		o.concurrency = Concurrency
+	}
+}
+
+// WithParallelScanMaxAttempts 设置每个region的最大尝试次数,默认为3,重试时从该region最后返回的行之后继续扫描
+func WithParallelScanMaxAttempts(MaxAttempts int) ParallelScanOption {
+	return func(o *parallelScanOptions) {
+		o.maxAttempts = MaxAttempts
+	}
+}
+
+// WithParallelScanProgress 设置进度回调,region开始扫描,失败重试和扫描完成时调用,可能被并发调用
+func WithParallelScanProgress(Progress func(RegionProgress)) ParallelScanOption {
+	return func(o *parallelScanOptions) {
+		o.progress = Progress
+	}
+}
+
+// WithParallelScanOptions 设置每个region的扫描器使用的配置项
+func WithParallelScanOptions(ScanOpts ...ScanOption) ParallelScanOption {
+	return func(o *parallelScanOptions) {
+		o.scanOpts = append(o.scanOpts, ScanOpts...)
+	}
+}
+
+// regionScan 单个region的扫描任务
+type regionScan struct {
+	progress RegionProgress
+	tscan    *hbase.TScan
+	lastRow  []byte
+}
+
+// splitScanByRegions 将扫描范围按region拆分,跳过副本region和已经分裂的下线region
+func splitScanByRegions(tscan *hbase.TScan, locations []*hbase.THRegionLocation) []*regionScan {
+	var tasks []*regionScan
+	for _, loc := range locations {
+		info := loc.GetRegionInfo()
+		if info == nil || info.GetReplicaId() != 0 || (info.GetOffline() && info.GetSplit()) {
+			continue
+		}
+		start := info.StartKey
+		if bytes.Compare(tscan.StartRow, start) > 0 {
+			start = tscan.StartRow
+		}
+		stop := info.EndKey
+		if len(tscan.StopRow) > 0 && (len(stop) == 0 || bytes.Compare(tscan.StopRow, stop) < 0) {
+			stop = tscan.StopRow
+		}
+		if len(stop) > 0 && bytes.Compare(start, stop) >= 0 {
+			continue
+		}
+		ts := *tscan
+		ts.StartRow = start
+		ts.StopRow = stop
+		tasks = append(tasks, &regionScan{
+			progress: RegionProgress{Region: info, StartRow: start, StopRow: stop},
+			tscan:    &ts,
+		})
+	}
+	for i, t := range tasks {
+		t.progress.Index = i
+		t.progress.Total = len(tasks)
+	}
+	return tasks
+}
+
+// ParallelScan 按region拆分扫描范围并行扫描,每个region使用一个扫描器
+// 每一行都会调用fn,同一region的行按顺序回调,不同region的回调会并发执行;fn返回错误时停止所有扫描并返回该错误.
+// region扫描失败时从该region最后返回的行之后重试,超过最大尝试次数后停止所有扫描并返回错误.
+// 不支持反向扫描,Limit和BatchSize.tscan为nil时扫描全表
+func (p *Client) ParallelScan(ctx context.Context, table []byte, tscan *hbase.TScan, fn func(*hbase.TResult_) error, opts ...ParallelScanOption) error {
+	if tscan == nil {
+		tscan = &hbase.TScan{}
+	}
+	o := parallelScanOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = defaultParallelScanConcurrency
+	}
+	if o.maxAttempts <= 0 {
+		o.maxAttempts = defaultParallelScanMaxAttempts
+	}
+	if tscan.GetReversed() || tscan.GetLimit() > 0 || tscan.GetBatchSize() > 0 {
+		return ErrParallelScanUnsupported
+	}
+	locations, err := p.GetAllRegionLocations(ctx, table)
+	if err != nil {
+		return err
+	}
+	tasks := splitScanByRegions(tscan, locations)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	queue := make(chan *regionScan)
+	for i := 0; i < o.concurrency && i < len(tasks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range queue {
+				if err := p.scanRegion(ctx, table, t, fn, &o); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+	for _, t := range tasks {
+		select {
+		case queue <- t:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// scanRegion 扫描单个region,失败时从最后返回的行之后重试
+func (p *Client) scanRegion(ctx context.Context, table []byte, t *regionScan, fn func(*hbase.TResult_) error, o *parallelScanOptions) error {
+	report := func() {
+		if o.progress != nil {
+			o.progress(t.progress)
+		}
+	}
+	for attempt := 1; ; attempt++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		t.progress.Attempt = attempt
+		t.progress.Err = nil
+		report()
+		tscan := t.tscan
+		if t.lastRow != nil {
+			ts := *t.tscan
+			ts.StartRow = append(append(make([]byte, 0, len(t.lastRow)+1), t.lastRow...), 0)
+			tscan = &ts
+		}
+		cbErr, err := p.scanRegionOnce(ctx, table, tscan, t, fn, o)
+		if cbErr != nil {
+			return cbErr
+		}
+		if err == nil {
+			t.progress.Done = true
+			report()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		t.progress.Err = err
+		report()
+		if attempt >= o.maxAttempts || !p.Opts.Retry.wait(ctx, attempt) {
+			return fmt.Errorf("扫描region[%q, %q)失败: %w", t.progress.StartRow, t.progress.StopRow, err)
+		}
+	}
+}
+
+// scanRegionOnce 打开扫描器扫描一次region,cbErr为回调返回的错误,err为扫描的错误
+func (p *Client) scanRegionOnce(ctx context.Context, table []byte, tscan *hbase.TScan, t *regionScan, fn func(*hbase.TResult_) error, o *parallelScanOptions) (cbErr error, err error) {
+	s := p.Scan(ctx, table, tscan, o.scanOpts...)
+	defer s.Close()
+	for s.Next() {
+		r := s.Result()
+		if cbErr = fn(r); cbErr != nil {
+			return cbErr, nil
+		}
+		t.lastRow = r.Row
+		t.progress.Rows++
+	}
+	return nil, s.Err()
+}
@@ -0,0 +1,260 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// testRegion 构造region位置信息
+func testRegion(start, end string) *hbase.THRegionLocation {
+	return &hbase.THRegionLocation{
+		ServerName: &hbase.TServerName{HostName: "rs1"},
+		RegionInfo: &hbase.THRegionInfo{TableName: []byte("t"), StartKey: []byte(start), EndKey: []byte(end)},
+	}
+}
+
+// testRegions 按分割点构造覆盖整张表的region,并附带一个副本region和一个已经分裂下线的父region
+func testRegions(splits ...string) []*hbase.THRegionLocation {
+	bounds := append(append([]string{""}, splits...), "")
+	var locations []*hbase.THRegionLocation
+	for i := 0; i+1 < len(bounds); i++ {
+		locations = append(locations, testRegion(bounds[i], bounds[i+1]))
+	}
+	replica := testRegion("", "")
+	replica.RegionInfo.ReplicaId = thrift.Int32Ptr(1)
+	parent := testRegion("", "")
+	parent.RegionInfo.Offline = thrift.BoolPtr(true)
+	parent.RegionInfo.Split = thrift.BoolPtr(true)
+	return append(locations, replica, parent)
+}
+
+func TestSplitScanByRegions(t *testing.T) {
+	locations := testRegions("b", "d")
+	for _, tc := range []struct {
+		name        string
+		start, stop string
+		want        string
+	}{
+		{"full", "", "", `[["" "b") ["b" "d") ["d" "")]`},
+		{"start in region", "c", "", `[["c" "d") ["d" "")]`},
+		{"stop in region", "", "c", `[["" "b") ["b" "c")]`},
+		{"start and stop in one region", "b1", "b2", `[["b1" "b2")]`},
+		// 起止行正好是region的边界时不会产生空的扫描
+		{"region bounds", "b", "d", `[["b" "d")]`},
+		{"start at last region", "d", "", `[["d" "")]`},
+		{"stop before first split", "", "a", `[["" "a")]`},
+		{"empty range", "c", "c", `[]`},
+	} {
+		tscan := &hbase.TScan{StartRow: []byte(tc.start), StopRow: []byte(tc.stop), Columns: []*hbase.TColumn{{Family: []byte("cf")}}}
+		tasks := splitScanByRegions(tscan, locations)
+		var ranges []string
+		for i, task := range tasks {
+			ranges = append(ranges, fmt.Sprintf("[%q %q)", task.tscan.StartRow, task.tscan.StopRow))
+			if task.progress.Index != i || task.progress.Total != len(tasks) {
+				t.Errorf("%s: task %d progress %+v", tc.name, i, task.progress)
+			}
+			if string(task.progress.StartRow) != string(task.tscan.StartRow) || string(task.progress.StopRow) != string(task.tscan.StopRow) {
+				t.Errorf("%s: task %d progress range differs from scan range", tc.name, i)
+			}
+			// 其他扫描设置保持不变
+			if len(task.tscan.Columns) != 1 {
+				t.Errorf("%s: task %d lost columns", tc.name, i)
+			}
+		}
+		if got := "[" + strings.Join(ranges, " ") + "]"; got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+		if string(tscan.StartRow) != tc.start || string(tscan.StopRow) != tc.stop {
+			t.Errorf("%s: 原扫描被修改了", tc.name)
+		}
+	}
+}
+
+// regionScanHandler 带有region信息的假服务端
+type regionScanHandler struct {
+	*scanHandler
+	locations []*hbase.THRegionLocation
+}
+
+func (h *regionScanHandler) GetAllRegionLocations(ctx context.Context, table []byte) ([]*hbase.THRegionLocation, error) {
+	return h.locations, nil
+}
+
+func newRegionScanHandler(n int, splits ...int) *regionScanHandler {
+	var keys []string
+	for _, i := range splits {
+		keys = append(keys, string(testRow(i)))
+	}
+	return &regionScanHandler{scanHandler: newScanHandler(n), locations: testRegions(keys...)}
+}
+
+// parallelCollect 并行扫描并收集所有行和每个region最后的进度
+func parallelCollect(c *Client, tscan *hbase.TScan, opts ...ParallelScanOption) ([]string, map[int]RegionProgress, error) {
+	var (
+		mu       sync.Mutex
+		rows     []string
+		progress = map[int]RegionProgress{}
+	)
+	opts = append(opts, WithParallelScanProgress(func(p RegionProgress) {
+		// 同一region的进度按顺序回调,只保留最后一次
+		mu.Lock()
+		defer mu.Unlock()
+		progress[p.Index] = p
+	}))
+	err := c.ParallelScan(context.Background(), []byte("t"), tscan, func(r *hbase.TResult_) error {
+		mu.Lock()
+		defer mu.Unlock()
+		rows = append(rows, string(r.Row))
+		return nil
+	}, opts...)
+	sort.Strings(rows)
+	return rows, progress, err
+}
+
+func wantRows(from, to int) []string {
+	var rows []string
+	for i := from; i < to; i++ {
+		rows = append(rows, string(testRow(i)))
+	}
+	return rows
+}
+
+func TestParallelScan(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		start    int
+		stop     int
+		from, to int
+		regions  int
+	}{
+		{"full", -1, -1, 0, 100, 4},
+		{"range", 10, 90, 10, 90, 4},
+		{"range in one region", 30, 40, 30, 40, 1},
+		{"open stop", 60, -1, 60, 100, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newRegionScanHandler(100, 25, 50, 75)
+			c := newTestClient(t, h)
+			tscan := &hbase.TScan{}
+			if tc.start >= 0 {
+				tscan.StartRow = testRow(tc.start)
+			}
+			if tc.stop >= 0 {
+				tscan.StopRow = testRow(tc.stop)
+			}
+			rows, progress, err := parallelCollect(c, tscan, WithParallelScanConcurrency(2), WithParallelScanOptions(WithScanBatchSize(7)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 副本region和下线的父region被跳过,每行只返回一次
+			if fmt.Sprint(rows) != fmt.Sprint(wantRows(tc.from, tc.to)) {
+				t.Fatalf("got %d rows %v", len(rows), rows)
+			}
+			if len(progress) != tc.regions {
+				t.Fatalf("扫描了%d个region,期望%d个", len(progress), tc.regions)
+			}
+			var total int64
+			for i, p := range progress {
+				if !p.Done || p.Attempt != 1 || p.Total != tc.regions {
+					t.Errorf("region %d progress %+v", i, p)
+				}
+				total += p.Rows
+			}
+			if total != int64(len(rows)) {
+				t.Fatalf("进度中的行数%d与返回的行数%d不一致", total, len(rows))
+			}
+		})
+	}
+}
+
+func TestParallelScanNilTScan(t *testing.T) {
+	c := newTestClient(t, newRegionScanHandler(100, 25, 50, 75))
+	rows, progress, err := parallelCollect(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(wantRows(0, 100)) || len(progress) != 4 {
+		t.Fatalf("got %d rows in %d regions", len(rows), len(progress))
+	}
+}
+
+func TestParallelScanRetry(t *testing.T) {
+	h := newRegionScanHandler(100, 25, 50, 75)
+	// 两个region各失效一次
+	h.expire[string(testRow(31))] = true
+	h.expire[string(testRow(80))] = true
+	c := newTestClient(t, h)
+	rows, progress, err := parallelCollect(c, &hbase.TScan{}, WithParallelScanOptions(WithScanBatchSize(4)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 从最后返回的行之后重试,不重复也不遗漏
+	if fmt.Sprint(rows) != fmt.Sprint(wantRows(0, 100)) {
+		t.Fatalf("got %d rows %v", len(rows), rows)
+	}
+	for i, p := range progress {
+		want := 1
+		if i == 1 || i == 3 {
+			want = 2
+		}
+		if !p.Done || p.Attempt != want || p.Rows != 25 {
+			t.Errorf("region %d progress %+v", i, p)
+		}
+	}
+
+	// 超过最大尝试次数时返回错误
+	h = newRegionScanHandler(100, 50)
+	h.expire[string(testRow(60))] = true
+	c = newTestClient(t, h)
+	_, _, err = parallelCollect(c, &hbase.TScan{}, WithParallelScanMaxAttempts(1))
+	if !errors.Is(err, exceptions.ErrScannerExpired) {
+		t.Fatalf("err = %v, want ErrScannerExpired", err)
+	}
+}
+
+func TestParallelScanCallbackError(t *testing.T) {
+	h := newRegionScanHandler(100, 25, 50, 75)
+	c := newTestClient(t, h)
+	stop := errors.New("stop")
+	var mu sync.Mutex
+	n := 0
+	err := c.ParallelScan(context.Background(), []byte("t"), &hbase.TScan{}, func(r *hbase.TResult_) error {
+		mu.Lock()
+		defer mu.Unlock()
+		n++
+		if string(r.Row) == string(testRow(60)) {
+			return stop
+		}
+		return nil
+	}, WithParallelScanConcurrency(1))
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v, want %v", err, stop)
+	}
+	// 只有一个并发时回调出错后不再扫描后面的region
+	if n != 61 {
+		t.Fatalf("回调了%d次,期望61次", n)
+	}
+}
+
+func TestParallelScanUnsupported(t *testing.T) {
+	c := newTestClient(t, newRegionScanHandler(10))
+	for _, tscan := range []*hbase.TScan{
+		{Reversed: thrift.BoolPtr(true)},
+		{Limit: thrift.Int32Ptr(10)},
+		{BatchSize: thrift.Int32Ptr(10)},
+	} {
+		err := c.ParallelScan(context.Background(), []byte("t"), tscan, func(*hbase.TResult_) error { return nil })
+		if !errors.Is(err, ErrParallelScanUnsupported) {
+			t.Errorf("err = %v, want ErrParallelScanUnsupported", err)
+		}
+	}
+}
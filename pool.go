@@ -3,6 +3,11 @@ package aliexhbase
 
 import (
 	"container/list"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,19 +29,56 @@ type ThriftPoolConfig struct {
 	Passwd string
 	// 最大连接数
 	MaxConn int32
-	// 创建连接超时时间
+	// 创建连接超时时间,即http传输层的拨号超时时间
 	ConnTimeout time.Duration
+	// http传输层等待服务端响应头的超时时间,为0时不限制
+	ResponseHeaderTimeout time.Duration
+	// http传输层tcp连接的keep-alive探测间隔,为0时使用默认值,为负数时关闭keep-alive
+	KeepAlive time.Duration
+	// http传输层对每个host保持的最大空闲tcp连接数,为0时与MaxConn一致
+	MaxIdleConnsPerHost int
+	// http传输层使用的代理,为nil时使用环境变量中的代理设置
+	Proxy *url.URL
+	// https地址使用的TLS配置,为nil时使用系统默认配置
+	TLSConfig *tls.Config
+	// http传输层使用的自定义RoundTripper,设置后以上http传输层相关的配置均不再生效
+	RoundTripper http.RoundTripper
+	// 包裹在http传输层外的中间件,先注册的在外层,可以用来为请求添加请求头,记录请求等
+	HTTPMiddlewares []func(http.RoundTripper) http.RoundTripper
+	// http传输层使用的认证策略,为nil时如果设置了User和Passwd则使用阿里云增强版hbase的认证方式,否则不认证
+	Auth Authenticator
+	// thrift传输层类型,默认为http
+	Transport TransportType
+	// thrift协议类型,默认为二进制协议
+	Protocol ProtocolType
+	// socket传输层的读写超时时间,为0时不限制
+	SocketTimeout time.Duration
 	// 空闲客户端超时时间，超时主动释放连接，关闭客户端
 	IdleTimeout time.Duration
 	// 获取Thrift客户端超时时间
 	Timeout time.Duration
 	// 获取Thrift客户端失败重试间隔
+	// Deprecated: 连接池已改为在连接释放时主动唤醒等待者,不再轮询,该配置不再生效
 	Interval time.Duration
 }
 
+// Clone 复制一份连接池配置,TLS配置也会被复制,修改副本不会影响原配置
+func (c *ThriftPoolConfig) Clone() *ThriftPoolConfig {
+	if c == nil {
+		return nil
+	}
+	config := *c
+	if c.TLSConfig != nil {
+		config.TLSConfig = c.TLSConfig.Clone()
+	}
+	return &config
+}
+
 // Thrift客户端连接池
 type ThriftPool struct {
 	idle list.List
+	// 等待获取连接的调用方队列,元素为chan *Conn,先进先出
+	waiters list.List
 	// 同步锁，确保count/status/idle等公共数据并发操作安全
 	lock *sync.Mutex
 	// 记录当前已经创建的Thrift客户端,确保MaxConn配置
@@ -45,6 +87,46 @@ type ThriftPool struct {
 	status uint32
 	// Thrift客户端连接池相关配置
 	config *ThriftPoolConfig
+	// 池中所有连接共用的http客户端,只有http传输层会用到
+	httpClient *http.Client
+	// 统计信息
+	stats poolCounters
+}
+
+// poolCounters 连接池的累计统计
+type poolCounters struct {
+	created      atomic.Int64
+	waitCount    atomic.Int64
+	waitDuration atomic.Int64
+	overMax      atomic.Int64
+	reconnects   atomic.Int64
+	idleClosed   atomic.Int64
+}
+
+// PoolStats 连接池统计信息
+type PoolStats struct {
+	// 最大连接数
+	MaxConn int32
+	// 当前连接数
+	OpenConnections int32
+	// 空闲连接数
+	Idle int
+	// 正在使用的连接数
+	InUse int
+	// 正在等待连接的调用方数量
+	Waiting int
+	// 累计新建的连接数
+	Created int64
+	// 累计因为连接池超额而等待的次数
+	WaitCount int64
+	// 累计等待连接的时间
+	WaitDuration time.Duration
+	// 累计等待超时(ErrOverMax)的次数
+	OverMaxCount int64
+	// 累计因为连接出错通过CloseConn关闭的连接数,不代表已重新建立连接
+	ReconnectCount int64
+	// 累计因为空闲超时被关闭的连接数
+	IdleClosed int64
 }
 
 var nowFunc = time.Now
@@ -58,6 +140,9 @@ func NewThriftPool(config *ThriftPoolConfig) *ThriftPool {
 		status: uint32(PoolStatus_Open),
 		count:  0,
 	}
+	if config.Transport == Transport_HTTP {
+		thriftPool.httpClient = config.NewHTTPClient()
+	}
 	// 初始化空闲链接
 	thriftPool.initConn()
 	// 定期清理过期空闲连接
@@ -112,9 +197,12 @@ func (p *ThriftPool) CheckTimeout() {
 		}
 		//timeout && clear
 		p.idle.Remove(ele)
+		p.stats.idleClosed.Add(1)
+		if !p.handoff(nil) {
+			atomic.AddInt32(&p.count, -1)
+		}
 		p.lock.Unlock()
 		v.Close() //close client connection
-		atomic.AddInt32(&p.count, -1)
 		p.lock.Lock()
 	}
 	p.lock.Unlock()
@@ -122,122 +210,221 @@ func (p *ThriftPool) CheckTimeout() {
 
 // 获取Thrift空闲连接
 func (p *ThriftPool) Get() (*Conn, error) {
-	return p.get(nowFunc().Add(p.config.Timeout))
+	return p.GetContext(context.Background())
+}
+
+// GetContext 获取Thrift空闲连接
+// 除了配置的Timeout外,ctx被取消或到达截止时间时也会停止等待,此时返回的错误包装了ctx.Err()
+func (p *ThriftPool) GetContext(ctx context.Context) (*Conn, error) {
+	return p.get(ctx, nowFunc().Add(p.config.Timeout))
 }
 
 // 获取连接的逻辑实现
-// expire设定了一个超时时间点，当没有可用连接时，程序会休眠一小段时间后重试
-// 如果一直获取不到连接，一旦到达超时时间点，则报ErrOverMax错误
-func (p *ThriftPool) get(expire time.Time) (*Conn, error) {
+// 有空闲连接时直接取用,未达到最大连接数时新建连接,否则进入等待队列.
+// 等待队列中的调用方会在Put或CloseConn释放出连接或名额时按先进先出的顺序被唤醒,
+// 一旦到达超时时间点expire仍未被唤醒,则报ErrOverMax错误
+func (p *ThriftPool) get(ctx context.Context, expire time.Time) (*Conn, error) {
 	if atomic.LoadUint32(&p.status) == uint32(PoolStatus_Stoped) {
 		return nil, ErrPoolClosed
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("ThriftPool 等待连接被中断: %w", err)
+	}
 
-	// 判断是否超额
 	p.lock.Lock()
-	if p.idle.Len() == 0 && atomic.LoadInt32(&p.count) >= p.config.MaxConn {
+	// 从队头中获取空闲连接,有空闲连接时等待队列一定为空
+	if ele := p.idle.Front(); ele != nil {
+		idlec := ele.Value.(*Conn)
+		p.idle.Remove(ele)
 		p.lock.Unlock()
-		// 不采用递归的方式来实现重试机制，防止栈溢出，这里改用循环方式来实现重试
-		for {
-			// 休眠一段时间再重试
-			time.Sleep(p.config.Interval)
-			// 超时退出
-			if nowFunc().After(expire) {
-				return nil, ErrOverMax
-			}
-			p.lock.Lock()
-			if p.idle.Len() == 0 && atomic.LoadInt32(&p.count) >= p.config.MaxConn {
-				p.lock.Unlock()
-			} else { // 有可用链接，退出for循环
-				break
-			}
-		}
+		return p.checkConn(idlec)
 	}
-
-	if p.idle.Len() == 0 {
+	// 未超额且没有人在排队时直接新建连接,有人排队时新来的调用方也必须排队,保证先进先出
+	if p.waiters.Len() == 0 && atomic.LoadInt32(&p.count) < p.config.MaxConn {
 		// 先加1，防止首次创建连接时，TCP握手太久，导致p.count未能及时+1，而新的请求已经到来
 		// 从而导致短暂性实际连接数大于p.count（大部分链接由于无法进入空闲链接队列，而被关闭，处于TIME_WATI状态）
 		atomic.AddInt32(&p.count, 1)
 		p.lock.Unlock()
-		client, err := NewConn(p.config.Addr, p.config.User, p.config.Passwd)
-		if err != nil {
-			atomic.AddInt32(&p.count, -1)
-			return nil, err
-		}
-		// 检查连接是否有效
-		if !client.Check() {
-			atomic.AddInt32(&p.count, -1)
-			return nil, ErrSocketDisconnect
-		}
+		return p.createConn()
+	}
+	// 超额,进入等待队列
+	// sync.Cond不支持超时等待,因此每个等待者持有一个容量为1的通道作为条件变量,
+	// 唤醒方在持有锁时向队头等待者的通道发送连接或名额(nil),因此发送永远不会阻塞
+	wait := make(chan *Conn, 1)
+	ele := p.waiters.PushBack(wait)
+	p.lock.Unlock()
 
-		return client, nil
+	start := nowFunc()
+	p.stats.waitCount.Add(1)
+	defer func() {
+		p.stats.waitDuration.Add(int64(nowFunc().Sub(start)))
+	}()
+	timer := time.NewTimer(expire.Sub(start))
+	defer timer.Stop()
+	var err error
+	select {
+	case c := <-wait:
+		return p.wakeup(c)
+	case <-timer.C:
+		p.stats.overMax.Add(1)
+		err = ErrOverMax
+	case <-ctx.Done():
+		err = fmt.Errorf("ThriftPool 等待连接被中断: %w", ctx.Err())
+	}
+	// 放弃等待,不能继续占用连接池的名额
+	p.lock.Lock()
+	select {
+	case c := <-wait:
+		// 放弃等待的同时已经被唤醒,把拿到的连接或名额交给下一个等待者
+		p.lock.Unlock()
+		p.giveBack(c)
+	default:
+		p.waiters.Remove(ele)
+		p.lock.Unlock()
 	}
+	return nil, err
+}
 
-	// 从队头中获取空闲连接
-	ele := p.idle.Front()
-	idlec := ele.Value.(*Conn)
-	p.idle.Remove(ele)
-	p.lock.Unlock()
+// wakeup 处理等待者被唤醒时拿到的连接,c为nil表示拿到了一个新建连接的名额
+func (p *ThriftPool) wakeup(c *Conn) (*Conn, error) {
+	if c != nil {
+		return p.checkConn(c)
+	}
+	// 连接池被释放时会用nil唤醒所有等待者
+	if atomic.LoadUint32(&p.status) == uint32(PoolStatus_Stoped) {
+		return nil, ErrPoolClosed
+	}
+	return p.createConn()
+}
 
-	// 连接从空闲队列获取，可能已经关闭了，这里再重新检查一遍
-	if !idlec.Check() {
-		atomic.AddInt32(&p.count, -1)
+// giveBack 归还等待者拿到但不再使用的连接或名额
+func (p *ThriftPool) giveBack(c *Conn) {
+	if c != nil {
+		p.Put(c)
+		return
+	}
+	if atomic.LoadUint32(&p.status) == uint32(PoolStatus_Stoped) {
+		return
+	}
+	p.releaseSlot()
+}
+
+// createConn 使用已经占用的名额新建连接,失败时释放名额
+func (p *ThriftPool) createConn() (*Conn, error) {
+	client, err := newConn(p.config, p.httpClient)
+	if err != nil {
+		p.releaseSlot()
+		return nil, err
+	}
+	// 检查连接是否有效
+	if !client.Check() {
+		p.releaseSlot()
+		return nil, ErrSocketDisconnect
+	}
+	p.stats.created.Add(1)
+	return client, nil
+}
+
+// checkConn 检查从空闲队列或其他调用方处拿到的连接,连接可能已经关闭了,这里再重新检查一遍
+func (p *ThriftPool) checkConn(c *Conn) (*Conn, error) {
+	if !c.Check() {
+		p.releaseSlot()
 		return nil, ErrSocketDisconnect
 	}
-	return idlec, nil
+	return c, nil
 }
 
-//Put 归还Thrift客户端
+// handoff 将连接或名额(conn为nil)直接交给等待队列队头的调用方,没有等待者时返回false
+// 调用时必须持有锁
+func (p *ThriftPool) handoff(conn *Conn) bool {
+	ele := p.waiters.Front()
+	if ele == nil {
+		return false
+	}
+	p.waiters.Remove(ele)
+	ele.Value.(chan *Conn) <- conn
+	return true
+}
+
+// releaseSlot 释放一个连接名额,有等待者时名额直接转交给队头的等待者
+// 连接池被释放时计数已经清零,此时不再减少计数
+func (p *ThriftPool) releaseSlot() {
+	p.lock.Lock()
+	if atomic.LoadUint32(&p.status) == uint32(PoolStatus_Stoped) {
+		p.lock.Unlock()
+		return
+	}
+	if !p.handoff(nil) {
+		atomic.AddInt32(&p.count, -1)
+	}
+	p.lock.Unlock()
+}
+
+//Put 归还Thrift客户端,有等待者时连接直接交给队头的等待者
 func (p *ThriftPool) Put(client *Conn) error {
 	if client == nil {
 		return nil
 	}
 
+	p.lock.Lock()
 	if atomic.LoadUint32(&p.status) == uint32(PoolStatus_Stoped) {
-		err := client.Close()
-		client = nil
-		return err
+		p.lock.Unlock()
+		return client.Close()
 	}
-
-	if atomic.LoadInt32(&p.count) > p.config.MaxConn || !client.Check() {
+	if atomic.LoadInt32(&p.count) > p.config.MaxConn {
 		atomic.AddInt32(&p.count, -1)
+		p.lock.Unlock()
+		return client.Close()
+	}
+	p.lock.Unlock()
+	if !client.Check() {
 		err := client.Close()
 		client = nil
+		p.releaseSlot()
 		return err
 	}
 
 	p.lock.Lock()
-	client.t = nowFunc()
-	p.idle.PushFront(client)
+	// 检查连接期间连接池可能已经被释放
+	if atomic.LoadUint32(&p.status) == uint32(PoolStatus_Stoped) {
+		p.lock.Unlock()
+		return client.Close()
+	}
+	if !p.handoff(client) {
+		client.t = nowFunc()
+		p.idle.PushFront(client)
+	}
 	p.lock.Unlock()
 
 	return nil
 }
 
 // 关闭有问题的连接，并创建新的连接
+// Deprecated: Client已改为通过CloseConn关闭出错的连接并重新从连接池获取,该方法不计入ReconnectCount
 func (p *ThriftPool) Reconnect(client *Conn) (newClient *Conn, err error) {
 	if client != nil {
 		client.Close()
 	}
 	client = nil
-	newClient, err = NewConn(p.config.Addr, p.config.User, p.config.Passwd)
+	newClient, err = newConn(p.config, p.httpClient)
 	if err != nil {
-		atomic.AddInt32(&p.count, -1)
+		p.releaseSlot()
 		return
 	}
 	if !newClient.Check() {
-		atomic.AddInt32(&p.count, -1)
+		p.releaseSlot()
 		return nil, ErrSocketDisconnect
 	}
 	return
 }
 
-//CloseConn 关闭指定连接
+//CloseConn 关闭指定连接,释放出的名额会交给等待者,计入ReconnectCount
 func (p *ThriftPool) CloseConn(client *Conn) {
 	if client != nil {
 		client.Close()
 	}
-	atomic.AddInt32(&p.count, -1)
+	p.stats.reconnects.Add(1)
+	p.releaseSlot()
 }
 
 //GetIdleCount 获取现在闲置连接个数
@@ -256,18 +443,48 @@ func (p *ThriftPool) GetConnCount() int32 {
 	return 0
 }
 
-//Release 释放连接池
+// Stats 获取连接池统计信息
+func (p *ThriftPool) Stats() PoolStats {
+	p.lock.Lock()
+	idle, waiting, maxConn := p.idle.Len(), p.waiters.Len(), p.config.MaxConn
+	p.lock.Unlock()
+	open := atomic.LoadInt32(&p.count)
+	inUse := int(open) - idle
+	if inUse < 0 {
+		inUse = 0
+	}
+	return PoolStats{
+		MaxConn:         maxConn,
+		OpenConnections: open,
+		Idle:            idle,
+		InUse:           inUse,
+		Waiting:         waiting,
+		Created:         p.stats.created.Load(),
+		WaitCount:       p.stats.waitCount.Load(),
+		WaitDuration:    time.Duration(p.stats.waitDuration.Load()),
+		OverMaxCount:    p.stats.overMax.Load(),
+		ReconnectCount:  p.stats.reconnects.Load(),
+		IdleClosed:      p.stats.idleClosed.Load(),
+	}
+}
+
+//Release 释放连接池,正在等待连接的调用方会收到ErrPoolClosed
 func (p *ThriftPool) Release() {
+	// 在锁内修改状态,releaseSlot和Put在锁内看到Stoped后不会再修改计数
+	p.lock.Lock()
 	atomic.StoreUint32(&p.status, uint32(PoolStatus_Stoped))
 	atomic.StoreInt32(&p.count, 0)
-
-	p.lock.Lock()
-	idle := p.idle
+	idle := make([]*Conn, 0, p.idle.Len())
+	for iter := p.idle.Front(); iter != nil; iter = iter.Next() {
+		idle = append(idle, iter.Value.(*Conn))
+	}
 	p.idle.Init()
+	for p.handoff(nil) {
+	}
 	p.lock.Unlock()
 
-	for iter := idle.Front(); iter != nil; iter = iter.Next() {
-		iter.Value.(*Conn).Close()
+	for _, c := range idle {
+		c.Close()
 	}
 }
 
@@ -0,0 +1,228 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestPool 创建不会真正发起请求的http连接池,http传输层的连接在发出请求前一直有效
+func newTestPool(t *testing.T, maxConn int32) *ThriftPool {
+	t.Helper()
+	p := NewThriftPool(&ThriftPoolConfig{
+		Addr:    "http://127.0.0.1:1",
+		MaxConn: maxConn,
+		Timeout: 5 * time.Second,
+	})
+	t.Cleanup(p.Release)
+	return p
+}
+
+// waitForWaiters 等待连接池的等待队列达到n个
+func waitForWaiters(t *testing.T, p *ThriftPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Stats().Waiting != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待队列长度为%d,期望%d", p.Stats().Waiting, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolWakesWaitersInFIFOOrder(t *testing.T) {
+	p := newTestPool(t, 1)
+	held, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const waiters = 5
+	order := make(chan int, waiters)
+	var wg sync.WaitGroup
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := p.Get()
+			if err != nil {
+				t.Errorf("等待者%d获取连接失败: %v", i, err)
+				return
+			}
+			order <- i
+			p.Put(c)
+		}(i)
+		// 前一个等待者入队后再启动下一个,保证入队顺序
+		waitForWaiters(t, p, i+1)
+	}
+	p.Put(held)
+	wg.Wait()
+	close(order)
+
+	want := 0
+	for got := range order {
+		if got != want {
+			t.Fatalf("第%d个被唤醒的是等待者%d", want, got)
+		}
+		want++
+	}
+	if want != waiters {
+		t.Fatalf("只有%d个等待者被唤醒", want)
+	}
+	if stats := p.Stats(); stats.OpenConnections != 1 || stats.Idle != 1 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolCanceledWaiterDoesNotLeakSlot(t *testing.T) {
+	p := newTestPool(t, 1)
+	held, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.GetContext(ctx)
+		errc <- err
+	}()
+	waitForWaiters(t, p, 1)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if stats := p.Stats(); stats.OpenConnections != 1 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats after cancel %+v", stats)
+	}
+
+	// 取消与归还同时发生时,被取消的等待者拿到的连接要转交出去而不是丢掉
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		got := make(chan *Conn, 1)
+		go func() {
+			c, _ := p.GetContext(ctx)
+			got <- c
+		}()
+		waitForWaiters(t, p, 1)
+		go cancel()
+		p.Put(held)
+		if c := <-got; c != nil {
+			held = c
+			continue
+		}
+		if held, err = p.Get(); err != nil {
+			t.Fatalf("第%d次: %v", i, err)
+		}
+		cancel()
+	}
+	if n := p.GetConnCount(); n != 1 {
+		t.Fatalf("conn count = %d, want 1", n)
+	}
+	p.Put(held)
+	if stats := p.Stats(); stats.OpenConnections != 1 || stats.Idle != 1 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolPutPastMaxConn(t *testing.T) {
+	p := newTestPool(t, 2)
+	c1, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 调小最大连接数后已经借出的连接超额了,超额的连接归还时要关闭并计数减1
+	p.lock.Lock()
+	p.config.MaxConn = 1
+	p.lock.Unlock()
+
+	if err := p.Put(c1); err != nil {
+		t.Fatal(err)
+	}
+	if stats := p.Stats(); stats.OpenConnections != 1 || stats.Idle != 0 {
+		t.Fatalf("unexpected stats after first put %+v", stats)
+	}
+	if err := p.Put(c2); err != nil {
+		t.Fatal(err)
+	}
+	if stats := p.Stats(); stats.OpenConnections != 1 || stats.Idle != 1 || stats.InUse != 0 {
+		t.Fatalf("unexpected stats after second put %+v", stats)
+	}
+
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != c2 {
+		t.Fatal("没有取到空闲的连接")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.GetContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	p.Put(c)
+	if n := p.GetConnCount(); n != 1 {
+		t.Fatalf("conn count = %d, want 1", n)
+	}
+}
+
+func TestPoolReleaseWithCheckedOutConns(t *testing.T) {
+	p := newTestPool(t, 2)
+	c1, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release()
+	// 释放后归还或关闭借出的连接不能让计数变成负数
+	p.CloseConn(c1)
+	c2.Close()
+	p.Put(c2)
+	if n := p.GetConnCount(); n != 0 {
+		t.Fatalf("释放后连接数为%d,期望0", n)
+	}
+	p.Recover()
+	for i := 0; i < 2; i++ {
+		if _, err := p.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := p.get(context.Background(), time.Now().Add(20*time.Millisecond)); !errors.Is(err, ErrOverMax) {
+		t.Fatalf("超过MaxConn时错误为%v,期望ErrOverMax", err)
+	}
+}
+
+func TestPoolReconnectCount(t *testing.T) {
+	p := newTestPool(t, 2)
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CloseConn关闭出错的连接,每次只计一次
+	p.CloseConn(c)
+	if stats := p.Stats(); stats.ReconnectCount != 1 || stats.OpenConnections != 0 {
+		t.Fatalf("unexpected stats after CloseConn %+v", stats)
+	}
+	c, err = p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = p.Reconnect(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Put(c)
+	if stats := p.Stats(); stats.ReconnectCount != 1 || stats.OpenConnections != 1 {
+		t.Fatalf("unexpected stats after Reconnect %+v", stats)
+	}
+}
@@ -0,0 +1,289 @@
+// hbase客户端的Prometheus指标
+// 导出连接池的连接数,等待和淘汰统计,以及按操作和表统计的耗时,错误数和收发字节数
+//
+//	m := promhbase.NewMetrics()
+//	c, err := aliexhbase.New(aliexhbase.WithURL(...), m.Instrument())
+//	m.ObservePool(c)
+//	err = m.Register(prometheus.DefaultRegisterer)
+package promhbase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase"
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 指标的标签名
+const (
+	labelOperation = "operation"
+	labelTable     = "table"
+	labelKind      = "kind"
+)
+
+// kinds 错误类型对应的标签值
+var kinds = []struct {
+	err  error
+	name string
+}{
+	{exceptions.ErrTableNotFound, "table_not_found"},
+	{exceptions.ErrTableExists, "table_exists"},
+	{exceptions.ErrNamespaceNotFound, "namespace_not_found"},
+	{exceptions.ErrTableDisabled, "table_disabled"},
+	{exceptions.ErrScannerExpired, "scanner_expired"},
+	{exceptions.ErrRegionUnavailable, "region_unavailable"},
+	{exceptions.ErrThrottled, "throttled"},
+	{exceptions.ErrAuthFailed, "auth_failed"},
+	{exceptions.ErrTimeout, "timeout"},
+	{exceptions.ErrIllegalArgument, "illegal_argument"},
+	{exceptions.ErrIO, "io"},
+	{exceptions.ErrTransport, "transport"},
+	{context.Canceled, "canceled"},
+}
+
+// errorKind 获取错误类型对应的标签值
+func errorKind(err error) string {
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return k.name
+		}
+	}
+	return "unknown"
+}
+
+// PoolStatser 可以提供连接池统计信息的对象,*aliexhbase.Client实现了该接口
+type PoolStatser interface {
+	PoolStats() aliexhbase.PoolStats
+}
+
+// config 指标配置
+type config struct {
+	namespace   string
+	subsystem   string
+	constLabels prometheus.Labels
+	buckets     []float64
+	waitBuckets []float64
+}
+
+// Option 指标配置项
+type Option interface {
+	apply(*config)
+}
+
+type funcOption func(*config)
+
+func (f funcOption) apply(c *config) {
+	f(c)
+}
+
+// WithNamespace 设置指标名的命名空间,默认为空
+func WithNamespace(namespace string) Option {
+	return funcOption(func(c *config) {
+		c.namespace = namespace
+	})
+}
+
+// WithSubsystem 设置指标名的子系统,默认为`hbase_client`
+func WithSubsystem(subsystem string) Option {
+	return funcOption(func(c *config) {
+		c.subsystem = subsystem
+	})
+}
+
+// WithConstLabels 设置所有指标共有的固定标签,同一进程中有多个客户端时可以用来区分
+func WithConstLabels(labels prometheus.Labels) Option {
+	return funcOption(func(c *config) {
+		c.constLabels = labels
+	})
+}
+
+// WithDurationBuckets 设置操作耗时直方图的分桶,单位s
+func WithDurationBuckets(buckets []float64) Option {
+	return funcOption(func(c *config) {
+		c.buckets = buckets
+	})
+}
+
+// WithPoolWaitBuckets 设置连接池等待时间直方图的分桶,单位s
+func WithPoolWaitBuckets(buckets []float64) Option {
+	return funcOption(func(c *config) {
+		c.waitBuckets = buckets
+	})
+}
+
+// Metrics hbase客户端的指标集合,实现了prometheus.Collector
+type Metrics struct {
+	duration      *prometheus.HistogramVec
+	errors        *prometheus.CounterVec
+	bytesSent     *prometheus.CounterVec
+	bytesReceived *prometheus.CounterVec
+	poolWait      prometheus.Histogram
+
+	maxConn      *prometheus.Desc
+	openConns    *prometheus.Desc
+	idleConns    *prometheus.Desc
+	inUseConns   *prometheus.Desc
+	waiting      *prometheus.Desc
+	created      *prometheus.Desc
+	waitCount    *prometheus.Desc
+	overMax      *prometheus.Desc
+	errorClosed  *prometheus.Desc
+	idleEviction *prometheus.Desc
+
+	lock sync.RWMutex
+	pool PoolStatser
+}
+
+// NewMetrics 创建指标集合
+func NewMetrics(opts ...Option) *Metrics {
+	c := &config{
+		subsystem:   "hbase_client",
+		buckets:     prometheus.DefBuckets,
+		waitBuckets: []float64{.0005, .001, .005, .01, .05, .1, .5, 1, 5},
+	}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	name := func(n string) string {
+		return prometheus.BuildFQName(c.namespace, c.subsystem, n)
+	}
+	desc := func(n, help string) *prometheus.Desc {
+		return prometheus.NewDesc(name(n), help, nil, c.constLabels)
+	}
+	opLabels := []string{labelOperation, labelTable}
+	return &Metrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Subsystem:   c.subsystem,
+			Name:        "operation_duration_seconds",
+			Help:        "hbase操作耗时,包含重试和等待连接的时间",
+			ConstLabels: c.constLabels,
+			Buckets:     c.buckets,
+		}, opLabels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Subsystem:   c.subsystem,
+			Name:        "operation_errors_total",
+			Help:        "hbase操作失败次数",
+			ConstLabels: c.constLabels,
+		}, append(opLabels, labelKind)),
+		bytesSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Subsystem:   c.subsystem,
+			Name:        "sent_bytes_total",
+			Help:        "hbase操作发送的字节数",
+			ConstLabels: c.constLabels,
+		}, opLabels),
+		bytesReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Subsystem:   c.subsystem,
+			Name:        "received_bytes_total",
+			Help:        "hbase操作接收的字节数",
+			ConstLabels: c.constLabels,
+		}, opLabels),
+		poolWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Subsystem:   c.subsystem,
+			Name:        "pool_wait_seconds",
+			Help:        "每次操作从连接池获取连接的等待时间",
+			ConstLabels: c.constLabels,
+			Buckets:     c.waitBuckets,
+		}),
+		maxConn:      desc("pool_max_connections", "连接池最大连接数"),
+		openConns:    desc("pool_open_connections", "连接池当前连接数"),
+		idleConns:    desc("pool_idle_connections", "连接池空闲连接数"),
+		inUseConns:   desc("pool_in_use_connections", "连接池正在使用的连接数"),
+		waiting:      desc("pool_waiting", "正在等待连接的调用方数量"),
+		created:      desc("pool_connections_created_total", "连接池累计新建的连接数"),
+		waitCount:    desc("pool_waits_total", "连接池已满需要等待连接的次数"),
+		overMax:      desc("pool_over_max_total", "等待连接超时(ErrOverMax)的次数"),
+		errorClosed:  desc("pool_connections_closed_total", "连接出错被关闭的连接数,不代表已重新建立连接"),
+		idleEviction: desc("pool_idle_evictions_total", "空闲超时被关闭的连接数"),
+	}
+}
+
+// Interceptor 记录操作指标的拦截器
+func (m *Metrics) Interceptor() aliexhbase.Interceptor {
+	return func(ctx context.Context, op *aliexhbase.Operation, invoke aliexhbase.Invoker) error {
+		start := time.Now()
+		err := invoke(ctx, op)
+		table := string(op.Table)
+		m.duration.WithLabelValues(op.Name, table).Observe(time.Since(start).Seconds())
+		m.poolWait.Observe(op.PoolWait.Seconds())
+		m.bytesSent.WithLabelValues(op.Name, table).Add(float64(op.BytesSent))
+		m.bytesReceived.WithLabelValues(op.Name, table).Add(float64(op.BytesReceived))
+		if err != nil {
+			m.errors.WithLabelValues(op.Name, table, errorKind(err)).Inc()
+		}
+		return err
+	}
+}
+
+// Instrument 注册记录操作指标拦截器的客户端配置项
+func (m *Metrics) Instrument() aliexhbase.Option {
+	return aliexhbase.WithInterceptors(m.Interceptor())
+}
+
+// ObservePool 设置连接池指标的来源,未设置时不导出连接池指标
+func (m *Metrics) ObservePool(p PoolStatser) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.pool = p
+}
+
+// Register 将指标注册到reg
+func (m *Metrics) Register(reg prometheus.Registerer) error {
+	return reg.Register(m)
+}
+
+// Describe 实现prometheus.Collector
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.duration.Describe(ch)
+	m.errors.Describe(ch)
+	m.bytesSent.Describe(ch)
+	m.bytesReceived.Describe(ch)
+	m.poolWait.Describe(ch)
+	for _, d := range []*prometheus.Desc{
+		m.maxConn, m.openConns, m.idleConns, m.inUseConns, m.waiting,
+		m.created, m.waitCount, m.overMax, m.errorClosed, m.idleEviction,
+	} {
+		ch <- d
+	}
+}
+
+// Collect 实现prometheus.Collector
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.duration.Collect(ch)
+	m.errors.Collect(ch)
+	m.bytesSent.Collect(ch)
+	m.bytesReceived.Collect(ch)
+	m.poolWait.Collect(ch)
+
+	m.lock.RLock()
+	pool := m.pool
+	m.lock.RUnlock()
+	if pool == nil {
+		return
+	}
+	s := pool.PoolStats()
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge(m.maxConn, float64(s.MaxConn))
+	gauge(m.openConns, float64(s.OpenConnections))
+	gauge(m.idleConns, float64(s.Idle))
+	gauge(m.inUseConns, float64(s.InUse))
+	gauge(m.waiting, float64(s.Waiting))
+	counter(m.created, float64(s.Created))
+	counter(m.waitCount, float64(s.WaitCount))
+	counter(m.overMax, float64(s.OverMaxCount))
+	counter(m.errorClosed, float64(s.ReconnectCount))
+	counter(m.idleEviction, float64(s.IdleClosed))
+}
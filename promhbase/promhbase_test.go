@@ -0,0 +1,154 @@
+package promhbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase"
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// fakePool 固定的连接池统计信息
+type fakePool aliexhbase.PoolStats
+
+func (p fakePool) PoolStats() aliexhbase.PoolStats {
+	return aliexhbase.PoolStats(p)
+}
+
+// call 通过拦截器执行一次假操作
+func call(m *Metrics, name, table string, sent, received int64, err error) {
+	op := &aliexhbase.Operation{Name: name, Table: []byte(table)}
+	m.Interceptor()(context.Background(), op, func(ctx context.Context, op *aliexhbase.Operation) error {
+		op.BytesSent = sent
+		op.BytesReceived = received
+		return err
+	})
+}
+
+func TestOperationMetrics(t *testing.T) {
+	m := NewMetrics(WithNamespace("app"), WithConstLabels(prometheus.Labels{"cluster": "c1"}))
+	notFound := exceptions.Wrap(&hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.TableNotFoundException: t2")}, "Get", []byte("t2"), nil)
+	call(m, "Get", "t1", 10, 100, nil)
+	call(m, "Get", "t1", 10, 50, nil)
+	call(m, "Get", "t2", 10, 0, notFound)
+	call(m, "Put", "t1", 30, 0, fmt.Errorf("put: %w", context.Canceled))
+	call(m, "Put", "t1", 30, 0, errors.New("boom"))
+	expired := exceptions.Wrap(&hbase.TIllegalArgument{Message: thrift.StringPtr("Invalid scanner Id")}, "GetScannerRows", nil, nil)
+	call(m, "GetScannerRows", "", 0, 0, expired)
+
+	want := `
+# HELP app_hbase_client_operation_errors_total hbase操作失败次数
+# TYPE app_hbase_client_operation_errors_total counter
+app_hbase_client_operation_errors_total{cluster="c1",kind="canceled",operation="Put",table="t1"} 1
+app_hbase_client_operation_errors_total{cluster="c1",kind="scanner_expired",operation="GetScannerRows",table=""} 1
+app_hbase_client_operation_errors_total{cluster="c1",kind="table_not_found",operation="Get",table="t2"} 1
+app_hbase_client_operation_errors_total{cluster="c1",kind="unknown",operation="Put",table="t1"} 1
+# HELP app_hbase_client_sent_bytes_total hbase操作发送的字节数
+# TYPE app_hbase_client_sent_bytes_total counter
+app_hbase_client_sent_bytes_total{cluster="c1",operation="Get",table="t1"} 20
+app_hbase_client_sent_bytes_total{cluster="c1",operation="Get",table="t2"} 10
+app_hbase_client_sent_bytes_total{cluster="c1",operation="GetScannerRows",table=""} 0
+app_hbase_client_sent_bytes_total{cluster="c1",operation="Put",table="t1"} 60
+# HELP app_hbase_client_received_bytes_total hbase操作接收的字节数
+# TYPE app_hbase_client_received_bytes_total counter
+app_hbase_client_received_bytes_total{cluster="c1",operation="Get",table="t1"} 150
+app_hbase_client_received_bytes_total{cluster="c1",operation="Get",table="t2"} 0
+app_hbase_client_received_bytes_total{cluster="c1",operation="GetScannerRows",table=""} 0
+app_hbase_client_received_bytes_total{cluster="c1",operation="Put",table="t1"} 0
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want),
+		"app_hbase_client_operation_errors_total",
+		"app_hbase_client_sent_bytes_total",
+		"app_hbase_client_received_bytes_total",
+	); err != nil {
+		t.Fatal(err)
+	}
+	// 耗时直方图按操作和表各一个,连接池等待时间只有一个
+	if n := testutil.CollectAndCount(m, "app_hbase_client_operation_duration_seconds"); n != 4 {
+		t.Fatalf("operation_duration_seconds有%d个序列,期望4个", n)
+	}
+	if n := testutil.CollectAndCount(m, "app_hbase_client_pool_wait_seconds"); n != 1 {
+		t.Fatalf("pool_wait_seconds有%d个序列,期望1个", n)
+	}
+	// 未设置连接池时不导出连接池指标
+	if n := testutil.CollectAndCount(m, "app_hbase_client_pool_open_connections"); n != 0 {
+		t.Fatalf("pool_open_connections有%d个序列,期望0个", n)
+	}
+}
+
+func TestPoolMetrics(t *testing.T) {
+	m := NewMetrics()
+	m.ObservePool(fakePool{
+		MaxConn:         60,
+		OpenConnections: 5,
+		Idle:            2,
+		InUse:           3,
+		Waiting:         1,
+		Created:         9,
+		WaitCount:       4,
+		OverMaxCount:    1,
+		ReconnectCount:  2,
+		IdleClosed:      3,
+	})
+	want := `
+# HELP hbase_client_pool_max_connections 连接池最大连接数
+# TYPE hbase_client_pool_max_connections gauge
+hbase_client_pool_max_connections 60
+# HELP hbase_client_pool_open_connections 连接池当前连接数
+# TYPE hbase_client_pool_open_connections gauge
+hbase_client_pool_open_connections 5
+# HELP hbase_client_pool_idle_connections 连接池空闲连接数
+# TYPE hbase_client_pool_idle_connections gauge
+hbase_client_pool_idle_connections 2
+# HELP hbase_client_pool_in_use_connections 连接池正在使用的连接数
+# TYPE hbase_client_pool_in_use_connections gauge
+hbase_client_pool_in_use_connections 3
+# HELP hbase_client_pool_waiting 正在等待连接的调用方数量
+# TYPE hbase_client_pool_waiting gauge
+hbase_client_pool_waiting 1
+# HELP hbase_client_pool_connections_created_total 连接池累计新建的连接数
+# TYPE hbase_client_pool_connections_created_total counter
+hbase_client_pool_connections_created_total 9
+# HELP hbase_client_pool_waits_total 连接池已满需要等待连接的次数
+# TYPE hbase_client_pool_waits_total counter
+hbase_client_pool_waits_total 4
+# HELP hbase_client_pool_over_max_total 等待连接超时(ErrOverMax)的次数
+# TYPE hbase_client_pool_over_max_total counter
+hbase_client_pool_over_max_total 1
+# HELP hbase_client_pool_connections_closed_total 连接出错被关闭的连接数,不代表已重新建立连接
+# TYPE hbase_client_pool_connections_closed_total counter
+hbase_client_pool_connections_closed_total 2
+# HELP hbase_client_pool_idle_evictions_total 空闲超时被关闭的连接数
+# TYPE hbase_client_pool_idle_evictions_total counter
+hbase_client_pool_idle_evictions_total 3
+`
+	if err := testutil.CollectAndCompare(m, strings.NewReader(want),
+		"hbase_client_pool_max_connections",
+		"hbase_client_pool_open_connections",
+		"hbase_client_pool_idle_connections",
+		"hbase_client_pool_in_use_connections",
+		"hbase_client_pool_waiting",
+		"hbase_client_pool_connections_created_total",
+		"hbase_client_pool_waits_total",
+		"hbase_client_pool_over_max_total",
+		"hbase_client_pool_connections_closed_total",
+		"hbase_client_pool_idle_evictions_total",
+	); err != nil {
+		t.Fatal(err)
+	}
+	// Describe和Collect一致,可以注册到Registry
+	reg := prometheus.NewPedanticRegistry()
+	if err := m.Register(reg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.Gather(); err != nil {
+		t.Fatal(err)
+	}
+}
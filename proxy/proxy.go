@@ -28,6 +28,7 @@ func (proxy *Proxy) IsOk() bool {
 // Init 初始化代理对象
 func (proxy *Proxy) Init(opts ...aliexhbase.Option) error {
 	o := aliexhbase.DefaultOptions
+	o.Poolconfig = o.Poolconfig.Clone()
 	for _, opt := range opts {
 		opt.Apply(&o)
 	}
@@ -0,0 +1,129 @@
+// 重试策略定义
+package aliexhbase
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// RetryPolicy 请求失败时的重试策略
+type RetryPolicy struct {
+	// 最大尝试次数,包括第一次请求,小于等于1时不重试
+	MaxAttempts int
+	// 第一次重试前的等待时间
+	InitialBackoff time.Duration
+	// 重试等待时间的上限,为0时不限制
+	MaxBackoff time.Duration
+	// 每次重试等待时间的增长倍数,小于1时按1处理
+	Multiplier float64
+	// 等待时间的随机抖动比例,取值0~1,实际等待时间在[backoff*(1-Jitter),backoff]之间
+	Jitter float64
+	// 判断错误是否可以重试,为nil时重试网络错误,thrift传输层错误,限流和region不可用
+	Retryable func(err error) bool
+	// 为true时非幂等的操作(Increment,Append,CheckAnd*等)也会重试
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy 默认的重试策略,网络错误,thrift传输层错误,限流和region不可用时重试一次
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    2,
+	InitialBackoff: time.Millisecond * 50,
+	MaxBackoff:     time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// httpStatusPrefix http传输层响应状态码不为200时TTransportException的错误信息前缀
+const httpStatusPrefix = "HTTP Response code: "
+
+// isHTTPStatusError 判断是否为http传输层返回的状态码错误,这类错误发生时连接本身仍然可用
+func isHTTPStatusError(err error) bool {
+	tErr, ok := err.(thrift.TTransportException)
+	return ok && strings.HasPrefix(tErr.Error(), httpStatusPrefix)
+}
+
+// isConnError 判断是否为连接相关的错误,出现这类错误的连接不能再继续使用
+// http状态码错误,认证失败和请求参数错误不是连接的问题
+func isConnError(err error) bool {
+	if kind := exceptions.Classify(err); kind == exceptions.ErrAuthFailed || kind == exceptions.ErrIllegalArgument {
+		return false
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	_, ok := err.(thrift.TTransportException)
+	return ok && !isHTTPStatusError(err)
+}
+
+// retryable 判断错误是否可以重试
+func (r *RetryPolicy) retryable(err error) bool {
+	if r.Retryable != nil {
+		return r.Retryable(err)
+	}
+	kind := exceptions.Classify(err)
+	// 认证失败和请求参数错误重试也不会成功
+	if kind == exceptions.ErrAuthFailed || kind == exceptions.ErrIllegalArgument {
+		return false
+	}
+	if isConnError(err) || isHTTPStatusError(err) {
+		return true
+	}
+	return kind == exceptions.ErrThrottled || kind == exceptions.ErrRegionUnavailable
+}
+
+// Backoff 计算第attempt次请求失败后重试前的等待时间
+func (r *RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := r.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(r.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if r.MaxBackoff > 0 && backoff > float64(r.MaxBackoff) {
+		backoff = float64(r.MaxBackoff)
+	}
+	if r.Jitter > 0 {
+		backoff -= backoff * math.Min(r.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// shouldRetry 判断第attempt次请求失败后是否应该重试
+func (r *RetryPolicy) shouldRetry(ctx context.Context, op *Operation, attempt int, err error) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	// 调用方已经放弃请求
+	if ctx.Err() != nil {
+		return false
+	}
+	if !r.RetryNonIdempotent && !op.Idempotent() {
+		return false
+	}
+	return r.retryable(err)
+}
+
+// wait 重试前等待,等待结束前ctx就会到达截止时间或被取消时返回false
+func (r *RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	backoff := r.Backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && nowFunc().Add(backoff).After(deadline) {
+		return false
+	}
+	if backoff <= 0 {
+		return true
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
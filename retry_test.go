@@ -0,0 +1,275 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+	logrus "github.com/sirupsen/logrus"
+)
+
+func TestBackoff(t *testing.T) {
+	r := RetryPolicy{InitialBackoff: 50 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	for attempt, want := range []time.Duration{50, 100, 200, 300, 300} {
+		if got := r.Backoff(attempt + 1); got != want*time.Millisecond {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt+1, got, want*time.Millisecond)
+		}
+	}
+	// 倍数小于1时按1处理,不设上限
+	r = RetryPolicy{InitialBackoff: 50 * time.Millisecond, Multiplier: 0.5}
+	if got := r.Backoff(5); got != 50*time.Millisecond {
+		t.Errorf("Backoff(5) = %v, want 50ms", got)
+	}
+
+	for _, tc := range []struct {
+		jitter   float64
+		min, max time.Duration
+	}{
+		{0.2, 80 * time.Millisecond, 100 * time.Millisecond},
+		{1, 0, 100 * time.Millisecond},
+		// 抖动比例超过1时按1处理,等待时间不会为负数
+		{3, 0, 100 * time.Millisecond},
+	} {
+		r := RetryPolicy{InitialBackoff: 100 * time.Millisecond, Multiplier: 2, Jitter: tc.jitter}
+		for i := 0; i < 1000; i++ {
+			if got := r.Backoff(1); got < tc.min || got > tc.max {
+				t.Fatalf("Jitter %v: Backoff(1) = %v, want [%v,%v]", tc.jitter, got, tc.min, tc.max)
+			}
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	r := DefaultRetryPolicy
+	for _, tc := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"net", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"transport", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 502"), true},
+		{"http unauthorized", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 401"), false},
+		{"http forbidden", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 403"), false},
+		{"http bad request", thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "HTTP Response code: 400"), false},
+		{"throttled", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.quotas.RpcThrottlingException: throttled")}, true},
+		{"region unavailable", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.NotServingRegionException: t,,1")}, true},
+		{"table not found", &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.TableNotFoundException: t")}, false},
+		{"illegal argument", &hbase.TIllegalArgument{Message: thrift.StringPtr("bad")}, false},
+		{"other", errors.New("other"), false},
+	} {
+		if got := r.retryable(tc.err); got != tc.want {
+			t.Errorf("%s: retryable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	// 自定义的判断代替默认规则
+	r.Retryable = func(err error) bool { return errors.Is(err, exceptions.ErrTableNotFound) }
+	if r.retryable(&net.OpError{Op: "dial", Err: errors.New("refused")}) {
+		t.Error("自定义Retryable没有生效")
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	throttled := &hbase.TIOError{Message: thrift.StringPtr("RegionTooBusyException")}
+	r := RetryPolicy{MaxAttempts: 3}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tc := range []struct {
+		name    string
+		ctx     context.Context
+		op      string
+		attempt int
+		want    bool
+	}{
+		{"idempotent", context.Background(), "Get", 1, true},
+		{"last attempt", context.Background(), "Get", 3, false},
+		{"canceled", canceled, "Get", 1, false},
+		{"Increment", context.Background(), "Increment", 1, false},
+		{"Append", context.Background(), "Append", 1, false},
+		{"CheckAndPut", context.Background(), "CheckAndPut", 1, false},
+		{"CheckAndDelete", context.Background(), "CheckAndDelete", 1, false},
+		{"CheckAndMutate", context.Background(), "CheckAndMutate", 1, false},
+		{"GetScannerRows", context.Background(), "GetScannerRows", 1, false},
+		{"CreateTable", context.Background(), "CreateTable", 1, false},
+	} {
+		if got := r.shouldRetry(tc.ctx, &Operation{Name: tc.op}, tc.attempt, throttled); got != tc.want {
+			t.Errorf("%s: shouldRetry = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	r.RetryNonIdempotent = true
+	if !r.shouldRetry(context.Background(), &Operation{Name: "Increment"}, 1, throttled) {
+		t.Error("RetryNonIdempotent时Increment没有重试")
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	r := RetryPolicy{InitialBackoff: time.Second}
+	// 等待结束前就会到达截止时间时不等待
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if r.wait(ctx, 1) {
+		t.Fatal("wait() = true, want false")
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("等待了%v", elapsed)
+	}
+
+	// 等待期间被取消
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start = time.Now()
+	if r.wait(ctx, 1) {
+		t.Fatal("wait() = true, want false")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("取消后等待了%v", elapsed)
+	}
+
+	r = RetryPolicy{InitialBackoff: 10 * time.Millisecond}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start = time.Now()
+	if !r.wait(ctx, 1) || time.Since(start) < 10*time.Millisecond {
+		t.Fatal("没有按退避时间等待")
+	}
+	if !(&RetryPolicy{}).wait(context.Background(), 1) {
+		t.Fatal("退避时间为0时wait() = false")
+	}
+}
+
+// busyHandler 总是返回限流错误并记录每个操作被调用次数的假服务端
+type busyHandler struct {
+	hbase.THBaseService
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (h *busyHandler) busy(op string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls[op]++
+	return &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.RegionTooBusyException: busy")}
+}
+
+func (h *busyHandler) Get(ctx context.Context, table []byte, tget *hbase.TGet) (*hbase.TResult_, error) {
+	return nil, h.busy("Get")
+}
+
+func (h *busyHandler) Increment(ctx context.Context, table []byte, tincrement *hbase.TIncrement) (*hbase.TResult_, error) {
+	return nil, h.busy("Increment")
+}
+
+func (h *busyHandler) CheckAndPut(ctx context.Context, table []byte, row []byte, family []byte, qualifier []byte, value []byte, tput *hbase.TPut) (bool, error) {
+	return false, h.busy("CheckAndPut")
+}
+
+func (h *busyHandler) CheckAndDelete(ctx context.Context, table []byte, row []byte, family []byte, qualifier []byte, value []byte, tdelete *hbase.TDelete) (bool, error) {
+	return false, h.busy("CheckAndDelete")
+}
+
+func (h *busyHandler) CheckAndMutate(ctx context.Context, table []byte, row []byte, family []byte, qualifier []byte, compareOp hbase.TCompareOp, value []byte, rowMutations *hbase.TRowMutations) (bool, error) {
+	return false, h.busy("CheckAndMutate")
+}
+
+func (h *busyHandler) GetScannerRows(ctx context.Context, scannerID int32, numRows int32) ([]*hbase.TResult_, error) {
+	return nil, h.busy("GetScannerRows")
+}
+
+func TestClientRetry(t *testing.T) {
+	h := &busyHandler{calls: map[string]int{}}
+	c := newTestClient(t, h, WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	ctx := context.Background()
+	table, row := []byte("t"), []byte("r")
+	calls := map[string]func() error{
+		"Get": func() error {
+			_, err := c.Get(ctx, table, &hbase.TGet{Row: row})
+			return err
+		},
+		"Increment": func() error {
+			_, err := c.Increment(ctx, table, testIncrement("r"))
+			return err
+		},
+		"CheckAndPut": func() error {
+			_, err := c.CheckAndPut(ctx, table, row, []byte("cf"), []byte("q"), nil, &hbase.TPut{Row: row})
+			return err
+		},
+		"CheckAndDelete": func() error {
+			_, err := c.CheckAndDelete(ctx, table, row, []byte("cf"), []byte("q"), nil, &hbase.TDelete{Row: row})
+			return err
+		},
+		"CheckAndMutate": func() error {
+			_, err := c.CheckAndMutate(ctx, table, row, []byte("cf"), []byte("q"), hbase.TCompareOp_EQUAL, nil, &hbase.TRowMutations{Row: row})
+			return err
+		},
+		"GetScannerRows": func() error {
+			_, err := c.GetScannerRows(ctx, 1, 10)
+			return err
+		},
+	}
+	for op, call := range calls {
+		err := call()
+		if !errors.Is(err, exceptions.ErrThrottled) {
+			t.Errorf("%s: err = %v, want ErrThrottled", op, err)
+		}
+		var e *exceptions.Error
+		if !errors.As(err, &e) || e.Op != op {
+			t.Errorf("%s: err = %#v", op, err)
+		}
+	}
+	// 只有幂等的操作会重试,扫描器已经前进的GetScannerRows和CheckAnd*重试可能导致结果不正确
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for op := range calls {
+		want := 1
+		if op == "Get" {
+			want = 3
+		}
+		if h.calls[op] != want {
+			t.Errorf("%s被调用了%d次,期望%d次", op, h.calls[op], want)
+		}
+	}
+}
+
+func TestClientNoRetryOnUnauthorized(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	var attempts int
+	c, err := New(WithURL(srv.URL), WithLogger(logger),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}),
+		WithInterceptors(func(ctx context.Context, op *Operation, invoke Invoker) error {
+			err := invoke(ctx, op)
+			attempts = op.Attempts
+			return err
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.HardClose() })
+	_, err = c.Get(context.Background(), []byte("t"), &hbase.TGet{Row: []byte("r")})
+	if !errors.Is(err, exceptions.ErrAuthFailed) {
+		t.Fatalf("err = %v, want ErrAuthFailed", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 || attempts != 1 {
+		t.Fatalf("请求了%d次,Attempts为%d,期望都为1", n, attempts)
+	}
+	// 认证失败不是连接的问题,连接应该归还而不是关闭
+	if s := c.PoolStats(); s.ReconnectCount != 0 {
+		t.Fatalf("关闭了%d个连接", s.ReconnectCount)
+	}
+}
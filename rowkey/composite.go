@@ -0,0 +1,226 @@
+// 保序的组合行键
+package rowkey
+
+import (
+	"time"
+)
+
+// 变长字段的转义:字段中的0x00写为0x00 0xFF,字段以0x00 0x01结束.
+// 分隔符小于任何转义后的内容,因此较短的字段排在以它为前缀的较长字段前面,组合行键的字节序与各字段依次比较的顺序一致
+const (
+	escapeByte     byte = 0x00
+	escapedZero    byte = 0xFF
+	terminatorByte byte = 0x01
+)
+
+// Key 组合行键构造器
+//
+//	row := rowkey.NewKey().Text(userID).ReverseTime(t).Int64(seq).Build()
+//
+// 变长字段(Bytes,Text)会被转义并加上分隔符,定长字段直接拼接,
+// 以前几个字段构造的行键可以作为前缀扫描的前缀
+type Key struct {
+	buf []byte
+}
+
+// NewKey 创建组合行键构造器
+func NewKey() *Key {
+	return &Key{}
+}
+
+// Raw 直接拼接字节,不转义也不加分隔符,用于哈希前缀等定长内容
+func (k *Key) Raw(b []byte) *Key {
+	k.buf = append(k.buf, b...)
+	return k
+}
+
+// Bytes 拼接变长字节串
+func (k *Key) Bytes(b []byte) *Key {
+	for _, c := range b {
+		if c == escapeByte {
+			k.buf = append(k.buf, escapeByte, escapedZero)
+			continue
+		}
+		k.buf = append(k.buf, c)
+	}
+	k.buf = append(k.buf, escapeByte, terminatorByte)
+	return k
+}
+
+// Text 拼接变长字符串
+func (k *Key) Text(s string) *Key {
+	return k.Bytes([]byte(s))
+}
+
+// Int64 拼接8字节保序编码的int64
+func (k *Key) Int64(v int64) *Key {
+	return k.Raw(Int64(v))
+}
+
+// Uint64 拼接8字节大端序的uint64
+func (k *Key) Uint64(v uint64) *Key {
+	return k.Raw(Uint64(v))
+}
+
+// Int32 拼接4字节保序编码的int32
+func (k *Key) Int32(v int32) *Key {
+	return k.Raw(Int32(v))
+}
+
+// Uint32 拼接4字节大端序的uint32
+func (k *Key) Uint32(v uint32) *Key {
+	return k.Raw(Uint32(v))
+}
+
+// Time 拼接8字节保序编码的毫秒时间戳,越早的时间越靠前
+func (k *Key) Time(t time.Time) *Key {
+	return k.Int64(t.UnixMilli())
+}
+
+// ReverseTime 拼接ReverseTimestamp编码的时间,越新的时间越靠前
+func (k *Key) ReverseTime(t time.Time) *Key {
+	return k.Raw(ReverseTimestamp(t))
+}
+
+// Build 获取行键
+func (k *Key) Build() []byte {
+	return append([]byte(nil), k.buf...)
+}
+
+// KeyReader 按构造时的字段顺序解析组合行键,解析错误会被记录下来,通过Err获取
+type KeyReader struct {
+	buf []byte
+	err error
+}
+
+// NewKeyReader 创建组合行键解析器
+func NewKeyReader(row []byte) *KeyReader {
+	return &KeyReader{buf: row}
+}
+
+// take 读取n个字节
+func (r *KeyReader) take(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if err := checkLen(r.buf, n); err != nil {
+		r.err = err
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+// Raw 读取n个字节
+func (r *KeyReader) Raw(n int) []byte {
+	return append([]byte(nil), r.take(n)...)
+}
+
+// Bytes 读取变长字节串
+func (r *KeyReader) Bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	var b []byte
+	for i := 0; i < len(r.buf); i++ {
+		if r.buf[i] != escapeByte {
+			b = append(b, r.buf[i])
+			continue
+		}
+		if i+1 >= len(r.buf) {
+			break
+		}
+		switch r.buf[i+1] {
+		case escapedZero:
+			b = append(b, escapeByte)
+			i++
+		case terminatorByte:
+			r.buf = r.buf[i+2:]
+			if b == nil {
+				b = []byte{}
+			}
+			return b
+		default:
+			r.err = ErrInvalidEscape
+			return nil
+		}
+	}
+	r.err = ErrNotTerminated
+	return nil
+}
+
+// Text 读取变长字符串
+func (r *KeyReader) Text() string {
+	return string(r.Bytes())
+}
+
+// Int64 读取Int64编码的值
+func (r *KeyReader) Int64() int64 {
+	b := r.take(8)
+	if b == nil {
+		return 0
+	}
+	v, _ := ParseInt64(b)
+	return v
+}
+
+// Uint64 读取Uint64编码的值
+func (r *KeyReader) Uint64() uint64 {
+	b := r.take(8)
+	if b == nil {
+		return 0
+	}
+	v, _ := ParseUint64(b)
+	return v
+}
+
+// Int32 读取Int32编码的值
+func (r *KeyReader) Int32() int32 {
+	b := r.take(4)
+	if b == nil {
+		return 0
+	}
+	v, _ := ParseInt32(b)
+	return v
+}
+
+// Uint32 读取Uint32编码的值
+func (r *KeyReader) Uint32() uint32 {
+	b := r.take(4)
+	if b == nil {
+		return 0
+	}
+	v, _ := ParseUint32(b)
+	return v
+}
+
+// Time 读取Time编码的时间
+func (r *KeyReader) Time() time.Time {
+	b := r.take(8)
+	if b == nil {
+		return time.Time{}
+	}
+	v, _ := ParseInt64(b)
+	return time.UnixMilli(v)
+}
+
+// ReverseTime 读取ReverseTime编码的时间
+func (r *KeyReader) ReverseTime() time.Time {
+	b := r.take(8)
+	if b == nil {
+		return time.Time{}
+	}
+	t, _ := ParseReverseTimestamp(b)
+	return t
+}
+
+// Remaining 获取还没有读取的部分
+func (r *KeyReader) Remaining() []byte {
+	return r.buf
+}
+
+// Err 获取解析过程中的第一个错误
+func (r *KeyReader) Err() error {
+	return r.err
+}
@@ -0,0 +1,130 @@
+package rowkey
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestKeyEscaping(t *testing.T) {
+	for _, tc := range []struct {
+		field []byte
+		want  string
+	}{
+		{nil, "0001"},
+		{[]byte("ab"), "61620001"},
+		{[]byte{0x00}, "00ff0001"},
+		{[]byte{0x00, 0x00}, "00ff00ff0001"},
+		{[]byte{'a', 0x00, 'b'}, "6100ff620001"},
+		{[]byte{0x01, 0xff}, "01ff0001"},
+	} {
+		row := NewKey().Bytes(tc.field).Build()
+		if got := hex.EncodeToString(row); got != tc.want {
+			t.Errorf("Bytes(%x) = %s, want %s", tc.field, got, tc.want)
+			continue
+		}
+		r := NewKeyReader(append(row, 0x2a))
+		if got := r.Bytes(); r.Err() != nil || !bytes.Equal(got, tc.field) || got == nil {
+			t.Errorf("KeyReader.Bytes() = %x, %v, want %x", got, r.Err(), tc.field)
+		}
+		if rest := r.Remaining(); !bytes.Equal(rest, []byte{0x2a}) {
+			t.Errorf("Remaining() = %x", rest)
+		}
+	}
+}
+
+func TestKeyReaderErrors(t *testing.T) {
+	for _, tc := range []struct {
+		row  string
+		want error
+	}{
+		{"6162", ErrNotTerminated},
+		{"616200", ErrNotTerminated},
+		{"610002", ErrInvalidEscape},
+	} {
+		row, _ := hex.DecodeString(tc.row)
+		r := NewKeyReader(row)
+		if got := r.Bytes(); got != nil || !errors.Is(r.Err(), tc.want) {
+			t.Errorf("Bytes(%s) = %x, %v, want %v", tc.row, got, r.Err(), tc.want)
+		}
+		// 出错后不再继续读取
+		if r.Int64() != 0 || !errors.Is(r.Err(), tc.want) {
+			t.Errorf("Int64 after error: %v", r.Err())
+		}
+	}
+	r := NewKeyReader([]byte{1, 2, 3})
+	if r.Int32(); !errors.Is(r.Err(), ErrInvalidLength) {
+		t.Errorf("Int32 err = %v", r.Err())
+	}
+}
+
+func TestKeyOrder(t *testing.T) {
+	type fields struct {
+		s string
+		n int64
+	}
+	// 按字段依次比较的顺序排列,其中包含以0x00结尾和以其他字段为前缀的字符串
+	ordered := []fields{
+		{"", math.MinInt64},
+		{"", 0},
+		{"a", -1},
+		{"a", 0},
+		{"a\x00", math.MinInt64},
+		{"a\x00\x00", 0},
+		{"a\x00b", 0},
+		{"a\x01", 0},
+		{"ab", math.MinInt64},
+		{"ab", math.MaxInt64},
+		{"b", 0},
+		{"\xff", 0},
+	}
+	var prev []byte
+	for i, f := range ordered {
+		row := NewKey().Text(f.s).Int64(f.n).Build()
+		if i > 0 && bytes.Compare(prev, row) >= 0 {
+			t.Errorf("%q,%d 的行键%x不大于前一个%x", f.s, f.n, row, prev)
+		}
+		prev = row
+		r := NewKeyReader(row)
+		if s, n := r.Text(), r.Int64(); s != f.s || n != f.n || r.Err() != nil || len(r.Remaining()) != 0 {
+			t.Errorf("解析%x = %q,%d,%v", row, s, n, r.Err())
+		}
+	}
+}
+
+func TestKeyFixedFields(t *testing.T) {
+	ts := time.UnixMilli(1700000000123)
+	row := NewKey().Raw([]byte("p")).Int32(-1).Uint32(7).Uint64(9).Time(ts).ReverseTime(ts).Build()
+	r := NewKeyReader(row)
+	if got := r.Raw(1); string(got) != "p" {
+		t.Errorf("Raw = %q", got)
+	}
+	if got := r.Int32(); got != -1 {
+		t.Errorf("Int32 = %d", got)
+	}
+	if got := r.Uint32(); got != 7 {
+		t.Errorf("Uint32 = %d", got)
+	}
+	if got := r.Uint64(); got != 9 {
+		t.Errorf("Uint64 = %d", got)
+	}
+	if got := r.Time(); !got.Equal(ts) {
+		t.Errorf("Time = %v", got)
+	}
+	if got := r.ReverseTime(); !got.Equal(ts) {
+		t.Errorf("ReverseTime = %v", got)
+	}
+	if r.Err() != nil || len(r.Remaining()) != 0 {
+		t.Errorf("err = %v, remaining %x", r.Err(), r.Remaining())
+	}
+	// 越新的时间反转后越小
+	if bytes.Compare(ReverseTimestamp(ts), ReverseTimestamp(ts.Add(-time.Millisecond))) >= 0 {
+		t.Error("ReverseTimestamp顺序错误")
+	}
+	if got := hex.EncodeToString(Int64(-1)); got != "7fffffffffffffff" {
+		t.Errorf("Int64(-1) = %s", got)
+	}
+}
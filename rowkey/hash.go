@@ -0,0 +1,82 @@
+// 哈希前缀
+package rowkey
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+)
+
+// MD5Prefixed 以md5(key)的前n个十六进制小写字符作为前缀,n取值1到32,超出范围时按边界处理
+// 十六进制前缀的行键可以用HexStringSplit预分区
+func MD5Prefixed(key []byte, n int) []byte {
+	if n < 1 {
+		n = 1
+	}
+	if n > md5.Size*2 {
+		n = md5.Size * 2
+	}
+	sum := md5.Sum(key)
+	prefix := hex.EncodeToString(sum[:])[:n]
+	row := make([]byte, 0, n+len(key))
+	row = append(row, prefix...)
+	return append(row, key...)
+}
+
+// Murmur3Prefixed 以Murmur3(key, 0)大端序的前n个字节作为前缀,n取值1到4,超出范围时按边界处理
+func Murmur3Prefixed(key []byte, n int) []byte {
+	if n < 1 {
+		n = 1
+	}
+	if n > 4 {
+		n = 4
+	}
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], Murmur3(key, 0))
+	row := make([]byte, 0, n+len(key))
+	row = append(row, sum[:n]...)
+	return append(row, key...)
+}
+
+// Murmur3 计算MurmurHash3 x86 32位哈希,与Guava的Hashing.murmur3_32(seed)一致
+func Murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	h := seed
+	n := len(data) / 4
+	for i := 0; i < n; i++ {
+		k := binary.LittleEndian.Uint32(data[i*4:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	tail := data[n*4:]
+	var k uint32
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(data))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,105 @@
+// 行键设计工具
+// 单调递增的行键(时间戳,自增id等)会让写入集中在一个region上,本包提供常用的打散和编码方式:
+//
+//   - Salter 按行键计算固定的桶号作为1字节前缀,与Apache Phoenix的加盐方式一致,
+//     配合Client.SaltedScan可以按未加盐的行键顺序扫描
+//   - MD5Prefixed/Murmur3Prefixed 以行键的哈希作为前缀
+//   - ReverseTimestamp 反转时间戳,使最新的数据排在前面
+//   - Int64/Uint64等 定长大端序编码,有符号数翻转符号位,字节序与数值顺序一致
+//   - Key/KeyReader 保序的组合行键
+package rowkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 错误类型
+var (
+	//ErrInvalidBuckets 桶数必须在1到256之间
+	ErrInvalidBuckets = errors.New("桶数必须在1到256之间")
+	//ErrInvalidLength 行键长度不足
+	ErrInvalidLength = errors.New("行键长度不足")
+	//ErrNotTerminated 变长字段缺少分隔符
+	ErrNotTerminated = errors.New("变长字段缺少分隔符")
+	//ErrInvalidEscape 变长字段中的转义不合法
+	ErrInvalidEscape = errors.New("变长字段中的转义不合法")
+)
+
+// checkLen 检查长度
+func checkLen(b []byte, n int) error {
+	if len(b) < n {
+		return fmt.Errorf("%w: 需要%d字节,实际为%d字节", ErrInvalidLength, n, len(b))
+	}
+	return nil
+}
+
+// Int64 将int64编码为8字节大端序并翻转符号位,负数排在正数前面
+func Int64(v int64) []byte {
+	return Uint64(uint64(v) ^ (1 << 63))
+}
+
+// ParseInt64 解码Int64编码的值
+func ParseInt64(b []byte) (int64, error) {
+	u, err := ParseUint64(b)
+	return int64(u ^ (1 << 63)), err
+}
+
+// Uint64 将uint64编码为8字节大端序
+func Uint64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// ParseUint64 解码Uint64编码的值
+func ParseUint64(b []byte) (uint64, error) {
+	if err := checkLen(b, 8); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// Int32 将int32编码为4字节大端序并翻转符号位,负数排在正数前面
+func Int32(v int32) []byte {
+	return Uint32(uint32(v) ^ (1 << 31))
+}
+
+// ParseInt32 解码Int32编码的值
+func ParseInt32(b []byte) (int32, error) {
+	u, err := ParseUint32(b)
+	return int32(u ^ (1 << 31)), err
+}
+
+// Uint32 将uint32编码为4字节大端序
+func Uint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// ParseUint32 解码Uint32编码的值
+func ParseUint32(b []byte) (uint32, error) {
+	if err := checkLen(b, 4); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// ReverseTimestamp 将时间编码为Long.MAX_VALUE减去毫秒时间戳的8字节大端序,越新的时间字节序越小
+// 与Java中常用的Bytes.toBytes(Long.MAX_VALUE - ts)一致,只支持1970年之后的时间
+func ReverseTimestamp(t time.Time) []byte {
+	return Uint64(uint64(math.MaxInt64 - t.UnixMilli()))
+}
+
+// ParseReverseTimestamp 解码ReverseTimestamp编码的时间
+func ParseReverseTimestamp(b []byte) (time.Time, error) {
+	u, err := ParseUint64(b)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(math.MaxInt64 - int64(u)), nil
+}
@@ -0,0 +1,58 @@
+// 加盐
+package rowkey
+
+// Salter 按行键计算桶号,以1字节的桶号作为前缀打散行键
+// 桶号为Java的Arrays.hashCode(key)对桶数取模的绝对值,与Apache Phoenix的SALT_BUCKETS一致,同一行键总是落在同一个桶
+type Salter struct {
+	buckets int
+}
+
+// NewSalter 创建加盐器,buckets为桶数,取值1到256,一般与预分区的region数一致
+func NewSalter(buckets int) (*Salter, error) {
+	if buckets < 1 || buckets > 256 {
+		return nil, ErrInvalidBuckets
+	}
+	return &Salter{buckets: buckets}, nil
+}
+
+// Buckets 获取桶数
+func (s *Salter) Buckets() int {
+	return s.buckets
+}
+
+// Bucket 计算行键所在的桶
+func (s *Salter) Bucket(key []byte) byte {
+	h := int32(1)
+	for _, c := range key {
+		h = 31*h + int32(int8(c))
+	}
+	bucket := h % int32(s.buckets)
+	if bucket < 0 {
+		bucket = -bucket
+	}
+	return byte(bucket)
+}
+
+// Salt 在行键前加上桶号
+func (s *Salter) Salt(key []byte) []byte {
+	row := make([]byte, 0, len(key)+1)
+	row = append(row, s.Bucket(key))
+	return append(row, key...)
+}
+
+// Unsalt 去掉加盐行键的桶号前缀
+func (s *Salter) Unsalt(row []byte) []byte {
+	if len(row) == 0 {
+		return row
+	}
+	return row[1:]
+}
+
+// BucketPrefixes 获取所有桶的前缀,按桶号升序
+func (s *Salter) BucketPrefixes() [][]byte {
+	prefixes := make([][]byte, s.buckets)
+	for i := range prefixes {
+		prefixes[i] = []byte{byte(i)}
+	}
+	return prefixes
+}
@@ -0,0 +1,85 @@
+package rowkey
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// 期望的桶号按Apache Phoenix的SaltingUtil.getSaltingByte计算:
+// Math.abs(Arrays.hashCode(key) % buckets),其中字节按Java的有符号byte参与计算
+func TestSalterPhoenixGolden(t *testing.T) {
+	for _, tc := range []struct {
+		key     []byte
+		buckets int
+		want    byte
+	}{
+		{[]byte(""), 4, 1},
+		{[]byte("a"), 4, 0},
+		{[]byte("abc"), 10, 5},
+		{[]byte("row1"), 8, 0},
+		// hashCode为负数
+		{[]byte("user_000123"), 16, 11},
+		{[]byte("2024-01-01|device-42"), 256, 230},
+		// 大于0x7F的字节按负数参与计算
+		{[]byte{0xff, 0x80}, 10, 2},
+		{[]byte{0x00}, 3, 1},
+		{[]byte("the quick brown fox"), 7, 6},
+	} {
+		s, err := NewSalter(tc.buckets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Bucket(tc.key); got != tc.want {
+			t.Errorf("Bucket(%q, %d) = %d, want %d", tc.key, tc.buckets, got, tc.want)
+		}
+		salted := s.Salt(tc.key)
+		if salted[0] != tc.want || !bytes.Equal(s.Unsalt(salted), tc.key) {
+			t.Errorf("Salt(%q) = %x", tc.key, salted)
+		}
+	}
+}
+
+func TestSalterBuckets(t *testing.T) {
+	for _, n := range []int{0, -1, 257} {
+		if _, err := NewSalter(n); !errors.Is(err, ErrInvalidBuckets) {
+			t.Errorf("NewSalter(%d) err = %v", n, err)
+		}
+	}
+	s, err := NewSalter(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixes := s.BucketPrefixes()
+	if len(prefixes) != 256 || prefixes[0][0] != 0 || prefixes[255][0] != 255 {
+		t.Fatalf("BucketPrefixes() = %d个", len(prefixes))
+	}
+	if got := s.Unsalt(nil); len(got) != 0 {
+		t.Fatalf("Unsalt(nil) = %x", got)
+	}
+}
+
+func TestMurmur3(t *testing.T) {
+	// MurmurHash3 x86_32的参考实现和Guava Hashing.murmur3_32的输出
+	for _, tc := range []struct {
+		data string
+		seed uint32
+		want uint32
+	}{
+		{"", 0, 0},
+		{"", 1, 0x514e28b7},
+		{"hello", 0, 0x248bfa47},
+		{"The quick brown fox jumps over the lazy dog", 0, 0x2e4ff723},
+	} {
+		if got := Murmur3([]byte(tc.data), tc.seed); got != tc.want {
+			t.Errorf("Murmur3(%q, %d) = %08x, want %08x", tc.data, tc.seed, got, tc.want)
+		}
+	}
+	if got := Murmur3Prefixed([]byte("hello"), 2); !bytes.Equal(got, []byte("\x24\x8bhello")) {
+		t.Errorf("Murmur3Prefixed = %x", got)
+	}
+	// md5("hello") = 5d41402abc4b2a76b9719d911017c592
+	if got := string(MD5Prefixed([]byte("hello"), 4)); got != "5d41hello" {
+		t.Errorf("MD5Prefixed = %s", got)
+	}
+}
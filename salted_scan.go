@@ -0,0 +1,234 @@
+// 加盐表的有序扫描
+package aliexhbase
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+
+	"github.com/Golang-Tools/aliexhbase/builder"
+	"github.com/Golang-Tools/aliexhbase/filter"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/Golang-Tools/aliexhbase/rowkey"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// SaltedScanner 加盐表的行迭代器,对每个桶分别扫描,按去掉桶号后的行键顺序合并结果
+// 用法与Scanner一致,返回的TResult_.Row仍然带有桶号前缀,可以用Salter.Unsalt去掉
+type SaltedScanner struct {
+	scanners []rowIterator
+	reversed bool
+	// 前缀扫描时未加盐的行键前缀,服务端没有应用PrefixFilter时在合并时过滤
+	prefix   []byte
+	limit    int32
+	returned int32
+	started  bool
+	heap     saltedHeap
+	result   *hbase.TResult_
+	err      error
+}
+
+// rowIterator 单个桶的行迭代器
+type rowIterator interface {
+	Next() bool
+	Result() *hbase.TResult_
+	Err() error
+	Close() error
+}
+
+// saltedItem 某个桶的当前行
+type saltedItem struct {
+	scanner rowIterator
+	result  *hbase.TResult_
+}
+
+// saltedHeap 按去掉桶号后的行键排序的堆
+type saltedHeap struct {
+	items    []saltedItem
+	reversed bool
+}
+
+func (h *saltedHeap) Len() int { return len(h.items) }
+
+func (h *saltedHeap) Less(i, j int) bool {
+	c := bytes.Compare(h.items[i].result.Row[1:], h.items[j].result.Row[1:])
+	if h.reversed {
+		return c > 0
+	}
+	return c < 0
+}
+
+func (h *saltedHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *saltedHeap) Push(x interface{}) { h.items = append(h.items, x.(saltedItem)) }
+
+func (h *saltedHeap) Pop() interface{} {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
+
+// saltedBucketScan 构造单个桶的TScan,tscan中的起止行为未加盐的行键
+func saltedBucketScan(tscan *hbase.TScan, bucket []byte) *hbase.TScan {
+	salted := func(row []byte) []byte {
+		return append(append(make([]byte, 0, len(bucket)+len(row)), bucket...), row...)
+	}
+	s := *tscan
+	upper, lower := &s.StopRow, &s.StartRow
+	if s.GetReversed() {
+		upper, lower = &s.StartRow, &s.StopRow
+	}
+	if len(*upper) > 0 {
+		*upper = salted(*upper)
+	} else {
+		// 正向扫描的结束行不包含在内,正好是下一个桶的开始;
+		// 反向扫描的起始行包含在内,下一个桶只有桶号的行会在合并时过滤掉
+		*upper = builder.PrefixStopRow(bucket)
+	}
+	switch {
+	case len(*lower) > 0:
+		*lower = salted(*lower)
+	case !s.GetReversed():
+		*lower = bucket
+	default:
+		// 反向扫描的结束行不包含在内,以桶号为结束行会漏掉只有桶号的行;
+		// 与builder的反向前缀扫描一样不设结束行,由PrefixFilter在离开本桶后结束扫描
+		*lower = nil
+		prefixFilter := filter.PrefixFilter{Prefix: bucket}.String()
+		if len(s.FilterString) == 0 {
+			s.FilterString = []byte(prefixFilter)
+		} else {
+			s.FilterString = []byte(prefixFilter + " AND (" + string(s.FilterString) + ")")
+		}
+	}
+	return &s
+}
+
+// SaltedScan 扫描用rowkey.Salter加盐的表,按未加盐的行键顺序返回结果
+// tscan的StartRow/StopRow为未加盐的行键,每个桶会分别打开一个扫描器,Limit对合并后的结果生效.
+// 过滤器作用于加盐后的行键,行键相关的过滤器(如PrefixFilter)需要自行处理桶号,前缀扫描请使用SaltedPrefixScan.
+// 没有下界的反向扫描会在每个桶的过滤器前加上桶号的PrefixFilter.tscan为nil时扫描全表,任意一个桶出错时结束整个扫描
+func (p *Client) SaltedScan(ctx context.Context, table []byte, salter *rowkey.Salter, tscan *hbase.TScan, opts ...ScanOption) *SaltedScanner {
+	if tscan == nil {
+		tscan = &hbase.TScan{}
+	}
+	prefixes := salter.BucketPrefixes()
+	scanners := make([]rowIterator, len(prefixes))
+	for i, bucket := range prefixes {
+		scanners[i] = p.Scan(ctx, table, saltedBucketScan(tscan, bucket), opts...)
+	}
+	return newSaltedScanner(scanners, tscan.GetReversed(), tscan.GetLimit())
+}
+
+// saltedPrefixBucketScan 构造单个桶的前缀扫描,扫描以桶号加prefix开头的行
+func saltedPrefixBucketScan(bucket, prefix []byte, reversed bool) *hbase.TScan {
+	salted := append(append(make([]byte, 0, len(bucket)+len(prefix)), bucket...), prefix...)
+	if !reversed {
+		return &hbase.TScan{StartRow: salted, StopRow: builder.PrefixStopRow(salted)}
+	}
+	// 与builder的反向前缀扫描一样不设结束行,由PrefixFilter在离开前缀范围后结束扫描
+	return &hbase.TScan{
+		StartRow:     builder.PrefixStopRow(salted),
+		Reversed:     thrift.BoolPtr(true),
+		FilterString: []byte(filter.PrefixFilter{Prefix: salted}.String()),
+	}
+}
+
+// SaltedPrefixScan 扫描用rowkey.Salter加盐的表中未加盐的行键以prefix开头的行,按未加盐的行键顺序返回结果
+// 每个桶以桶号加prefix为前缀分别打开一个扫描器,reversed为true时反向扫描,任意一个桶出错时结束整个扫描
+func (p *Client) SaltedPrefixScan(ctx context.Context, table []byte, salter *rowkey.Salter, prefix []byte, reversed bool, opts ...ScanOption) *SaltedScanner {
+	prefixes := salter.BucketPrefixes()
+	scanners := make([]rowIterator, len(prefixes))
+	for i, bucket := range prefixes {
+		scanners[i] = p.Scan(ctx, table, saltedPrefixBucketScan(bucket, prefix, reversed), opts...)
+	}
+	s := newSaltedScanner(scanners, reversed, 0)
+	s.prefix = prefix
+	return s
+}
+
+// newSaltedScanner 合并各个桶的行迭代器,scanners按桶号排列
+func newSaltedScanner(scanners []rowIterator, reversed bool, limit int32) *SaltedScanner {
+	s := &SaltedScanner{scanners: scanners, reversed: reversed, limit: limit}
+	s.heap.reversed = reversed
+	return s
+}
+
+// advance 读取扫描器的下一行放入堆中,出错时记录错误
+func (s *SaltedScanner) advance(scanner rowIterator, bucket byte) bool {
+	for scanner.Next() {
+		r := scanner.Result()
+		// 反向扫描的起始行可能是下一个桶只有桶号的行,跳过不属于本桶的行
+		if len(r.Row) == 0 || r.Row[0] > bucket {
+			continue
+		}
+		// 反向扫描没有下界且服务端没有应用PrefixFilter(如设置了FilterBytes)时会读到前面的桶,已经离开本桶
+		if r.Row[0] < bucket {
+			scanner.Close()
+			return true
+		}
+		if s.prefix != nil && !bytes.HasPrefix(r.Row[1:], s.prefix) {
+			// 还没有进入前缀范围时跳过,已经离开前缀范围时结束本桶
+			if (bytes.Compare(r.Row[1:], s.prefix) > 0) == s.reversed {
+				continue
+			}
+			scanner.Close()
+			return true
+		}
+		heap.Push(&s.heap, saltedItem{scanner: scanner, result: r})
+		return true
+	}
+	if err := scanner.Err(); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+// Next 迭代到下一行,没有更多数据或出错时返回false,可以通过Err区分
+func (s *SaltedScanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		for i, scanner := range s.scanners {
+			if !s.advance(scanner, byte(i)) {
+				s.Close()
+				return false
+			}
+		}
+	}
+	if s.heap.Len() == 0 || (s.limit > 0 && s.returned >= s.limit) {
+		s.result = nil
+		s.Close()
+		return false
+	}
+	item := heap.Pop(&s.heap).(saltedItem)
+	s.result = item.result
+	s.returned++
+	if !s.advance(item.scanner, item.result.Row[0]) {
+		s.result = nil
+		s.Close()
+		return false
+	}
+	return true
+}
+
+// Result 获取当前行
+func (s *SaltedScanner) Result() *hbase.TResult_ {
+	return s.result
+}
+
+// Err 获取扫描过程中的错误,需要在Next返回false后调用
+func (s *SaltedScanner) Err() error {
+	return s.err
+}
+
+// Close 结束所有桶的扫描,可以重复调用
+func (s *SaltedScanner) Close() error {
+	for _, scanner := range s.scanners {
+		scanner.Close()
+	}
+	return s.err
+}
@@ -0,0 +1,361 @@
+package aliexhbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/Golang-Tools/aliexhbase/rowkey"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// fakeRows 返回固定行的单个桶的迭代器
+type fakeRows struct {
+	rows   [][]byte
+	err    error
+	pos    int
+	result *hbase.TResult_
+	closed bool
+}
+
+func (f *fakeRows) Next() bool {
+	if f.closed || f.pos >= len(f.rows) {
+		f.result = nil
+		return false
+	}
+	f.result = &hbase.TResult_{Row: f.rows[f.pos]}
+	f.pos++
+	return true
+}
+
+func (f *fakeRows) Result() *hbase.TResult_ { return f.result }
+
+func (f *fakeRows) Err() error {
+	if f.pos >= len(f.rows) {
+		return f.err
+	}
+	return nil
+}
+
+func (f *fakeRows) Close() error {
+	f.closed = true
+	return f.err
+}
+
+// saltedBuckets 将未加盐的行键加盐后按桶分组,每个桶内按扫描方向排列,模拟各个桶的扫描结果
+// 反向扫描时每个桶的第一行是下一个桶只有桶号的行
+func saltedBuckets(salter *rowkey.Salter, keys []string, reversed bool) []*fakeRows {
+	buckets := make([]*fakeRows, salter.Buckets())
+	for i := range buckets {
+		buckets[i] = &fakeRows{}
+	}
+	for _, key := range keys {
+		row := salter.Salt([]byte(key))
+		buckets[row[0]].rows = append(buckets[row[0]].rows, row)
+	}
+	for i, b := range buckets {
+		sort.Slice(b.rows, func(x, y int) bool {
+			c := bytes.Compare(b.rows[x], b.rows[y])
+			if reversed {
+				return c > 0
+			}
+			return c < 0
+		})
+		if reversed && i+1 < len(buckets) {
+			b.rows = append([][]byte{{byte(i + 1)}}, b.rows...)
+		}
+	}
+	return buckets
+}
+
+func mergeSalted(t *testing.T, salter *rowkey.Salter, buckets []*fakeRows, reversed bool, limit int32) ([]string, error) {
+	t.Helper()
+	scanners := make([]rowIterator, len(buckets))
+	for i, b := range buckets {
+		scanners[i] = b
+	}
+	s := newSaltedScanner(scanners, reversed, limit)
+	var keys []string
+	for s.Next() {
+		keys = append(keys, string(salter.Unsalt(s.Result().Row)))
+	}
+	if s.Next() {
+		t.Fatal("结束后Next返回了true")
+	}
+	for i, b := range buckets {
+		if !b.closed {
+			t.Fatalf("桶%d的扫描器没有关闭", i)
+		}
+	}
+	return keys, s.Err()
+}
+
+func TestSaltedScannerMerge(t *testing.T) {
+	salter, err := rowkey.NewSalter(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{""}
+	for i := 0; i < 40; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+	}
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	for _, tc := range []struct {
+		name     string
+		reversed bool
+		limit    int32
+		want     []string
+	}{
+		{"forward", false, 0, sorted},
+		{"forward limit", false, 7, sorted[:7]},
+		{"reverse", true, 0, reverseStrings(sorted)},
+		{"reverse limit", true, 7, reverseStrings(sorted)[:7]},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := mergeSalted(t, salter, saltedBuckets(salter, keys, tc.reversed), tc.reversed, tc.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("got %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSaltedScannerEmptyBuckets(t *testing.T) {
+	salter, err := rowkey.NewSalter(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := mergeSalted(t, salter, saltedBuckets(salter, []string{"only"}, false), false, 0)
+	if err != nil || fmt.Sprint(got) != "[only]" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	got, err = mergeSalted(t, salter, saltedBuckets(salter, nil, true), true, 0)
+	if err != nil || len(got) != 0 {
+		t.Fatalf("got %q, %v", got, err)
+	}
+}
+
+func TestSaltedScannerLeavesBucket(t *testing.T) {
+	salter, err := rowkey.NewSalter(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"", "a", "b", "c", "d", "e", "f", "g"}
+	buckets := saltedBuckets(salter, keys, true)
+	// 服务端没有应用PrefixFilter时反向扫描会继续读到前面的桶,前面的桶的第一行是本桶只有桶号的行,不再重复
+	for i := len(buckets) - 1; i > 0; i-- {
+		for j := i - 1; j >= 0; j-- {
+			buckets[i].rows = append(buckets[i].rows, saltedBuckets(salter, keys, true)[j].rows[1:]...)
+		}
+	}
+	got, err := mergeSalted(t, salter, buckets, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := reverseStrings(keys); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+	for i, b := range buckets {
+		if i > 0 && b.pos == len(b.rows) {
+			t.Errorf("桶%d离开本桶后继续读取了前面的桶", i)
+		}
+	}
+}
+
+func TestSaltedScan(t *testing.T) {
+	salter, err := rowkey.NewSalter(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{""}
+	for i := 0; i < 20; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+	}
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	h := newScanHandler(0)
+	for _, key := range keys {
+		h.rows = append(h.rows, salter.Salt([]byte(key)))
+	}
+	sort.Slice(h.rows, func(i, j int) bool { return bytes.Compare(h.rows[i], h.rows[j]) < 0 })
+	c := newTestClient(t, h)
+
+	for _, tc := range []struct {
+		name  string
+		tscan *hbase.TScan
+		want  []string
+	}{
+		{"nil", nil, sorted},
+		{"forward", &hbase.TScan{}, sorted},
+		{"forward range", &hbase.TScan{StartRow: []byte("k005"), StopRow: []byte("k010")}, sorted[6:11]},
+		// 假服务端不处理过滤器,反向扫描会读到前面的桶
+		{"reverse", &hbase.TScan{Reversed: thrift.BoolPtr(true)}, reverseStrings(sorted)},
+		{"reverse upper bound", &hbase.TScan{Reversed: thrift.BoolPtr(true), StartRow: []byte("k004")}, reverseStrings(sorted[:6])},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := c.SaltedScan(context.Background(), []byte("t"), salter, tc.tscan)
+			var got []string
+			for s.Next() {
+				got = append(got, string(salter.Unsalt(s.Result().Row)))
+			}
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("got %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSaltedPrefixScan(t *testing.T) {
+	salter, err := rowkey.NewSalter(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"", "k", "k01", "k02", "l"}
+	for i := 0; i < 30; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+	}
+	h := newScanHandler(0)
+	for _, key := range keys {
+		h.rows = append(h.rows, salter.Salt([]byte(key)))
+	}
+	sort.Slice(h.rows, func(i, j int) bool { return bytes.Compare(h.rows[i], h.rows[j]) < 0 })
+	c := newTestClient(t, h)
+	var want []string
+	for _, key := range keys {
+		if strings.HasPrefix(key, "k01") {
+			want = append(want, key)
+		}
+	}
+	sort.Strings(want)
+	for _, reversed := range []bool{false, true} {
+		// 假服务端不处理过滤器,反向扫描会读到前缀范围之外的行
+		s := c.SaltedPrefixScan(context.Background(), []byte("t"), salter, []byte("k01"), reversed, WithScanBatchSize(3))
+		var got []string
+		for s.Next() {
+			got = append(got, string(salter.Unsalt(s.Result().Row)))
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		expected := want
+		if reversed {
+			expected = reverseStrings(want)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Fatalf("reversed=%v: got %q\nwant %q", reversed, got, expected)
+		}
+	}
+}
+
+func TestSaltedPrefixBucketScan(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		bucket      []byte
+		reversed    bool
+		start, stop string
+		filter      string
+	}{
+		{"forward", []byte{3}, false, "036162", "036163", ""},
+		{"reverse", []byte{3}, true, "036163", "", "PrefixFilter('\x03ab')"},
+		{"forward last bucket", []byte{0xff}, false, "ff6162", "ff6163", ""},
+	} {
+		s := saltedPrefixBucketScan(tc.bucket, []byte("ab"), tc.reversed)
+		if got := fmt.Sprintf("%x", s.StartRow); got != tc.start {
+			t.Errorf("%s: StartRow = %s, want %s", tc.name, got, tc.start)
+		}
+		if got := fmt.Sprintf("%x", s.StopRow); got != tc.stop {
+			t.Errorf("%s: StopRow = %s, want %s", tc.name, got, tc.stop)
+		}
+		if got := string(s.FilterString); got != tc.filter {
+			t.Errorf("%s: FilterString = %q, want %q", tc.name, got, tc.filter)
+		}
+		if s.GetReversed() != tc.reversed {
+			t.Errorf("%s: Reversed = %v", tc.name, s.GetReversed())
+		}
+	}
+}
+
+func TestSaltedScannerError(t *testing.T) {
+	salter, err := rowkey.NewSalter(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for i := 0; i < 30; i++ {
+		keys = append(keys, fmt.Sprintf("k%03d", i))
+	}
+	buckets := saltedBuckets(salter, keys, false)
+	failure := errors.New("bucket failed")
+	buckets[1].rows = buckets[1].rows[:2]
+	buckets[1].err = failure
+	got, err := mergeSalted(t, salter, buckets, false, 0)
+	if !errors.Is(err, failure) {
+		t.Fatalf("Err() = %v, want %v", err, failure)
+	}
+	// 出错的桶读完之前返回的行仍然有序
+	if !sort.StringsAreSorted(got) {
+		t.Fatalf("rows not sorted: %q", got)
+	}
+}
+
+func TestSaltedBucketScan(t *testing.T) {
+	bucket := []byte{3}
+	for _, tc := range []struct {
+		name        string
+		tscan       *hbase.TScan
+		start, stop string
+		filter      string
+	}{
+		{"forward full", &hbase.TScan{}, "03", "04", ""},
+		{"forward range", &hbase.TScan{StartRow: []byte("a"), StopRow: []byte("b")}, "0361", "0362", ""},
+		{"forward filter", &hbase.TScan{FilterString: []byte("KeyOnlyFilter()")}, "03", "04", "KeyOnlyFilter()"},
+		// 没有下界的反向扫描不设结束行,由PrefixFilter限制在本桶内
+		{"reverse full", &hbase.TScan{Reversed: thrift.BoolPtr(true)}, "04", "", "PrefixFilter('\x03')"},
+		{"reverse upper bound", &hbase.TScan{Reversed: thrift.BoolPtr(true), StartRow: []byte("b")}, "0362", "", "PrefixFilter('\x03')"},
+		{"reverse filter", &hbase.TScan{Reversed: thrift.BoolPtr(true), FilterString: []byte("KeyOnlyFilter() OR FirstKeyOnlyFilter()")}, "04", "", "PrefixFilter('\x03') AND (KeyOnlyFilter() OR FirstKeyOnlyFilter())"},
+		{"reverse lower bound", &hbase.TScan{Reversed: thrift.BoolPtr(true), StopRow: []byte("a")}, "04", "0361", ""},
+		{"reverse range", &hbase.TScan{Reversed: thrift.BoolPtr(true), StartRow: []byte("b"), StopRow: []byte("a")}, "0362", "0361", ""},
+	} {
+		s := saltedBucketScan(tc.tscan, bucket)
+		if got := fmt.Sprintf("%x", s.StartRow); got != tc.start {
+			t.Errorf("%s: StartRow = %s, want %s", tc.name, got, tc.start)
+		}
+		if got := fmt.Sprintf("%x", s.StopRow); got != tc.stop {
+			t.Errorf("%s: StopRow = %s, want %s", tc.name, got, tc.stop)
+		}
+		if got := string(s.FilterString); got != tc.filter {
+			t.Errorf("%s: FilterString = %q, want %q", tc.name, got, tc.filter)
+		}
+	}
+	// 不修改调用方的TScan
+	tscan := &hbase.TScan{Reversed: thrift.BoolPtr(true), FilterString: []byte("KeyOnlyFilter()")}
+	saltedBucketScan(tscan, bucket)
+	if tscan.StartRow != nil || tscan.StopRow != nil || string(tscan.FilterString) != "KeyOnlyFilter()" {
+		t.Errorf("saltedBucketScan modified tscan: %+v", tscan)
+	}
+	// 最后一个桶的结束行为表尾
+	if s := saltedBucketScan(&hbase.TScan{}, []byte{0xff}); s.StopRow != nil {
+		t.Errorf("StopRow = %x, want nil", s.StopRow)
+	}
+}
+
+func reverseStrings(s []string) []string {
+	r := make([]string, len(s))
+	for i, v := range s {
+		r[len(s)-1-i] = v
+	}
+	return r
+}
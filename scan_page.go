@@ -0,0 +1,107 @@
+// 分页扫描
+package aliexhbase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// scanTokenVersion 分页令牌的格式版本
+const scanTokenVersion = 1
+
+// ScanPage 一页扫描结果
+type ScanPage struct {
+	// 本页的行
+	Results []*hbase.TResult_
+	// 获取下一页的令牌,为空表示没有更多数据
+	NextToken string
+}
+
+// encodeScanToken 将表名和下一页的TScan编码为令牌
+// 格式为base64(版本号 + uvarint(表名长度) + 表名 + thrift二进制协议序列化的TScan)
+func encodeScanToken(ctx context.Context, table []byte, tscan *hbase.TScan) (string, error) {
+	body, err := thrift.NewTSerializer().Write(ctx, tscan)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+len(table)+len(body))
+	buf = append(buf, scanTokenVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(table)))
+	buf = append(buf, table...)
+	buf = append(buf, body...)
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// decodeScanToken 解析令牌,令牌中的表名必须与table一致
+func decodeScanToken(token string, table []byte) (*hbase.TScan, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) == 0 || buf[0] != scanTokenVersion {
+		return nil, ErrScanTokenInvalid
+	}
+	buf = buf[1:]
+	n, size := binary.Uvarint(buf)
+	if size <= 0 || uint64(len(buf)-size) < n {
+		return nil, ErrScanTokenInvalid
+	}
+	buf = buf[size:]
+	if !bytes.Equal(buf[:n], table) {
+		return nil, ErrScanTokenInvalid
+	}
+	tscan := hbase.NewTScan()
+	if err := thrift.NewTDeserializer().Read(tscan, buf[n:]); err != nil {
+		return nil, ErrScanTokenInvalid
+	}
+	return tscan, nil
+}
+
+// ScanPage 分页扫描,token为空时从tscan开始扫描,tscan为nil时扫描全表,否则从令牌记录的位置继续扫描并忽略tscan
+// 每页最多返回pageSize行,服务端扫描器在请求结束时就会关闭,不会在请求之间保持.
+// 令牌记录了下一页的起始行,扫描方向和TScan的其他设置(列,过滤器,时间范围,剩余的Limit等),
+// 令牌没有签名,直接交给不受信任的调用方时需要自行校验或签名.不支持BatchSize
+func (p *Client) ScanPage(ctx context.Context, table []byte, tscan *hbase.TScan, pageSize int32, token string) (*ScanPage, error) {
+	if pageSize <= 0 {
+		return nil, ErrScanPageSize
+	}
+	if token != "" {
+		var err error
+		if tscan, err = decodeScanToken(token, table); err != nil {
+			return nil, err
+		}
+	} else if tscan == nil {
+		tscan = &hbase.TScan{}
+	}
+	if tscan.GetBatchSize() > 0 {
+		return nil, ErrScanPageBatchSize
+	}
+	// 多取一行,用它作为下一页的起始行,同时判断是否还有下一页
+	numRows := pageSize + 1
+	limit := tscan.GetLimit()
+	if limit > 0 && limit <= pageSize {
+		numRows = limit
+	}
+	results, err := p.GetScannerResults(ctx, table, tscan, numRows)
+	if err != nil {
+		return nil, err
+	}
+	page := &ScanPage{Results: results}
+	if int32(len(results)) <= pageSize {
+		return page, nil
+	}
+	page.Results = results[:pageSize]
+	next := *tscan
+	// 正向和反向扫描的StartRow都是包含在内的,直接使用多取的那一行
+	next.StartRow = results[pageSize].Row
+	if limit > 0 {
+		remaining := limit - pageSize
+		next.Limit = &remaining
+	}
+	if page.NextToken, err = encodeScanToken(ctx, table, &next); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,167 @@
+package aliexhbase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+func (h *scanHandler) GetScannerResults(ctx context.Context, table []byte, tscan *hbase.TScan, numRows int32) ([]*hbase.TResult_, error) {
+	id, err := h.OpenScanner(ctx, table, tscan)
+	if err != nil {
+		return nil, err
+	}
+	defer h.CloseScanner(ctx, id)
+	return h.GetScannerRows(ctx, id, numRows)
+}
+
+func TestScanTokenRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	tscan := &hbase.TScan{
+		StartRow:     []byte("row\x00\xff"),
+		StopRow:      []byte("row9"),
+		Columns:      []*hbase.TColumn{{Family: []byte("cf"), Qualifier: []byte("q")}},
+		FilterString: []byte("PrefixFilter('row')"),
+		TimeRange:    &hbase.TTimeRange{MinStamp: 1, MaxStamp: 2},
+		Reversed:     thrift.BoolPtr(true),
+		Limit:        thrift.Int32Ptr(7),
+	}
+	token, err := encodeScanToken(ctx, []byte("ns:t"), tscan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeScanToken(token, []byte("ns:t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, tscan) {
+		t.Fatalf("decoded %+v, want %+v", decoded, tscan)
+	}
+
+	raw, _ := base64.RawURLEncoding.DecodeString(token)
+	reencode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	unknownVersion := append([]byte{scanTokenVersion + 1}, raw[1:]...)
+	for _, tc := range []struct {
+		name  string
+		token string
+		table string
+	}{
+		{"other table", token, "t"},
+		{"table prefix", token, "ns:t2"},
+		{"not base64", "!!!", "ns:t"},
+		{"empty", reencode(nil), "ns:t"},
+		{"unknown version", reencode(unknownVersion), "ns:t"},
+		{"truncated table", reencode(raw[:3]), "ns:t"},
+		{"bad length", reencode([]byte{scanTokenVersion, 0xff}), "ns:t"},
+		{"truncated scan", reencode(raw[:len(raw)-3]), "ns:t"},
+	} {
+		if _, err := decodeScanToken(tc.token, []byte(tc.table)); !errors.Is(err, ErrScanTokenInvalid) {
+			t.Errorf("%s: err = %v, want ErrScanTokenInvalid", tc.name, err)
+		}
+	}
+}
+
+// scanPages 逐页扫描直到没有下一页,返回每页的行
+func scanPages(t *testing.T, c *Client, tscan *hbase.TScan, pageSize int32) [][]string {
+	t.Helper()
+	var pages [][]string
+	token := ""
+	for {
+		page, err := c.ScanPage(context.Background(), []byte("t"), tscan, pageSize, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rows []string
+		for _, r := range page.Results {
+			rows = append(rows, string(r.Row))
+		}
+		pages = append(pages, rows)
+		if page.NextToken == "" {
+			return pages
+		}
+		if len(pages) > 100 {
+			t.Fatal("分页没有结束")
+		}
+		token = page.NextToken
+	}
+}
+
+func TestScanPage(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		reversed bool
+		limit    int32
+		sizes    []int
+	}{
+		{"forward", false, 0, []int{5, 5, 5, 5, 3}},
+		{"reverse", true, 0, []int{5, 5, 5, 5, 3}},
+		// 剩余的Limit记录在令牌中
+		{"limit", false, 12, []int{5, 5, 2}},
+		{"limit multiple of page", false, 10, []int{5, 5}},
+		{"limit below page", false, 3, []int{3}},
+		{"reverse limit", true, 12, []int{5, 5, 2}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, newScanHandler(23))
+			tscan := &hbase.TScan{}
+			if tc.reversed {
+				tscan.Reversed = thrift.BoolPtr(true)
+			}
+			if tc.limit > 0 {
+				tscan.Limit = thrift.Int32Ptr(tc.limit)
+			}
+			pages := scanPages(t, c, tscan, 5)
+			var sizes []int
+			var rows []string
+			for _, page := range pages {
+				sizes = append(sizes, len(page))
+				rows = append(rows, page...)
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tc.sizes) {
+				t.Fatalf("page sizes %v, want %v", sizes, tc.sizes)
+			}
+			for i, row := range rows {
+				want := i
+				if tc.reversed {
+					want = 22 - i
+				}
+				if row != string(testRow(want)) {
+					t.Fatalf("第%d行为%s,期望%s", i, row, testRow(want))
+				}
+			}
+			if tscan.GetLimit() != tc.limit {
+				t.Fatal("原扫描被修改了")
+			}
+		})
+	}
+}
+
+func TestScanPageErrors(t *testing.T) {
+	c := newTestClient(t, newScanHandler(23))
+	ctx := context.Background()
+	if _, err := c.ScanPage(ctx, []byte("t"), &hbase.TScan{}, 0, ""); !errors.Is(err, ErrScanPageSize) {
+		t.Errorf("err = %v, want ErrScanPageSize", err)
+	}
+	if _, err := c.ScanPage(ctx, []byte("t"), &hbase.TScan{BatchSize: thrift.Int32Ptr(2)}, 5, ""); !errors.Is(err, ErrScanPageBatchSize) {
+		t.Errorf("err = %v, want ErrScanPageBatchSize", err)
+	}
+	page, err := c.ScanPage(ctx, []byte("t"), nil, 5, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Results) != 5 || page.NextToken == "" {
+		t.Fatalf("nil tscan: got %d rows, token %q", len(page.Results), page.NextToken)
+	}
+	// 令牌只能用于签发它的表
+	if _, err := c.ScanPage(ctx, []byte("t2"), nil, 5, page.NextToken); !errors.Is(err, ErrScanTokenInvalid) {
+		t.Errorf("err = %v, want ErrScanTokenInvalid", err)
+	}
+}
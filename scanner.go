@@ -0,0 +1,306 @@
+// 行迭代器
+package aliexhbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+)
+
+// defaultScanBatchSize 扫描时每批获取的默认行数
+const defaultScanBatchSize = 100
+
+// defaultScanMaxResumes 可恢复扫描默认的最大连续恢复次数
+const defaultScanMaxResumes = 3
+
+// scanOptions 扫描配置
+type scanOptions struct {
+	batchSize  int32
+	prefetch   int
+	resume     bool
+	maxResumes int
+}
+
+// ScanOption 扫描配置项
+type ScanOption func(*scanOptions)
+
+// WithScanBatchSize 设置每次从服务端获取的行数,默认使用TScan的Caching,未设置时为100
+func WithScanBatchSize(BatchSize int32) ScanOption {
+	return func(o *scanOptions) {
+		o.batchSize = BatchSize
+	}
+}
+
+// WithScanPrefetch 设置后台预取的批次数,默认为1.除了缓存的Prefetch批外,后台协程还会多获取一批等待放入缓存,
+// 因此调用方正在消费的批次之外最多提前获取Prefetch+1批,为0时也会提前获取一批
+func WithScanPrefetch(Prefetch int) ScanOption {
+	return func(o *scanOptions) {
+		o.prefetch = Prefetch
+	}
+}
+
+// WithScanResume 开启可恢复扫描,服务端扫描器失效,region迁移或传输层出错时从最后返回的行之后重新打开扫描器继续扫描,
+// 不会返回重复的行也不会遗漏行.MaxResumes为没有取得进展时的最大连续恢复次数,小于等于0时为3,
+// 恢复前按客户端重试策略的退避时间等待.可恢复扫描不支持TScan的BatchSize
+func WithScanResume(MaxResumes int) ScanOption {
+	return func(o *scanOptions) {
+		o.resume = true
+		o.maxResumes = MaxResumes
+	}
+}
+
+// Scanner 行迭代器,在后台分批获取扫描结果
+//
+//	s := client.Scan(ctx, table, tscan)
+//	defer s.Close()
+//	for s.Next() {
+//		r := s.Result()
+//	}
+//	if err := s.Err(); err != nil {
+//	}
+//
+// 扫描结束,出错或ctx被取消时会自动关闭服务端的扫描器,Close可以提前结束扫描
+type Scanner struct {
+	client  *Client
+	table   []byte
+	tscan   *hbase.TScan
+	opts    scanOptions
+	ctx     context.Context
+	cancel  context.CancelFunc
+	batches chan []*hbase.TResult_
+	done    chan struct{}
+	closing int32
+	once    sync.Once
+
+	// 最后返回的行和已返回的行数,只由后台协程读写,用于恢复扫描和保证不超过Limit
+	lastRow  []byte
+	returned int32
+
+	buf    []*hbase.TResult_
+	result *hbase.TResult_
+	// err 只在batches关闭前由后台协程写入
+	err error
+}
+
+// Scan 创建行迭代器扫描表,后台协程会立即开始获取数据
+// 使用完后需要调用Close,或者迭代到Next返回false.tscan为nil时扫描全表
+func (p *Client) Scan(ctx context.Context, table []byte, tscan *hbase.TScan, opts ...ScanOption) *Scanner {
+	if tscan == nil {
+		tscan = &hbase.TScan{}
+	}
+	o := scanOptions{prefetch: 1}
+	if tscan.Caching != nil && *tscan.Caching > 0 {
+		o.batchSize = *tscan.Caching
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.batchSize <= 0 {
+		o.batchSize = defaultScanBatchSize
+	}
+	if o.prefetch < 0 {
+		o.prefetch = 0
+	}
+	if o.maxResumes <= 0 {
+		o.maxResumes = defaultScanMaxResumes
+	}
+	s := &Scanner{
+		client:  p,
+		table:   table,
+		tscan:   tscan,
+		opts:    o,
+		batches: make(chan []*hbase.TResult_, o.prefetch),
+		done:    make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	go s.run()
+	return s
+}
+
+// run 后台获取扫描结果
+func (s *Scanner) run() {
+	defer close(s.done)
+	defer close(s.batches)
+	defer s.cancel()
+	if s.opts.resume && s.tscan.BatchSize != nil && *s.tscan.BatchSize > 0 {
+		s.setErr(ErrScanResumeBatchSize)
+		return
+	}
+	failures := 0
+	for {
+		progressed, err := s.scan()
+		if err == nil {
+			return
+		}
+		if progressed {
+			failures = 0
+		}
+		if !s.opts.resume || s.ctx.Err() != nil || !resumable(err) || failures >= s.opts.maxResumes {
+			s.setErr(err)
+			return
+		}
+		failures++
+		s.client.Opts.Logger.WithError(err).WithField("table", string(s.table)).WithField("attempt", failures).Warn("Resume hbase scanner")
+		if !s.client.Opts.Retry.wait(s.ctx, failures) {
+			s.setErr(err)
+			return
+		}
+	}
+}
+
+// resumable 判断扫描错误是否可以通过重新打开扫描器恢复
+func resumable(err error) bool {
+	return errors.Is(err, exceptions.ErrScannerExpired) ||
+		errors.Is(err, exceptions.ErrRegionUnavailable) ||
+		errors.Is(err, exceptions.ErrTransport) ||
+		errors.Is(err, exceptions.ErrTimeout)
+}
+
+// resumeScan 构造从最后返回的行之后继续扫描的TScan,已达到Limit时返回false
+// 正向扫描从最后一行之后的第一个可能的行键开始;
+// 反向扫描时比最后一行小的行键没有下确界,因此从最后一行开始并跳过它
+func (s *Scanner) resumeScan() (*hbase.TScan, bool) {
+	if s.lastRow == nil {
+		return s.tscan, true
+	}
+	tscan := *s.tscan
+	if tscan.Limit != nil && *tscan.Limit > 0 {
+		limit := *tscan.Limit - s.returned
+		if limit <= 0 {
+			return nil, false
+		}
+		if s.reversed() {
+			// 重复的最后一行也会占用服务端的limit
+			limit++
+		}
+		tscan.Limit = &limit
+	}
+	if s.reversed() {
+		tscan.StartRow = s.lastRow
+	} else {
+		tscan.StartRow = append(append(make([]byte, 0, len(s.lastRow)+1), s.lastRow...), 0)
+	}
+	return &tscan, true
+}
+
+// reversed 是否为反向扫描
+func (s *Scanner) reversed() bool {
+	return s.tscan.Reversed != nil && *s.tscan.Reversed
+}
+
+// scan 打开扫描器读取数据直到扫描结束或出错,progressed表示是否返回了新的行
+func (s *Scanner) scan() (progressed bool, err error) {
+	tscan, ok := s.resumeScan()
+	if !ok {
+		return false, nil
+	}
+	scannerID, err := s.client.OpenScanner(s.ctx, s.table, tscan)
+	if err != nil {
+		return false, err
+	}
+	defer s.closeScanner(scannerID)
+	skip := s.lastRow != nil && s.reversed()
+	for {
+		rows, err := s.client.GetScannerRows(s.ctx, scannerID, s.opts.batchSize)
+		if err != nil {
+			return progressed, err
+		}
+		if len(rows) == 0 {
+			return progressed, nil
+		}
+		if skip {
+			skip = false
+			if bytes.Equal(rows[0].Row, s.lastRow) {
+				rows = rows[1:]
+				if len(rows) == 0 {
+					continue
+				}
+			}
+		}
+		// 反向恢复时如果最后一行已经被删除,服务端会多返回一行,按剩余的Limit截断
+		if limit := s.tscan.GetLimit(); limit > 0 && int32(len(rows)) > limit-s.returned {
+			rows = rows[:limit-s.returned]
+			if len(rows) == 0 {
+				return progressed, nil
+			}
+		}
+		s.lastRow = rows[len(rows)-1].Row
+		s.returned += int32(len(rows))
+		progressed = true
+		select {
+		case s.batches <- rows:
+		case <-s.ctx.Done():
+			return progressed, s.ctx.Err()
+		}
+	}
+}
+
+// setErr 记录扫描错误,调用方主动Close导致的错误不记录,ctx被取消导致的错误记录为ctx的错误
+func (s *Scanner) setErr(err error) {
+	if atomic.LoadInt32(&s.closing) == 1 {
+		return
+	}
+	if ctxErr := s.ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	s.err = err
+}
+
+// closeScanner 关闭服务端扫描器,扫描的ctx可能已经被取消,因此使用新的ctx
+func (s *Scanner) closeScanner(scannerID int32) {
+	ctx, cancel := s.client.NewCtx()
+	defer cancel()
+	if err := s.client.CloseScanner(ctx, scannerID); err != nil {
+		s.client.Opts.Logger.WithError(err).WithField("scanner_id", scannerID).Warn("Close scanner error")
+	}
+}
+
+// Next 迭代到下一行,没有更多数据或出错时返回false,可以通过Err区分
+func (s *Scanner) Next() bool {
+	if len(s.buf) == 0 {
+		rows, ok := <-s.batches
+		if !ok {
+			// 后台协程关闭batches后马上关闭done,等待它以保证随后调用Err能拿到错误
+			<-s.done
+			s.result = nil
+			return false
+		}
+		s.buf = rows
+	}
+	s.result = s.buf[0]
+	s.buf[0] = nil
+	s.buf = s.buf[1:]
+	return true
+}
+
+// Result 获取当前行
+func (s *Scanner) Result() *hbase.TResult_ {
+	return s.result
+}
+
+// Err 获取扫描过程中的错误,需要在Next返回false后调用
+func (s *Scanner) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Close 结束扫描并等待服务端扫描器关闭,可以重复调用
+func (s *Scanner) Close() error {
+	s.once.Do(func() {
+		atomic.StoreInt32(&s.closing, 1)
+		s.cancel()
+		for range s.batches {
+		}
+	})
+	<-s.done
+	return s.err
+}
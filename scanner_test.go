@@ -0,0 +1,326 @@
+package aliexhbase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/exceptions"
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	"github.com/apache/thrift/lib/go/thrift"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// fakeScanner 假服务端上的扫描器状态
+type fakeScanner struct {
+	pos      int
+	reversed bool
+	limit    int32
+	sent     int32
+	stopRow  []byte
+	lastRow  []byte
+}
+
+// scanHandler 在内存中的有序行上实现扫描接口的假服务端
+type scanHandler struct {
+	hbase.THBaseService
+	mu       sync.Mutex
+	rows     [][]byte
+	scanners map[int32]*fakeScanner
+	nextID   int32
+	opens    int
+	fetches  int
+	// 将要返回这些行时让扫描器失效,每行只失效一次
+	expire map[string]bool
+	// 扫描器失效时同时删除它返回的最后一行,模拟恢复扫描前最后一行被删除
+	deleteLast bool
+}
+
+func newScanHandler(n int) *scanHandler {
+	h := &scanHandler{scanners: map[int32]*fakeScanner{}, expire: map[string]bool{}}
+	for i := 0; i < n; i++ {
+		h.rows = append(h.rows, testRow(i))
+	}
+	return h
+}
+
+func (h *scanHandler) OpenScanner(ctx context.Context, table []byte, tscan *hbase.TScan) (int32, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.opens++
+	h.nextID++
+	sc := &fakeScanner{reversed: tscan.GetReversed(), limit: tscan.GetLimit(), stopRow: tscan.StopRow}
+	if sc.reversed {
+		sc.pos = len(h.rows) - 1
+		if len(tscan.StartRow) > 0 {
+			for sc.pos >= 0 && bytes.Compare(h.rows[sc.pos], tscan.StartRow) > 0 {
+				sc.pos--
+			}
+		}
+	} else {
+		for sc.pos < len(h.rows) && bytes.Compare(h.rows[sc.pos], tscan.StartRow) < 0 {
+			sc.pos++
+		}
+	}
+	h.scanners[h.nextID] = sc
+	return h.nextID, nil
+}
+
+func (h *scanHandler) GetScannerRows(ctx context.Context, scannerID int32, numRows int32) ([]*hbase.TResult_, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fetches++
+	sc, ok := h.scanners[scannerID]
+	if !ok {
+		return nil, &hbase.TIllegalArgument{Message: thrift.StringPtr("Invalid scanner Id")}
+	}
+	var rows []*hbase.TResult_
+	for int32(len(rows)) < numRows && sc.pos >= 0 && sc.pos < len(h.rows) && (sc.limit <= 0 || sc.sent < sc.limit) {
+		row := h.rows[sc.pos]
+		if !sc.reversed && len(sc.stopRow) > 0 && bytes.Compare(row, sc.stopRow) >= 0 {
+			break
+		}
+		if h.expire[string(row)] {
+			delete(h.expire, string(row))
+			delete(h.scanners, scannerID)
+			if h.deleteLast && sc.lastRow != nil {
+				h.delete(sc.lastRow)
+			}
+			if len(rows) > 0 {
+				return rows, nil
+			}
+			return nil, &hbase.TIOError{Message: thrift.StringPtr("org.apache.hadoop.hbase.UnknownScannerException: lease expired")}
+		}
+		rows = append(rows, &hbase.TResult_{Row: row})
+		sc.lastRow = row
+		sc.sent++
+		if sc.reversed {
+			sc.pos--
+		} else {
+			sc.pos++
+		}
+	}
+	return rows, nil
+}
+
+func (h *scanHandler) CloseScanner(ctx context.Context, scannerID int32) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.scanners, scannerID)
+	return nil
+}
+
+// delete 删除一行,调用时必须持有锁
+func (h *scanHandler) delete(row []byte) {
+	for i, r := range h.rows {
+		if bytes.Equal(r, row) {
+			h.rows = append(h.rows[:i:i], h.rows[i+1:]...)
+			return
+		}
+	}
+}
+
+func testRow(i int) []byte {
+	return []byte(fmt.Sprintf("row%04d", i))
+}
+
+// newTestClient 创建连接到假服务端的客户端
+func newTestClient(t *testing.T, h hbase.THBaseService, opts ...Option) *Client {
+	t.Helper()
+	processor := hbase.NewTHBaseServiceProcessor(h)
+	factory := thrift.NewTBinaryProtocolFactoryDefault()
+	srv := httptest.NewServer(http.HandlerFunc(thrift.NewThriftHandlerFunc(processor, factory, factory)))
+	t.Cleanup(srv.Close)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	c, err := New(append([]Option{WithURL(srv.URL), WithLogger(logger)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.HardClose() })
+	return c
+}
+
+// collectRows 读取扫描的所有行
+func collectRows(s *Scanner) []string {
+	var rows []string
+	for s.Next() {
+		rows = append(rows, string(s.Result().Row))
+	}
+	return rows
+}
+
+func TestScannerErrAfterNext(t *testing.T) {
+	h := newScanHandler(50)
+	c := newTestClient(t, h)
+	for i := 0; i < 100; i++ {
+		h.expire[string(testRow(21))] = true
+		s := c.Scan(context.Background(), []byte("t"), &hbase.TScan{}, WithScanBatchSize(5))
+		rows := collectRows(s)
+		// Next返回false后马上调用Err必须拿到错误
+		if err := s.Err(); !errors.Is(err, exceptions.ErrScannerExpired) {
+			t.Fatalf("第%d次: Err() = %v, want ErrScannerExpired", i, err)
+		}
+		if len(rows) != 21 {
+			t.Fatalf("第%d次: 返回了%d行,期望21行", i, len(rows))
+		}
+		if err := s.Close(); !errors.Is(err, exceptions.ErrScannerExpired) {
+			t.Fatalf("第%d次: Close() = %v, want ErrScannerExpired", i, err)
+		}
+	}
+}
+
+func TestScannerEnd(t *testing.T) {
+	h := newScanHandler(23)
+	c := newTestClient(t, h)
+	s := c.Scan(context.Background(), []byte("t"), &hbase.TScan{}, WithScanBatchSize(5))
+	rows := collectRows(s)
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 23 || rows[0] != string(testRow(0)) || rows[22] != string(testRow(22)) {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+	if s.Next() {
+		t.Fatal("扫描结束后Next返回了true")
+	}
+}
+
+func TestScannerNilTScan(t *testing.T) {
+	c := newTestClient(t, newScanHandler(23))
+	s := c.Scan(context.Background(), []byte("t"), nil, WithScanBatchSize(5), WithScanResume(0))
+	rows := collectRows(s)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 23 {
+		t.Fatalf("nil tscan: got %d rows, want 23", len(rows))
+	}
+}
+
+func TestScannerResume(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		reversed bool
+		limit    int32
+		want     int
+	}{
+		{"forward", false, 0, 100},
+		{"reverse", true, 0, 100},
+		{"forward limit", false, 50, 50},
+		{"reverse limit", true, 50, 50},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newScanHandler(100)
+			// 批次中间和批次开头各失效一次,第二次失效发生在恢复后的扫描器上
+			for _, i := range []int{13, 14, 31, 60, 80} {
+				h.expire[string(testRow(i))] = true
+			}
+			c := newTestClient(t, h)
+			tscan := &hbase.TScan{}
+			if tc.reversed {
+				tscan.Reversed = thrift.BoolPtr(true)
+			}
+			if tc.limit > 0 {
+				tscan.Limit = thrift.Int32Ptr(tc.limit)
+			}
+			s := c.Scan(context.Background(), []byte("t"), tscan, WithScanBatchSize(7), WithScanResume(0))
+			rows := collectRows(s)
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != tc.want {
+				t.Fatalf("返回了%d行,期望%d行", len(rows), tc.want)
+			}
+			for i, row := range rows {
+				want := i
+				if tc.reversed {
+					want = 99 - i
+				}
+				if row != string(testRow(want)) {
+					t.Fatalf("第%d行为%s,期望%s", i, row, testRow(want))
+				}
+			}
+			if h.opens < 2 {
+				t.Fatalf("扫描器只打开了%d次", h.opens)
+			}
+		})
+	}
+}
+
+func TestScannerResumeLastRowDeleted(t *testing.T) {
+	for _, reversed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reversed=%v", reversed), func(t *testing.T) {
+			h := newScanHandler(100)
+			h.deleteLast = true
+			expire := 40
+			if reversed {
+				expire = 59
+			}
+			h.expire[string(testRow(expire))] = true
+			c := newTestClient(t, h)
+			tscan := &hbase.TScan{Limit: thrift.Int32Ptr(50)}
+			if reversed {
+				tscan.Reversed = thrift.BoolPtr(true)
+			}
+			s := c.Scan(context.Background(), []byte("t"), tscan, WithScanBatchSize(10), WithScanResume(0))
+			rows := collectRows(s)
+			if err := s.Err(); err != nil {
+				t.Fatal(err)
+			}
+			// 恢复前最后返回的行被删除后,恢复的扫描器不会再返回它,也不能因此超过Limit
+			if len(rows) != 50 {
+				t.Fatalf("返回了%d行,期望50行", len(rows))
+			}
+			seen := map[string]bool{}
+			for i, row := range rows {
+				if seen[row] {
+					t.Fatalf("第%d行%s重复", i, row)
+				}
+				seen[row] = true
+				if i > 0 && (row < rows[i-1]) != reversed {
+					t.Fatalf("第%d行%s顺序错误", i, row)
+				}
+			}
+		})
+	}
+}
+
+func TestScannerPrefetch(t *testing.T) {
+	for _, prefetch := range []int{0, 1, 3} {
+		t.Run(fmt.Sprintf("prefetch=%d", prefetch), func(t *testing.T) {
+			h := newScanHandler(100)
+			c := newTestClient(t, h)
+			s := c.Scan(context.Background(), []byte("t"), &hbase.TScan{}, WithScanBatchSize(5), WithScanPrefetch(prefetch))
+			defer s.Close()
+			if !s.Next() {
+				t.Fatal(s.Err())
+			}
+			fetches := func() int {
+				h.mu.Lock()
+				defer h.mu.Unlock()
+				return h.fetches
+			}
+			// 正在消费的一批,缓存的prefetch批,以及等待放入缓存的一批
+			want := prefetch + 2
+			deadline := time.Now().Add(5 * time.Second)
+			for fetches() < want {
+				if time.Now().After(deadline) {
+					t.Fatalf("只获取了%d批,期望%d批", fetches(), want)
+				}
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if n := fetches(); n != want {
+				t.Fatalf("提前获取了%d批,期望%d批", n, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// 慢操作日志
+package aliexhbase
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	logrus "github.com/sirupsen/logrus"
+)
+
+// KeyFormat 慢操作日志中行键的记录格式
+type KeyFormat int8
+
+const (
+	// KeyFormat_Text 按带转义的文本记录
+	KeyFormat_Text KeyFormat = iota
+	// KeyFormat_Hex 按16进制记录
+	KeyFormat_Hex
+)
+
+// defaultSlowLogMaxKeyLen 慢操作日志中行键默认最多记录的字节数
+const defaultSlowLogMaxKeyLen = 64
+
+// slowLogMaxColumns 慢操作日志中最多记录的列数
+const slowLogMaxColumns = 16
+
+// SlowLogConfig 慢操作日志配置
+type SlowLogConfig struct {
+	// 耗时超过该值的操作会记录日志,为0时不记录
+	Threshold time.Duration
+	// 慢操作的采样率,取值范围(0,1],为0时记录全部慢操作
+	SampleRate float64
+	// 行键的记录格式
+	KeyFormat KeyFormat
+	// 行键最多记录的字节数,超出部分会被截断,为0时使用默认值64
+	MaxKeyLen int
+	// 隐藏行键和过滤器中的值,只记录长度
+	Redact bool
+}
+
+// sampled 判断这次慢操作是否需要记录
+func (c *SlowLogConfig) sampled() bool {
+	if c.SampleRate <= 0 || c.SampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < c.SampleRate
+}
+
+// formatKey 按配置格式化行键
+func (c *SlowLogConfig) formatKey(key []byte) string {
+	if c.Redact {
+		return fmt.Sprintf("<redacted %d bytes>", len(key))
+	}
+	maxLen := c.MaxKeyLen
+	if maxLen <= 0 {
+		maxLen = defaultSlowLogMaxKeyLen
+	}
+	truncated := len(key) > maxLen
+	if truncated {
+		key = key[:maxLen]
+	}
+	var s string
+	if c.KeyFormat == KeyFormat_Hex {
+		s = hex.EncodeToString(key)
+	} else {
+		s = fmt.Sprintf("%q", key)
+	}
+	if truncated {
+		s += "..."
+	}
+	return s
+}
+
+// formatFilter 按配置格式化过滤器字符串,隐藏值时将单引号中的常量替换为`?`
+func (c *SlowLogConfig) formatFilter(filter []byte) string {
+	if !c.Redact {
+		return string(filter)
+	}
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(filter); i++ {
+		ch := filter[i]
+		if ch != '\'' {
+			if !quoted {
+				b.WriteByte(ch)
+			}
+			continue
+		}
+		if quoted && i+1 < len(filter) && filter[i+1] == '\'' {
+			// 两个连续的单引号是转义后的单引号
+			i++
+			continue
+		}
+		if quoted {
+			b.WriteString("?'")
+		} else {
+			b.WriteByte('\'')
+		}
+		quoted = !quoted
+	}
+	return b.String()
+}
+
+// formatColumns 将列格式化为`family:qualifier`的列表
+func formatColumns(n int, column func(i int) (family, qualifier []byte)) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i == slowLogMaxColumns {
+			fmt.Fprintf(&b, ",...(%d more)", n-i)
+			break
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		family, qualifier := column(i)
+		b.Write(family)
+		if len(qualifier) > 0 {
+			b.WriteByte(':')
+			b.Write(qualifier)
+		}
+	}
+	return b.String()
+}
+
+// summarize 提取请求参数的摘要
+func (c *SlowLogConfig) summarize(op *Operation) logrus.Fields {
+	fields := logrus.Fields{}
+	for _, arg := range op.Args {
+		switch a := arg.(type) {
+		case *hbase.TGet:
+			if a == nil {
+				continue
+			}
+			if len(a.Columns) > 0 {
+				fields["columns"] = formatColumns(len(a.Columns), func(i int) ([]byte, []byte) {
+					return a.Columns[i].Family, a.Columns[i].Qualifier
+				})
+			}
+			if len(a.FilterString) > 0 {
+				fields["filter"] = c.formatFilter(a.FilterString)
+			}
+			if a.MaxVersions != nil {
+				fields["max_versions"] = *a.MaxVersions
+			}
+		case []*hbase.TGet:
+			fields["gets"] = len(a)
+		case *hbase.TScan:
+			if a == nil {
+				continue
+			}
+			fields["start_row"] = c.formatKey(a.StartRow)
+			fields["stop_row"] = c.formatKey(a.StopRow)
+			if len(a.Columns) > 0 {
+				fields["columns"] = formatColumns(len(a.Columns), func(i int) ([]byte, []byte) {
+					return a.Columns[i].Family, a.Columns[i].Qualifier
+				})
+			}
+			if len(a.FilterString) > 0 {
+				fields["filter"] = c.formatFilter(a.FilterString)
+			}
+			if a.Caching != nil {
+				fields["caching"] = *a.Caching
+			}
+			if a.Limit != nil {
+				fields["limit"] = *a.Limit
+			}
+			if a.BatchSize != nil {
+				fields["batch_size"] = *a.BatchSize
+			}
+			if a.Reversed != nil && *a.Reversed {
+				fields["reversed"] = true
+			}
+		case *hbase.TPut:
+			if a == nil {
+				continue
+			}
+			fields["columns"] = formatColumns(len(a.ColumnValues), func(i int) ([]byte, []byte) {
+				return a.ColumnValues[i].Family, a.ColumnValues[i].Qualifier
+			})
+		case []*hbase.TPut:
+			fields["puts"] = len(a)
+		case *hbase.TDelete:
+			if a == nil {
+				continue
+			}
+			if len(a.Columns) > 0 {
+				fields["columns"] = formatColumns(len(a.Columns), func(i int) ([]byte, []byte) {
+					return a.Columns[i].Family, a.Columns[i].Qualifier
+				})
+			}
+		case []*hbase.TDelete:
+			fields["deletes"] = len(a)
+		}
+	}
+	return fields
+}
+
+// slowLog 执行操作,耗时超过阈值时记录日志
+func (p *Client) slowLog(ctx context.Context, op *Operation, invoke Invoker) error {
+	c := &p.Opts.SlowLog
+	start := nowFunc()
+	err := invoke(ctx, op)
+	elapsed := nowFunc().Sub(start)
+	if elapsed < c.Threshold || !c.sampled() {
+		return err
+	}
+	fields := c.summarize(op)
+	fields["operation"] = op.Name
+	if len(op.Table) > 0 {
+		fields["table"] = string(op.Table)
+	}
+	if op.Row != nil {
+		fields["row"] = c.formatKey(op.Row)
+	}
+	fields["elapsed_ms"] = float64(elapsed) / float64(time.Millisecond)
+	fields["pool_wait_ms"] = float64(op.PoolWait) / float64(time.Millisecond)
+	fields["attempts"] = op.Attempts
+	logger := p.Opts.Logger.WithFields(fields)
+	if err != nil {
+		logger = logger.WithError(err)
+	}
+	logger.Warn("Slow hbase operation")
+	return err
+}
@@ -0,0 +1,134 @@
+package aliexhbase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Golang-Tools/aliexhbase/gen-go/hbase"
+	logrus "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// slowInterceptor 行键为slow的操作耗时超过阈值,不实际发出请求
+func slowInterceptor(err error) Interceptor {
+	return func(ctx context.Context, op *Operation, invoke Invoker) error {
+		if string(op.Row) == "slow" {
+			time.Sleep(60 * time.Millisecond)
+		}
+		op.Attempts = 1
+		return err
+	}
+}
+
+func newSlowLogClient(t *testing.T, err error, opts ...Option) (*Client, *test.Hook) {
+	t.Helper()
+	logger, hook := test.NewNullLogger()
+	c := newTestClient(t, tableHandler{}, append([]Option{WithSlowLogThresholdMS(50), WithInterceptors(slowInterceptor(err))}, opts...)...)
+	c.Opts.Logger = logger
+	return c, hook
+}
+
+func TestSlowLogThreshold(t *testing.T) {
+	c, hook := newSlowLogClient(t, nil)
+	ctx := context.Background()
+	tget := func(row string) *hbase.TGet {
+		return &hbase.TGet{
+			Row:          []byte(row),
+			Columns:      []*hbase.TColumn{{Family: []byte("cf"), Qualifier: []byte("q")}, {Family: []byte("cf2")}},
+			FilterString: []byte("ValueFilter(=, 'binary:secret')"),
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(ctx, []byte("t"), tget("fast")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 快的操作不记录
+	if n := len(hook.AllEntries()); n != 0 {
+		t.Fatalf("记录了%d条日志", n)
+	}
+	if _, err := c.Get(ctx, []byte("t"), tget("slow")); err != nil {
+		t.Fatal(err)
+	}
+	entries := hook.AllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("记录了%d条日志,期望1条", len(entries))
+	}
+	e := entries[0]
+	if e.Level != logrus.WarnLevel || e.Message != "Slow hbase operation" {
+		t.Fatalf("unexpected entry %v %q", e.Level, e.Message)
+	}
+	for key, want := range map[string]interface{}{
+		"operation": "Get",
+		"table":     "t",
+		"row":       `"slow"`,
+		"columns":   "cf:q,cf2",
+		"filter":    "ValueFilter(=, 'binary:secret')",
+		"attempts":  1,
+	} {
+		if got := e.Data[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+	if elapsed, _ := e.Data["elapsed_ms"].(float64); elapsed < 50 {
+		t.Errorf("elapsed_ms = %v", e.Data["elapsed_ms"])
+	}
+	if _, ok := e.Data[logrus.ErrorKey]; ok {
+		t.Error("成功的操作记录了错误")
+	}
+}
+
+func TestSlowLogError(t *testing.T) {
+	failure := errors.New("boom")
+	c, hook := newSlowLogClient(t, failure, WithSlowLogRedact())
+	if err := c.Put(context.Background(), []byte("t"), &hbase.TPut{Row: []byte("slow")}); !errors.Is(err, failure) {
+		t.Fatalf("err = %v", err)
+	}
+	// 失败的快操作也不记录
+	if err := c.Put(context.Background(), []byte("t"), &hbase.TPut{Row: []byte("fast")}); !errors.Is(err, failure) {
+		t.Fatalf("err = %v", err)
+	}
+	entries := hook.AllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("记录了%d条日志,期望1条", len(entries))
+	}
+	e := entries[0]
+	if e.Data["operation"] != "Put" || e.Data["row"] != "<redacted 4 bytes>" {
+		t.Fatalf("unexpected fields %v", e.Data)
+	}
+	if err, _ := e.Data[logrus.ErrorKey].(error); !errors.Is(err, failure) {
+		t.Fatalf("error = %v", e.Data[logrus.ErrorKey])
+	}
+}
+
+func TestSlowLogFormat(t *testing.T) {
+	c := SlowLogConfig{MaxKeyLen: 4}
+	if got := c.formatKey([]byte("ab\x00cdef")); got != `"ab\x00c"...` {
+		t.Errorf("formatKey = %s", got)
+	}
+	c.KeyFormat = KeyFormat_Hex
+	if got := c.formatKey([]byte{0xde, 0xad}); got != "dead" {
+		t.Errorf("formatKey = %s", got)
+	}
+	c.Redact = true
+	if got := c.formatKey([]byte("secret")); got != "<redacted 6 bytes>" {
+		t.Errorf("formatKey = %s", got)
+	}
+	// 单引号中的常量被隐藏,转义的单引号也在常量中
+	if got := c.formatFilter([]byte("SingleColumnValueFilter('cf', 'q', =, 'binary:it''s') AND PrefixFilter('a')")); got != "SingleColumnValueFilter('?', '?', =, '?') AND PrefixFilter('?')" {
+		t.Errorf("formatFilter = %s", got)
+	}
+	var columns []*hbase.TColumn
+	for i := 0; i < slowLogMaxColumns+2; i++ {
+		columns = append(columns, &hbase.TColumn{Family: []byte("f")})
+	}
+	got := formatColumns(len(columns), func(i int) ([]byte, []byte) {
+		return columns[i].Family, columns[i].Qualifier
+	})
+	if want := strings.Repeat("f,", slowLogMaxColumns-1) + "f,...(2 more)"; got != want {
+		t.Errorf("formatColumns = %s, want %s", got, want)
+	}
+}
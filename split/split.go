@@ -0,0 +1,159 @@
+// 预分区的分割点生成
+// 生成的分割点可以直接作为Client.CreateTable/CreateTableWithSplits的splitKeys,n个region需要n-1个分割点:
+//
+//   - HexString 十六进制字符串行键均匀分割,与HBase的HexStringSplit一致,适用于rowkey.MD5Prefixed
+//   - UniformBytes 任意字节行键均匀分割,与HBase的UniformSplit类似
+//   - Decimal 定长十进制字符串行键均匀分割
+//   - SaltBuckets 每个rowkey.Salter的桶一个region
+//   - Sample 按已有行键的分位点分割
+package split
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/Golang-Tools/aliexhbase/rowkey"
+)
+
+// 错误类型
+var (
+	//ErrInvalidRegions region数必须大于0
+	ErrInvalidRegions = errors.New("region数必须大于0")
+	//ErrInvalidWidth 行键宽度不合法
+	ErrInvalidWidth = errors.New("行键宽度不合法")
+	//ErrTooManyRegions region数超过了行键空间的大小
+	ErrTooManyRegions = errors.New("region数超过了行键空间的大小")
+	//ErrEmptySplitKey 分割点为空
+	ErrEmptySplitKey = errors.New("分割点不能为空")
+	//ErrSplitKeysNotSorted 分割点没有按升序排列
+	ErrSplitKeysNotSorted = errors.New("分割点必须按字节序升序排列")
+	//ErrDuplicateSplitKey 分割点重复
+	ErrDuplicateSplitKey = errors.New("分割点重复")
+)
+
+// uniform 将[0,space)均匀分为regions份,返回regions-1个分割点
+func uniform(space *big.Int, regions int) ([]*big.Int, error) {
+	if regions < 1 {
+		return nil, ErrInvalidRegions
+	}
+	if big.NewInt(int64(regions)).Cmp(space) > 0 {
+		return nil, fmt.Errorf("%w: %d > %s", ErrTooManyRegions, regions, space)
+	}
+	step := new(big.Int).Div(space, big.NewInt(int64(regions)))
+	points := make([]*big.Int, 0, regions-1)
+	for i := 1; i < regions; i++ {
+		points = append(points, new(big.Int).Mul(step, big.NewInt(int64(i))))
+	}
+	return points, nil
+}
+
+// HexString 生成width个十六进制小写字符的分割点,将"00..0"到"ff..f"均匀分为regions个region
+// width为8时与HBase的HexStringSplit一致,width取值1到32
+func HexString(regions, width int) ([][]byte, error) {
+	if width < 1 || width > 32 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidWidth, width)
+	}
+	space := new(big.Int).Lsh(big.NewInt(1), uint(4*width))
+	points, err := uniform(space, regions)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, len(points))
+	for i, p := range points {
+		s := p.Text(16)
+		keys[i] = []byte(strings.Repeat("0", width-len(s)) + s)
+	}
+	return keys, nil
+}
+
+// UniformBytes 生成width字节的分割点,将字节空间均匀分为regions个region,width取值1到16
+func UniformBytes(regions, width int) ([][]byte, error) {
+	if width < 1 || width > 16 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidWidth, width)
+	}
+	space := new(big.Int).Lsh(big.NewInt(1), uint(8*width))
+	points, err := uniform(space, regions)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, len(points))
+	for i, p := range points {
+		keys[i] = p.FillBytes(make([]byte, width))
+	}
+	return keys, nil
+}
+
+// Decimal 生成width位补零十进制字符串的分割点,将"00..0"到"99..9"均匀分为regions个region,width取值1到38
+func Decimal(regions, width int) ([][]byte, error) {
+	if width < 1 || width > 38 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidWidth, width)
+	}
+	space := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(width)), nil)
+	points, err := uniform(space, regions)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([][]byte, len(points))
+	for i, p := range points {
+		s := p.String()
+		keys[i] = []byte(strings.Repeat("0", width-len(s)) + s)
+	}
+	return keys, nil
+}
+
+// SaltBuckets 生成每个桶一个region的分割点,即除0号桶外每个桶的前缀
+func SaltBuckets(salter *rowkey.Salter) [][]byte {
+	return salter.BucketPrefixes()[1:]
+}
+
+// Sample 按行键样本的分位点生成分割点,样本不需要有序,重复的分割点会被去掉,因此结果可能少于regions-1个
+func Sample(keys [][]byte, regions int) ([][]byte, error) {
+	if regions < 1 {
+		return nil, ErrInvalidRegions
+	}
+	sorted := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		if len(key) > 0 {
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	var splits [][]byte
+	for i := 1; i < regions && len(sorted) > 0; i++ {
+		key := sorted[i*len(sorted)/regions]
+		// 第一个分割点不能是最小的样本,否则第一个region为空
+		if bytes.Equal(key, sorted[0]) {
+			continue
+		}
+		if len(splits) > 0 && bytes.Equal(splits[len(splits)-1], key) {
+			continue
+		}
+		splits = append(splits, append([]byte(nil), key...))
+	}
+	return splits, nil
+}
+
+// Validate 校验分割点非空,按字节序升序排列且没有重复
+func Validate(keys [][]byte) error {
+	for i, key := range keys {
+		if len(key) == 0 {
+			return fmt.Errorf("%w: 第%d个", ErrEmptySplitKey, i)
+		}
+		if i == 0 {
+			continue
+		}
+		switch bytes.Compare(keys[i-1], key) {
+		case 0:
+			return fmt.Errorf("%w: 第%d个%q", ErrDuplicateSplitKey, i, key)
+		case 1:
+			return fmt.Errorf("%w: 第%d个%q小于前一个%q", ErrSplitKeysNotSorted, i, key, keys[i-1])
+		}
+	}
+	return nil
+}
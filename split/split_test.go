@@ -0,0 +1,161 @@
+package split
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Golang-Tools/aliexhbase/rowkey"
+)
+
+func TestHexString(t *testing.T) {
+	for _, tc := range []struct {
+		regions, width int
+		want           string
+	}{
+		// 与HBase的new HexStringSplit().split(n)一致
+		{4, 8, "[40000000 80000000 c0000000]"},
+		{3, 8, "[55555555 aaaaaaaa]"},
+		{2, 8, "[80000000]"},
+		{1, 8, "[]"},
+		{4, 2, "[40 80 c0]"},
+	} {
+		keys, err := HexString(tc.regions, tc.width)
+		if err != nil {
+			t.Errorf("HexString(%d, %d): %v", tc.regions, tc.width, err)
+			continue
+		}
+		if got := fmt.Sprintf("%s", keys); got != tc.want {
+			t.Errorf("HexString(%d, %d) = %s, want %s", tc.regions, tc.width, got, tc.want)
+		}
+		if err := Validate(keys); err != nil {
+			t.Errorf("HexString(%d, %d): %v", tc.regions, tc.width, err)
+		}
+	}
+}
+
+func TestUniformBytesAndDecimal(t *testing.T) {
+	keys, err := UniformBytes(4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%x", keys); got != "[40 80 c0]" {
+		t.Errorf("UniformBytes(4, 1) = %s", got)
+	}
+	keys, err = UniformBytes(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%x", keys); got != "[8000]" {
+		t.Errorf("UniformBytes(2, 2) = %s", got)
+	}
+	keys, err = Decimal(4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%s", keys); got != "[250 500 750]" {
+		t.Errorf("Decimal(4, 3) = %s", got)
+	}
+	keys, err = Decimal(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%s", keys); got != "[33 66]" {
+		t.Errorf("Decimal(3, 2) = %s", got)
+	}
+}
+
+func TestSaltBuckets(t *testing.T) {
+	salter, err := rowkey.NewSalter(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprintf("%x", SaltBuckets(salter)); got != "[01 02 03]" {
+		t.Errorf("SaltBuckets = %s", got)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"HexString regions 0", second(HexString(0, 8)), ErrInvalidRegions},
+		{"HexString width 0", second(HexString(4, 0)), ErrInvalidWidth},
+		{"HexString width 33", second(HexString(4, 33)), ErrInvalidWidth},
+		{"HexString too many", second(HexString(17, 1)), ErrTooManyRegions},
+		{"UniformBytes width 17", second(UniformBytes(4, 17)), ErrInvalidWidth},
+		{"UniformBytes too many", second(UniformBytes(257, 1)), ErrTooManyRegions},
+		{"Decimal width 39", second(Decimal(4, 39)), ErrInvalidWidth},
+		{"Decimal too many", second(Decimal(11, 1)), ErrTooManyRegions},
+		{"Sample regions 0", second(Sample(nil, 0)), ErrInvalidRegions},
+	} {
+		if !errors.Is(tc.err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, tc.err, tc.want)
+		}
+	}
+}
+
+func second(_ [][]byte, err error) error {
+	return err
+}
+
+func TestSample(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		keys    []string
+		regions int
+		want    string
+	}{
+		{"quantiles", []string{"h", "b", "f", "d", "a", "c", "g", "e"}, 4, "[c e g]"},
+		// 重复的分割点只保留一个
+		{"dedup", []string{"a", "b", "b", "b", "b", "b", "b", "c"}, 4, "[b]"},
+		// 最小的样本不能作为分割点
+		{"skip min", []string{"a", "a", "a", "a", "a", "a", "b", "c"}, 4, "[b]"},
+		// 空行键被忽略
+		{"empty keys", []string{"", "", "a", "b"}, 2, "[b]"},
+		{"no keys", nil, 4, "[]"},
+		{"one region", []string{"a", "b"}, 1, "[]"},
+	} {
+		keys := make([][]byte, len(tc.keys))
+		for i, k := range tc.keys {
+			keys[i] = []byte(k)
+		}
+		splits, err := Sample(keys, tc.regions)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := fmt.Sprintf("%s", splits); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+		if err := Validate(splits); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		keys []string
+		want error
+	}{
+		{"ok", []string{"a", "b", "c"}, nil},
+		{"none", nil, nil},
+		{"empty", []string{"a", ""}, ErrEmptySplitKey},
+		{"duplicate", []string{"a", "b", "b"}, ErrDuplicateSplitKey},
+		{"unsorted", []string{"b", "a"}, ErrSplitKeysNotSorted},
+		// 按字节序而不是按长度比较
+		{"prefix", []string{"ab", "a"}, ErrSplitKeysNotSorted},
+	} {
+		keys := make([][]byte, len(tc.keys))
+		for i, k := range tc.keys {
+			keys[i] = []byte(k)
+		}
+		if err := Validate(keys); !errors.Is(err, tc.want) || (err == nil) != (tc.want == nil) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
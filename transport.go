@@ -0,0 +1,183 @@
+// 传输层定义
+package aliexhbase
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// TransportType thrift传输层类型
+type TransportType uint8
+
+const (
+	// Transport_HTTP 基于http的传输层,阿里云增强版hbase使用这种方式
+	Transport_HTTP TransportType = iota
+	// Transport_Buffered 基于tcp socket的带缓冲传输层,对应hbase thrift2服务的默认配置
+	Transport_Buffered
+	// Transport_Framed 基于tcp socket的分帧传输层,对应hbase thrift2服务开启了`hbase.regionserver.thrift.framed`
+	Transport_Framed
+)
+
+// ProtocolType thrift协议类型
+type ProtocolType uint8
+
+const (
+	// Protocol_Binary 二进制协议
+	Protocol_Binary ProtocolType = iota
+	// Protocol_Compact 压缩协议,对应hbase thrift2服务开启了`hbase.regionserver.thrift.compact`
+	Protocol_Compact
+)
+
+// socket传输层的缓冲区大小
+const socketBufferSize = 8192
+
+// schemeTransports url的schema与传输层类型的对应关系
+var schemeTransports = map[string]TransportType{
+	"http":            Transport_HTTP,
+	"https":           Transport_HTTP,
+	"thrift":          Transport_Buffered,
+	"thrift+buffered": Transport_Buffered,
+	"thrift+framed":   Transport_Framed,
+}
+
+// parseTransportScheme 根据url的schema确定传输层类型
+func parseTransportScheme(scheme string) (TransportType, error) {
+	t, ok := schemeTransports[strings.ToLower(scheme)]
+	if !ok {
+		return Transport_HTTP, ErrUnsupportedScheme
+	}
+	return t, nil
+}
+
+// parseProtocol 根据名字确定协议类型
+func parseProtocol(name string) (ProtocolType, error) {
+	switch strings.ToLower(name) {
+	case "", "binary":
+		return Protocol_Binary, nil
+	case "compact":
+		return Protocol_Compact, nil
+	default:
+		return Protocol_Binary, ErrUnsupportedProtocol
+	}
+}
+
+// protocolFactory 构造协议对应的thrift协议工厂
+func (p ProtocolType) protocolFactory() thrift.TProtocolFactory {
+	if p == Protocol_Compact {
+		return thrift.NewTCompactProtocolFactory()
+	}
+	return thrift.NewTBinaryProtocolFactoryDefault()
+}
+
+// newTransport 根据连接池配置构造thrift传输层,http传输层使用httpClient发送请求,认证由httpClient负责
+func newTransport(config *ThriftPoolConfig, httpClient *http.Client) (thrift.TTransport, error) {
+	switch config.Transport {
+	case Transport_Buffered, Transport_Framed:
+		socket, err := thrift.NewTSocketTimeout(config.Addr, config.ConnTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if err := socket.Open(); err != nil {
+			return nil, err
+		}
+		// ConnTimeout只作为建立连接的超时时间,读写超时单独设置
+		socket.SetTimeout(config.SocketTimeout)
+		if config.Transport == Transport_Framed {
+			return thrift.NewTFramedTransport(socket), nil
+		}
+		return thrift.NewTBufferedTransport(socket, socketBufferSize), nil
+	default:
+		transport, err := thrift.NewTHttpClientWithOptions(config.Addr, thrift.THttpClientOptions{Client: httpClient})
+		if err != nil {
+			return nil, err
+		}
+		if err := transport.Open(); err != nil {
+			return nil, err
+		}
+		return transport, nil
+	}
+}
+
+// parseURL 解析形如`schema://[user:pwd@]host:port[/path][?protocol=compact]`的地址
+// 返回去掉了认证信息和协议参数后的地址,http传输层为完整的url,socket传输层为`host:port`
+func parseURL(URL string) (config *ThriftPoolConfig, err error) {
+	U, err := url.Parse(URL)
+	if err != nil {
+		return nil, err
+	}
+	config = &ThriftPoolConfig{}
+	if config.Transport, err = parseTransportScheme(U.Scheme); err != nil {
+		return nil, err
+	}
+	query := U.Query()
+	if config.Protocol, err = parseProtocol(query.Get("protocol")); err != nil {
+		return nil, err
+	}
+	if U.User != nil {
+		config.User = U.User.Username()
+		config.Passwd, _ = U.User.Password()
+	}
+	if config.Transport != Transport_HTTP {
+		config.Addr = U.Host
+		return config, nil
+	}
+	U.User = nil
+	if _, ok := query["protocol"]; ok {
+		query.Del("protocol")
+		U.RawQuery = query.Encode()
+	}
+	config.Addr = U.String()
+	return config, nil
+}
+
+// NewHTTPClient 根据连接池配置构造thrift http传输层使用的http客户端
+func (c *ThriftPoolConfig) NewHTTPClient() *http.Client {
+	return &http.Client{Transport: c.newRoundTripper()}
+}
+
+// newRoundTripper 构造http传输层使用的RoundTripper,需要认证时会在外层包装认证策略,中间件包装在最外层
+func (c *ThriftPoolConfig) newRoundTripper() http.RoundTripper {
+	rt := c.RoundTripper
+	if rt == nil {
+		rt = c.newHTTPTransport()
+	}
+	if auth := c.authenticator(); auth != NoAuth {
+		rt = &authTransport{base: rt, auth: auth}
+	}
+	for i := len(c.HTTPMiddlewares) - 1; i >= 0; i-- {
+		rt = c.HTTPMiddlewares[i](rt)
+	}
+	return rt
+}
+
+// newHTTPTransport 根据配置构造http.Transport
+func (c *ThriftPoolConfig) newHTTPTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   c.ConnTimeout,
+		KeepAlive: c.KeepAlive,
+	}
+	// 默认每个host只保持2个空闲连接,连接池中的连接并发请求时会不断重建tcp连接,因此默认与最大连接数保持一致
+	maxIdleConnsPerHost := c.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = int(c.MaxConn)
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       c.IdleTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       c.TLSConfig,
+	}
+	if c.Proxy != nil {
+		transport.Proxy = http.ProxyURL(c.Proxy)
+	}
+	return transport
+}